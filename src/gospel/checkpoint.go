@@ -0,0 +1,21 @@
+package gospel
+
+import "context"
+
+// CheckpointStore is an interface for persisting the address that a consumer
+// has reached while reading from an event store.
+//
+// A checkpoint is typically the nx address returned by Reader.Next() after the
+// consumer has finished handling the current fact. Consumers resume reading by
+// passing their checkpoint to EventStore.Open().
+type CheckpointStore interface {
+	// LoadCheckpoint returns the address most recently saved for the consumer
+	// with the given name.
+	//
+	// ok is false if no checkpoint has been saved for the consumer.
+	LoadCheckpoint(ctx context.Context, name string) (addr Address, ok bool, err error)
+
+	// SaveCheckpoint saves addr as the checkpoint for the consumer with the
+	// given name, replacing any existing checkpoint.
+	SaveCheckpoint(ctx context.Context, name string, addr Address) error
+}
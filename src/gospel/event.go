@@ -4,6 +4,21 @@ package gospel
 //
 // Events are appended to a named stream to produce facts.
 type Event struct {
+	// ID is an optional application-defined identifier for the event, such as
+	// a UUID. It must be unique within the store.
+	//
+	// When an append contains events with IDs that have already been
+	// appended, the append is treated as a retry of the earlier append. If
+	// every event in the append has an ID, and they were previously appended
+	// together to the same stream in the same order, the append succeeds
+	// without producing any new facts, and the next address after the original
+	// append is returned. Otherwise, the append fails with a conflict.
+	//
+	// Two events in the same append can not have the same ID. Such an append
+	// fails with an error that is not a conflict, as retrying it can never
+	// succeed.
+	ID string
+
 	// EventType is the kind of event that occurred.
 	//
 	// It is typically expressed as a human-readable verb in the past-tense,
@@ -20,6 +35,15 @@ type Event struct {
 	// Body is application-defined binary data containing the specifics of the
 	// event.
 	Body []byte
+
+	// Metadata is a set of application-defined key/value pairs that describe
+	// the context in which the event occurred, such as correlation and
+	// causation IDs, the user or tenant responsible, or tracing information.
+	//
+	// Metadata is stored alongside the event, and is not interpreted by the
+	// event store. An empty map is equivalent to nil, and is returned as nil
+	// when the event is read.
+	Metadata map[string]string
 }
 
 func (f Event) String() string {
@@ -15,6 +15,9 @@ type EventStore interface {
 	// nx is the address of the next unused offset after the facts have been
 	// appended.
 	//
+	// If two events in ev have the same ID, the append fails, and
+	// IsConflict(err) returns false. See Event.ID.
+	//
 	// Append panics if ev is empty.
 	Append(ctx context.Context, addr Address, ev ...Event) (nx Address, err error)
 
@@ -27,13 +30,60 @@ type EventStore interface {
 	// nx is the address of the next unused offset after the facts have been
 	// appended.
 	//
+	// If two events in ev have the same ID, the append fails, and
+	// IsConflict(err) returns false. See Event.ID.
+	//
 	// AppendUnchecked panics if ev is empty.
 	AppendUnchecked(ctx context.Context, stream string, ev ...Event) (nx Address, err error)
 
+	// AppendExpected atomically writes one or more events to the end of a
+	// stream, producing a contiguous block of facts.
+	//
+	// The stream must satisfy the expectation e, otherwise the append fails,
+	// and IsConflict(err) returns true.
+	//
+	// nx is the address of the next unused offset after the facts have been
+	// appended.
+	//
+	// If two events in ev have the same ID, the append fails, and
+	// IsConflict(err) returns false. See Event.ID.
+	//
+	// AppendExpected panics if ev is empty.
+	AppendExpected(ctx context.Context, stream string, e Expectation, ev ...Event) (nx Address, err error)
+
+	// AppendMulti atomically writes events to one or more streams. Either
+	// all of the events are appended, or none of them are.
+	//
+	// Each stream must satisfy the expectation in its StreamAppend, otherwise
+	// the append fails, and IsConflict(err) returns true. The address
+	// returned by the error's ConflictDetails() method identifies the stream
+	// that conflicted.
+	//
+	// The appends are performed in the order given. If the same stream appears
+	// more than once, each expectation is checked against the state of the
+	// stream after the preceding appends.
+	//
+	// nx contains the address of the next unused offset of each stream after
+	// its facts have been appended, in the same order as a.
+	//
+	// If two events in the same element of a have the same ID, the append
+	// fails, and IsConflict(err) returns false. See Event.ID.
+	//
+	// AppendMulti panics if a is empty, if any element of a has no events, or
+	// if any element of a refers to the ε-stream.
+	AppendMulti(ctx context.Context, a ...StreamAppend) (nx []Address, err error)
+
 	// Open returns a reader that begins reading facts at addr.
 	//
 	// ctx applies to the opening of the reader, and not to the reader itself.
 	Open(ctx context.Context, addr Address, opts ...ReaderOption) (Reader, error)
+
+	// StreamInfo returns information about the current state of a stream,
+	// including the next unused offset.
+	//
+	// It does not return an error if the stream does not exist, instead
+	// info.Exists is false.
+	StreamInfo(ctx context.Context, stream string) (info StreamInfo, err error)
 }
 
 // IsConflict returns true if err indicates that an EventStore.Append() call
@@ -47,5 +97,16 @@ func IsConflict(err error) bool {
 // Use IsConflict() to check for conflicts.
 type ConflictError interface {
 	error
+
+	// ConflictDetails returns the address at which the append was attempted,
+	// and the event that could not be appended.
 	ConflictDetails() (Address, Event)
+
+	// ConflictHead returns the next unused offset of the stream at the time of
+	// the conflict. It can be used to resume reading the stream from the
+	// point at which the conflicting facts begin.
+	//
+	// exists is false if the stream did not exist at the time of the conflict,
+	// in which case nx.Offset is zero.
+	ConflictHead() (nx Address, exists bool)
 }
@@ -0,0 +1,107 @@
+package gospel
+
+import "strconv"
+
+// ExpectationMode is an enumeration of the kinds of condition that can be
+// placed on the state of a stream when appending to it.
+type ExpectationMode int
+
+const (
+	// AnyOffset is an expectation mode that is satisfied by any stream,
+	// whether or not it exists. It is equivalent to EventStore.AppendUnchecked().
+	AnyOffset ExpectationMode = iota
+
+	// ExactOffset is an expectation mode that is satisfied when the next
+	// unused offset of the stream is equal to Expectation.Offset. It is
+	// equivalent to EventStore.Append().
+	ExactOffset
+
+	// NoStream is an expectation mode that is satisfied when the stream does
+	// not exist, that is, when no facts have been appended to it.
+	NoStream
+
+	// StreamExists is an expectation mode that is satisfied when at least one
+	// fact has been appended to the stream, regardless of its next unused
+	// offset.
+	StreamExists
+
+	// MaxOffset is an expectation mode that is satisfied when the next unused
+	// offset of the stream is less than or equal to Expectation.Offset.
+	MaxOffset
+)
+
+// Expectation is a condition on the state of a stream that must be satisfied
+// for an append to succeed.
+type Expectation struct {
+	// Mode is the kind of condition.
+	Mode ExpectationMode
+
+	// Offset is the offset that the stream's next unused offset is compared
+	// against. It is only used by the ExactOffset and MaxOffset modes.
+	Offset uint64
+}
+
+// ExpectAnyOffset returns an expectation that is satisfied by any stream.
+func ExpectAnyOffset() Expectation {
+	return Expectation{Mode: AnyOffset}
+}
+
+// ExpectOffset returns an expectation that is satisfied when the next unused
+// offset of the stream is equal to offset.
+func ExpectOffset(offset uint64) Expectation {
+	return Expectation{Mode: ExactOffset, Offset: offset}
+}
+
+// ExpectNoStream returns an expectation that is satisfied when the stream does
+// not exist.
+func ExpectNoStream() Expectation {
+	return Expectation{Mode: NoStream}
+}
+
+// ExpectStreamExists returns an expectation that is satisfied when the stream
+// exists, regardless of its next unused offset.
+func ExpectStreamExists() Expectation {
+	return Expectation{Mode: StreamExists}
+}
+
+// ExpectOffsetAtMost returns an expectation that is satisfied when the next
+// unused offset of the stream is less than or equal to offset.
+func ExpectOffsetAtMost(offset uint64) Expectation {
+	return Expectation{Mode: MaxOffset, Offset: offset}
+}
+
+// IsSatisfiedBy returns true if a stream with the given next unused offset
+// satisfies the expectation.
+func (e Expectation) IsSatisfiedBy(next uint64) bool {
+	switch e.Mode {
+	case AnyOffset:
+		return true
+	case ExactOffset:
+		return next == e.Offset
+	case NoStream:
+		return next == 0
+	case StreamExists:
+		return next > 0
+	case MaxOffset:
+		return next <= e.Offset
+	default:
+		panic("unrecognized expectation mode: " + strconv.Itoa(int(e.Mode)))
+	}
+}
+
+func (e Expectation) String() string {
+	switch e.Mode {
+	case AnyOffset:
+		return "any offset"
+	case ExactOffset:
+		return "offset " + strconv.FormatUint(e.Offset, 10)
+	case NoStream:
+		return "no stream"
+	case StreamExists:
+		return "stream exists"
+	case MaxOffset:
+		return "offset at most " + strconv.FormatUint(e.Offset, 10)
+	default:
+		return "unrecognized expectation mode: " + strconv.Itoa(int(e.Mode))
+	}
+}
@@ -0,0 +1,47 @@
+package gospel_test
+
+import (
+	. "github.com/jmalloc/gospel/src/gospel"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Expectation", func() {
+	DescribeTable(
+		"IsSatisfiedBy",
+		func(e Expectation, next uint64, expected bool) {
+			Expect(e.IsSatisfiedBy(next)).To(Equal(expected))
+		},
+		Entry("any offset, stream does not exist", ExpectAnyOffset(), uint64(0), true),
+		Entry("any offset, stream exists", ExpectAnyOffset(), uint64(5), true),
+		Entry("exact offset, equal", ExpectOffset(5), uint64(5), true),
+		Entry("exact offset, lower", ExpectOffset(5), uint64(4), false),
+		Entry("exact offset, higher", ExpectOffset(5), uint64(6), false),
+		Entry("no stream, stream does not exist", ExpectNoStream(), uint64(0), true),
+		Entry("no stream, stream exists", ExpectNoStream(), uint64(1), false),
+		Entry("stream exists, stream does not exist", ExpectStreamExists(), uint64(0), false),
+		Entry("stream exists, stream exists", ExpectStreamExists(), uint64(1), true),
+		Entry("offset at most, lower", ExpectOffsetAtMost(5), uint64(4), true),
+		Entry("offset at most, equal", ExpectOffsetAtMost(5), uint64(5), true),
+		Entry("offset at most, higher", ExpectOffsetAtMost(5), uint64(6), false),
+	)
+
+	It("panics if the mode is not recognized", func() {
+		Expect(func() {
+			Expectation{Mode: -1}.IsSatisfiedBy(0)
+		}).To(Panic())
+	})
+
+	DescribeTable(
+		"String",
+		func(e Expectation, expected string) {
+			Expect(e.String()).To(Equal(expected))
+		},
+		Entry("any offset", ExpectAnyOffset(), "any offset"),
+		Entry("exact offset", ExpectOffset(5), "offset 5"),
+		Entry("no stream", ExpectNoStream(), "no stream"),
+		Entry("stream exists", ExpectStreamExists(), "stream exists"),
+		Entry("offset at most", ExpectOffsetAtMost(5), "offset at most 5"),
+	)
+})
@@ -7,6 +7,14 @@ type Fact struct {
 	// Addr identifies the fact by its stream and position within that stream.
 	Addr Address
 
+	// Origin is the address of the fact on the named stream that its event was
+	// originally appended to.
+	//
+	// For facts on the ε-stream, it identifies the same event on its
+	// originating stream. For facts on a named stream, and for facts recorded
+	// by the event store itself, such as "$stream.created", it is equal to Addr.
+	Origin Address
+
 	// Time is the time at which the fact was created.
 	//
 	// This does not necessarily correlate with the time at which the event
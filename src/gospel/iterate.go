@@ -0,0 +1,78 @@
+package gospel
+
+import "context"
+
+// ForEach calls fn for each fact read from r, in order.
+//
+// nx is the address that r has reached after reading f. Once fn has handled f,
+// nx can be persisted as a checkpoint from which to resume reading in a future
+// call to EventStore.Open().
+//
+// It blocks until r reaches the end of the range it was opened to read, ctx is
+// canceled, or an error occurs. It returns nil if r reaches the end of its
+// range, otherwise it returns the error returned by r or fn. r is not closed.
+func ForEach(
+	ctx context.Context,
+	r Reader,
+	fn func(f Fact, nx Address) error,
+) error {
+	for {
+		nx, err := r.Next(ctx)
+		if err == ErrEndOfRange {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err := fn(r.Get(), nx); err != nil {
+			return err
+		}
+	}
+}
+
+// Item is a fact that has been read by Channel.
+type Item struct {
+	// Fact is the fact that was read.
+	Fact Fact
+
+	// Next is the address that the reader reached after reading Fact. Once the
+	// fact has been handled, it can be persisted as a checkpoint from which to
+	// resume reading in a future call to EventStore.Open().
+	Next Address
+}
+
+// Channel starts a goroutine that reads facts from r and sends them on the
+// returned items channel, in order.
+//
+// buffer is the capacity of the items channel. The goroutine stops reading when
+// r reaches the end of the range it was opened to read, ctx is canceled, or an
+// error occurs. It then closes the items channel and sends a single value on
+// the result channel: nil if r reached the end of its range, otherwise the
+// error that caused it to stop.
+//
+// r is owned by the goroutine until a value is received from the result
+// channel, at which point the caller is responsible for closing it.
+func Channel(
+	ctx context.Context,
+	r Reader,
+	buffer int,
+) (items <-chan Item, result <-chan error) {
+	ich := make(chan Item, buffer)
+	rch := make(chan error, 1)
+
+	go func() {
+		err := ForEach(ctx, r, func(f Fact, nx Address) error {
+			select {
+			case ich <- Item{f, nx}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+
+		close(ich)
+		rch <- err
+	}()
+
+	return ich, rch
+}
@@ -0,0 +1,189 @@
+package gospel_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	. "github.com/jmalloc/gospel/src/gospel"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// sliceReader is a Reader that reads from a fixed set of facts.
+type sliceReader struct {
+	facts []Fact
+	index int
+	err   error // error to return once the facts are exhausted
+}
+
+func newSliceReader(n int, err error) *sliceReader {
+	r := &sliceReader{err: err}
+
+	for i := 0; i < n; i++ {
+		r.facts = append(r.facts, Fact{
+			Addr:  Address{Stream: "test-stream", Offset: uint64(i)},
+			Event: Event{EventType: "event-type-" + strconv.Itoa(i)},
+		})
+	}
+
+	r.index = -1
+
+	return r
+}
+
+func (r *sliceReader) Next(ctx context.Context) (Address, error) {
+	if r.index+1 < len(r.facts) {
+		r.index++
+		return r.facts[r.index].Addr.Next(), nil
+	}
+
+	if r.err != nil {
+		return Address{}, r.err
+	}
+
+	<-ctx.Done()
+	return Address{}, ctx.Err()
+}
+
+func (r *sliceReader) TryNext(ctx context.Context) (Address, bool, error) {
+	panic("not implemented")
+}
+
+func (r *sliceReader) Get() Fact {
+	return r.facts[r.index]
+}
+
+func (r *sliceReader) Close() error {
+	return nil
+}
+
+var _ = Describe("ForEach", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+	)
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 5*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("calls the function for each fact, with the next address", func() {
+		r := newSliceReader(3, ErrEndOfRange)
+
+		var offsets, nexts []uint64
+
+		err := ForEach(ctx, r, func(f Fact, nx Address) error {
+			offsets = append(offsets, f.Addr.Offset)
+			nexts = append(nexts, nx.Offset)
+			return nil
+		})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(offsets).To(Equal([]uint64{0, 1, 2}))
+		Expect(nexts).To(Equal([]uint64{1, 2, 3}))
+	})
+
+	It("returns the error returned by the function", func() {
+		r := newSliceReader(3, ErrEndOfRange)
+		e := errors.New("<error>")
+
+		var count int
+
+		err := ForEach(ctx, r, func(Fact, Address) error {
+			count++
+			return e
+		})
+
+		Expect(err).To(Equal(e))
+		Expect(count).To(Equal(1))
+	})
+
+	It("returns the error returned by the reader", func() {
+		e := errors.New("<error>")
+		r := newSliceReader(1, e)
+
+		err := ForEach(ctx, r, func(Fact, Address) error {
+			return nil
+		})
+
+		Expect(err).To(Equal(e))
+	})
+
+	It("returns an error if the context is canceled", func() {
+		r := newSliceReader(1, nil)
+		c, stop := context.WithCancel(ctx)
+
+		err := ForEach(c, r, func(Fact, Address) error {
+			stop()
+			return nil
+		})
+
+		Expect(err).To(Equal(context.Canceled))
+	})
+})
+
+var _ = Describe("Channel", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+	)
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 5*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("sends each fact, with the next address, then closes the channel", func() {
+		r := newSliceReader(3, ErrEndOfRange)
+
+		items, result := Channel(ctx, r, 0)
+
+		var offsets, nexts []uint64
+
+		for i := range items {
+			offsets = append(offsets, i.Fact.Addr.Offset)
+			nexts = append(nexts, i.Next.Offset)
+		}
+
+		Expect(offsets).To(Equal([]uint64{0, 1, 2}))
+		Expect(nexts).To(Equal([]uint64{1, 2, 3}))
+		Expect(<-result).ShouldNot(HaveOccurred())
+	})
+
+	It("reports the error returned by the reader", func() {
+		e := errors.New("<error>")
+		r := newSliceReader(1, e)
+
+		items, result := Channel(ctx, r, 1)
+
+		for range items {
+		}
+
+		Expect(<-result).To(Equal(e))
+	})
+
+	It("stops when the context is canceled while waiting for a receiver", func() {
+		r := newSliceReader(3, ErrEndOfRange)
+		c, stop := context.WithCancel(ctx)
+
+		items, result := Channel(c, r, 0)
+
+		<-items
+		stop()
+
+		Expect(<-result).To(Equal(context.Canceled))
+	})
+})
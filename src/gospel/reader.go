@@ -2,6 +2,9 @@ package gospel
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/jmalloc/gospel/src/internal/options"
 )
@@ -19,6 +22,9 @@ type Reader interface {
 	// Note that nx is not always the address immediately following the fact
 	// returned by Get() - it may be "further ahead" in the stream, thus
 	// skipping over any facts that the reader is not interested in.
+	//
+	// It returns ErrEndOfRange if the reader has read all of the facts in the
+	// range that it was opened to read.
 	Next(ctx context.Context) (nx Address, err error)
 
 	// TryNext blocks until the next fact is available for reading, the end of
@@ -31,6 +37,9 @@ type Reader interface {
 	// nx is the offset within the stream that the reader has reached. It can be
 	// used to efficiently resume reading in a future call to EventStore.Open().
 	// nx is invalid if ok is false.
+	//
+	// It returns ErrEndOfRange if the reader has read all of the facts in the
+	// range that it was opened to read.
 	TryNext(ctx context.Context) (nx Address, ok bool, err error)
 
 	// Get returns the "current" fact.
@@ -43,6 +52,11 @@ type Reader interface {
 	Close() error
 }
 
+// ErrEndOfRange is returned by Reader.Next() and Reader.TryNext() when the
+// reader has read all of the facts in the range that it was opened to read,
+// such as when a reverse reader reaches the beginning of the stream.
+var ErrEndOfRange = errors.New("reached the end of the range")
+
 // ReaderOption is a function that applies a reader option to a ReaderOptions
 // struct.
 type ReaderOption = options.ReaderOption
@@ -51,10 +65,203 @@ type ReaderOption = options.ReaderOption
 // events of a specific type.
 //
 // Multiple FilterByEventType options can be combined to expand the list of
-// allowed types.
+// allowed types. They can also be combined with FilterByEventTypePrefix and
+// FilterByEventTypePattern, in which case facts with events that match any of
+// the filters are allowed.
 func FilterByEventType(types ...string) ReaderOption {
 	return func(o *options.ReaderOptions) {
 		o.FilterByEventType = true
 		o.EventTypes = append(o.EventTypes, types...)
 	}
 }
+
+// FilterByEventTypePrefix is a reader option that limits the reader to facts
+// with events whose type begins with one of the given prefixes.
+//
+// It can be combined with the other event type filters to expand the list of
+// allowed types.
+func FilterByEventTypePrefix(prefixes ...string) ReaderOption {
+	return func(o *options.ReaderOptions) {
+		o.FilterByEventType = true
+		o.EventTypePrefixes = append(o.EventTypePrefixes, prefixes...)
+	}
+}
+
+// FilterByEventTypePattern is a reader option that limits the reader to facts
+// with events whose type matches one of the given glob patterns.
+//
+// Within a pattern, '*' matches any sequence of bytes, including an empty
+// sequence, and '?' matches any single byte. All other bytes match themselves.
+// Matching is byte-based in every event store, so a single '?' does not match
+// a multi-byte UTF-8 character.
+//
+// It can be combined with the other event type filters to expand the list of
+// allowed types.
+func FilterByEventTypePattern(patterns ...string) ReaderOption {
+	return func(o *options.ReaderOptions) {
+		o.FilterByEventType = true
+		o.EventTypePatterns = append(o.EventTypePatterns, patterns...)
+	}
+}
+
+// ExcludeEventTypes is a reader option that excludes facts with events of a
+// specific type from the reader.
+//
+// Multiple ExcludeEventTypes options can be combined to expand the list of
+// excluded types. If the reader also uses an inclusive event type filter, such
+// as FilterByEventType, facts must be allowed by that filter and not excluded.
+//
+// It is useful for skipping over facts that are recorded by the event store
+// itself, such as "$stream.created" facts on the ε-stream.
+func ExcludeEventTypes(types ...string) ReaderOption {
+	return func(o *options.ReaderOptions) {
+		o.ExcludedEventTypes = append(o.ExcludedEventTypes, types...)
+	}
+}
+
+// FilterByContentType is a reader option that limits the reader to facts with
+// events of a specific content type.
+//
+// A content type that ends with '*' matches any content type that begins with
+// the preceding characters, such as "application/vnd.mycompany.*" or
+// "text/*". All other content types must match exactly.
+//
+// Multiple FilterByContentType options can be combined to expand the list of
+// allowed content types. If the reader also uses an event type filter, facts
+// must match both filters.
+func FilterByContentType(types ...string) ReaderOption {
+	return func(o *options.ReaderOptions) {
+		o.FilterByContentType = true
+
+		for _, t := range types {
+			if strings.HasSuffix(t, "*") {
+				o.ContentTypePrefixes = append(o.ContentTypePrefixes, t[:len(t)-1])
+			} else {
+				o.ContentTypes = append(o.ContentTypes, t)
+			}
+		}
+	}
+}
+
+// FilterByStream is a reader option that limits the reader to facts that were
+// originally appended to one of the given streams.
+//
+// It is intended for use when reading the ε-stream, where it allows facts from
+// specific streams to be read in ε-stream order. The nx address returned by
+// the reader still refers to an offset on the ε-stream. Facts recorded by the
+// event store itself, such as "$stream.created", do not originate from a named
+// stream and are always filtered out.
+//
+// When reading a named stream, all of the facts originate from that stream, so
+// either all of them are allowed, or none of them are.
+//
+// Multiple FilterByStream options can be combined to expand the list of
+// allowed streams. They can also be combined with FilterByStreamPrefix and
+// FilterByStreamPattern, in which case facts that originate from a stream that
+// matches any of the filters are allowed.
+func FilterByStream(streams ...string) ReaderOption {
+	return func(o *options.ReaderOptions) {
+		o.FilterByStream = true
+		o.Streams = append(o.Streams, streams...)
+	}
+}
+
+// FilterByStreamPrefix is a reader option that limits the reader to facts that
+// were originally appended to a stream whose name begins with one of the given
+// prefixes.
+//
+// See FilterByStream for more information about stream filters.
+func FilterByStreamPrefix(prefixes ...string) ReaderOption {
+	return func(o *options.ReaderOptions) {
+		o.FilterByStream = true
+		o.StreamPrefixes = append(o.StreamPrefixes, prefixes...)
+	}
+}
+
+// FilterByStreamPattern is a reader option that limits the reader to facts
+// that were originally appended to a stream whose name matches one of the
+// given glob patterns, such as "order-*".
+//
+// Patterns use the same syntax as FilterByEventTypePattern. See FilterByStream
+// for more information about stream filters.
+func FilterByStreamPattern(patterns ...string) ReaderOption {
+	return func(o *options.ReaderOptions) {
+		o.FilterByStream = true
+		o.StreamPatterns = append(o.StreamPatterns, patterns...)
+	}
+}
+
+// FromTime is a reader option that limits the reader to facts that occurred
+// at or after t.
+//
+// A forward reader begins at the first fact that occurred at or after t, or at
+// the offset passed to EventStore.Open() if it is later, without reading the
+// facts before it. Event stores that are backed by a database find this fact
+// using an index, so a reader that begins at offset zero does not need to scan
+// the entire stream. If no such fact has been appended yet, the reader begins
+// at the head of the stream.
+//
+// Fact times are not guaranteed to increase monotonically with the offset,
+// particularly on the ε-stream, so facts that occurred before t may appear
+// after the first fact that occurred at or after t. Every such fact is
+// skipped, as are the facts that occurred before t when reading in reverse.
+//
+// If multiple FromTime options are combined, the latest time is used.
+func FromTime(t time.Time) ReaderOption {
+	return func(o *options.ReaderOptions) {
+		if !o.FilterByTime || t.After(o.FromTime) {
+			o.FilterByTime = true
+			o.FromTime = t
+		}
+	}
+}
+
+// Reverse is a reader option that causes the reader to read facts in reverse
+// order, from the given address towards the beginning of the stream.
+//
+// A reverse reader begins at the fact immediately before the address passed to
+// EventStore.Open(), such that a forward reader and a reverse reader opened at
+// the same address read the facts on either side of it. Hence, opening a
+// reverse reader at the next unused offset of a stream, as reported by
+// EventStore.StreamInfo(), reads the stream from its most recent fact.
+//
+// The nx address returned by Next() and TryNext() can be passed to
+// EventStore.Open() along with the Reverse option to resume reading.
+//
+// A reverse reader does not wait for new facts. Once the first fact in the
+// stream has been read, Next() and TryNext() return ErrEndOfRange.
+func Reverse() ReaderOption {
+	return func(o *options.ReaderOptions) {
+		o.Reverse = true
+	}
+}
+
+// StopAt is a reader option that limits the reader to facts before the given
+// offset. Once the reader reaches offset, Next() and TryNext() return
+// ErrEndOfRange. Until then, the reader waits for new facts as usual.
+//
+// When combined with Reverse(), the reader is limited to facts at or after the
+// given offset instead, such that the reader ends after reading the fact at
+// offset.
+//
+// If multiple StopAt options are combined, the last offset is used.
+func StopAt(offset uint64) ReaderOption {
+	return func(o *options.ReaderOptions) {
+		o.StopAtOffset = true
+		o.StopOffset = offset
+	}
+}
+
+// StopAtHead is a reader option that limits the reader to the facts that had
+// been appended to the stream when the reader was opened. Once the reader
+// reaches the next unused offset as at the time EventStore.Open() was called,
+// Next() and TryNext() return ErrEndOfRange.
+//
+// It can be combined with StopAt(), in which case the reader ends at
+// whichever offset it reaches first. It has no effect on reverse readers,
+// which never read facts appended after the reader was opened.
+func StopAtHead() ReaderOption {
+	return func(o *options.ReaderOptions) {
+		o.StopAtHead = true
+	}
+}
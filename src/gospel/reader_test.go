@@ -1,6 +1,8 @@
 package gospel_test
 
 import (
+	"time"
+
 	. "github.com/jmalloc/gospel/src/gospel"
 	"github.com/jmalloc/gospel/src/internal/options"
 	. "github.com/onsi/ginkgo"
@@ -45,3 +47,244 @@ var _ = Describe("FilterByEventType", func() {
 		))
 	})
 })
+
+var _ = Describe("FilterByEventTypePrefix", func() {
+	It("enables the event type filter", func() {
+		opts := &options.ReaderOptions{}
+
+		FilterByEventTypePrefix("foo.")(opts)
+
+		Expect(opts.FilterByEventType).To(BeTrue())
+	})
+
+	It("is additive", func() {
+		opts := &options.ReaderOptions{}
+
+		FilterByEventTypePrefix("foo.")(opts)
+		FilterByEventTypePrefix("bar.")(opts)
+
+		Expect(opts.EventTypePrefixes).To(Equal(
+			[]string{
+				"foo.",
+				"bar.",
+			},
+		))
+	})
+})
+
+var _ = Describe("FilterByEventTypePattern", func() {
+	It("enables the event type filter", func() {
+		opts := &options.ReaderOptions{}
+
+		FilterByEventTypePattern("foo.*")(opts)
+
+		Expect(opts.FilterByEventType).To(BeTrue())
+	})
+
+	It("is additive", func() {
+		opts := &options.ReaderOptions{}
+
+		FilterByEventTypePattern("foo.*")(opts)
+		FilterByEventTypePattern("*.bar")(opts)
+
+		Expect(opts.EventTypePatterns).To(Equal(
+			[]string{
+				"foo.*",
+				"*.bar",
+			},
+		))
+	})
+})
+
+var _ = Describe("ExcludeEventTypes", func() {
+	It("does not enable the inclusive event type filter", func() {
+		opts := &options.ReaderOptions{}
+
+		ExcludeEventTypes("foo")(opts)
+
+		Expect(opts.FilterByEventType).To(BeFalse())
+	})
+
+	It("is additive", func() {
+		opts := &options.ReaderOptions{}
+
+		ExcludeEventTypes("foo", "bar")(opts)
+		ExcludeEventTypes("baz")(opts)
+
+		Expect(opts.ExcludedEventTypes).To(Equal(
+			[]string{
+				"foo",
+				"bar",
+				"baz",
+			},
+		))
+	})
+})
+
+var _ = Describe("FilterByContentType", func() {
+	It("enables the content type filter", func() {
+		opts := &options.ReaderOptions{}
+
+		FilterByContentType("application/json")(opts)
+
+		Expect(opts.FilterByContentType).To(BeTrue())
+	})
+
+	It("separates exact content types from content type families", func() {
+		opts := &options.ReaderOptions{}
+
+		FilterByContentType("application/json", "text/*")(opts)
+		FilterByContentType("application/vnd.foo.*", "text/plain")(opts)
+
+		Expect(opts.ContentTypes).To(Equal(
+			[]string{
+				"application/json",
+				"text/plain",
+			},
+		))
+		Expect(opts.ContentTypePrefixes).To(Equal(
+			[]string{
+				"text/",
+				"application/vnd.foo.",
+			},
+		))
+	})
+})
+
+var _ = Describe("FilterByStream", func() {
+	It("enables the stream filter", func() {
+		opts := &options.ReaderOptions{}
+
+		FilterByStream("foo")(opts)
+
+		Expect(opts.FilterByStream).To(BeTrue())
+	})
+
+	It("is additive", func() {
+		opts := &options.ReaderOptions{}
+
+		FilterByStream("foo")(opts)
+		FilterByStream("bar")(opts)
+
+		Expect(opts.Streams).To(Equal(
+			[]string{
+				"foo",
+				"bar",
+			},
+		))
+	})
+})
+
+var _ = Describe("FilterByStreamPrefix", func() {
+	It("enables the stream filter", func() {
+		opts := &options.ReaderOptions{}
+
+		FilterByStreamPrefix("foo-")(opts)
+
+		Expect(opts.FilterByStream).To(BeTrue())
+	})
+
+	It("is additive", func() {
+		opts := &options.ReaderOptions{}
+
+		FilterByStreamPrefix("foo-")(opts)
+		FilterByStreamPrefix("bar-")(opts)
+
+		Expect(opts.StreamPrefixes).To(Equal(
+			[]string{
+				"foo-",
+				"bar-",
+			},
+		))
+	})
+})
+
+var _ = Describe("FilterByStreamPattern", func() {
+	It("enables the stream filter", func() {
+		opts := &options.ReaderOptions{}
+
+		FilterByStreamPattern("foo-*")(opts)
+
+		Expect(opts.FilterByStream).To(BeTrue())
+	})
+
+	It("is additive", func() {
+		opts := &options.ReaderOptions{}
+
+		FilterByStreamPattern("foo-*")(opts)
+		FilterByStreamPattern("*-bar")(opts)
+
+		Expect(opts.StreamPatterns).To(Equal(
+			[]string{
+				"foo-*",
+				"*-bar",
+			},
+		))
+	})
+})
+
+var _ = Describe("FromTime", func() {
+	t := time.Date(2018, 1, 2, 9, 0, 0, 0, time.UTC)
+
+	It("enables the time filter", func() {
+		opts := &options.ReaderOptions{}
+
+		FromTime(t)(opts)
+
+		Expect(opts.FilterByTime).To(BeTrue())
+		Expect(opts.FromTime).To(Equal(t))
+	})
+
+	It("uses the latest time", func() {
+		opts := &options.ReaderOptions{}
+
+		FromTime(t)(opts)
+		FromTime(t.Add(-time.Hour))(opts)
+
+		Expect(opts.FromTime).To(Equal(t))
+
+		FromTime(t.Add(time.Hour))(opts)
+
+		Expect(opts.FromTime).To(Equal(t.Add(time.Hour)))
+	})
+})
+
+var _ = Describe("Reverse", func() {
+	It("enables reverse reading", func() {
+		opts := &options.ReaderOptions{}
+
+		Reverse()(opts)
+
+		Expect(opts.Reverse).To(BeTrue())
+	})
+})
+
+var _ = Describe("StopAt", func() {
+	It("enables the stop offset", func() {
+		opts := &options.ReaderOptions{}
+
+		StopAt(10)(opts)
+
+		Expect(opts.StopAtOffset).To(BeTrue())
+		Expect(opts.StopOffset).To(BeNumerically("==", 10))
+	})
+
+	It("uses the last offset", func() {
+		opts := &options.ReaderOptions{}
+
+		StopAt(10)(opts)
+		StopAt(20)(opts)
+
+		Expect(opts.StopOffset).To(BeNumerically("==", 20))
+	})
+})
+
+var _ = Describe("StopAtHead", func() {
+	It("enables stopping at the head of the stream", func() {
+		opts := &options.ReaderOptions{}
+
+		StopAtHead()(opts)
+
+		Expect(opts.StopAtHead).To(BeTrue())
+	})
+})
@@ -0,0 +1,17 @@
+package gospel
+
+// StreamAppend describes the events to append to a single stream as part of
+// an atomic multi-stream append performed with EventStore.AppendMulti().
+type StreamAppend struct {
+	// Stream is the name of the stream to append to. It must not be the
+	// ε-stream.
+	Stream string
+
+	// Expectation is the condition that the stream must satisfy for the
+	// append to succeed.
+	Expectation Expectation
+
+	// Events is the set of events to append to the stream. It must not be
+	// empty.
+	Events []Event
+}
@@ -0,0 +1,30 @@
+package gospel
+
+import "time"
+
+// StreamInfo contains information about the current state of a stream.
+type StreamInfo struct {
+	// Exists is true if at least one fact has been appended to the stream.
+	//
+	// The ε-stream always exists, as it contains a fact describing the creation
+	// of the store itself.
+	Exists bool
+
+	// Next is the address of the next unused offset within the stream. It can
+	// be passed to EventStore.Append() to append to the end of the stream.
+	//
+	// If the stream does not exist, the offset is zero.
+	Next Address
+
+	// FirstFactTime and LastFactTime are the times of the first and last facts
+	// on the stream, respectively. They are zero if the stream contains no
+	// facts, or if the corresponding fact has been discarded by the store,
+	// such as when older partitions are truncated.
+	FirstFactTime time.Time
+	LastFactTime  time.Time
+
+	// FactCount is the number of facts that have been appended to the stream.
+	// It is always equal to Next.Offset, and includes any facts that have
+	// since been discarded by the store.
+	FactCount uint64
+}
@@ -0,0 +1,36 @@
+package gospelagg
+
+import "github.com/jmalloc/gospel/src/gospel"
+
+// Aggregate is an interface for a domain object whose state is derived from
+// the facts on a single stream.
+type Aggregate interface {
+	// Apply updates the aggregate's state to reflect f.
+	Apply(f gospel.Fact) error
+}
+
+// Snapshotter is an interface for aggregates that support snapshots.
+//
+// If an aggregate implements Snapshotter, a Repository that has a
+// SnapshotStore uses snapshots to avoid replaying the entire stream each time
+// the aggregate is loaded.
+type Snapshotter interface {
+	Aggregate
+
+	// MarshalSnapshot returns a binary representation of the aggregate's
+	// state.
+	MarshalSnapshot() ([]byte, error)
+
+	// UnmarshalSnapshot restores the aggregate's state from a binary
+	// representation produced by MarshalSnapshot().
+	UnmarshalSnapshot(data []byte) error
+}
+
+// Factory is a function that returns a new aggregate in its initial state.
+type Factory func() Aggregate
+
+// Command is a function that inspects the state of an aggregate and returns the
+// events to append to its stream.
+//
+// It may return no events, in which case nothing is appended.
+type Command func(agg Aggregate) ([]gospel.Event, error)
@@ -0,0 +1,8 @@
+// Package gospelagg provides a repository for event-sourced aggregates stored
+// in a gospel event store.
+//
+// An aggregate is loaded by replaying the facts on its stream, optionally
+// beginning from a snapshot. New events are appended at the offset following
+// the last fact that was replayed, so that concurrent changes to the same
+// aggregate are detected as conflicts.
+package gospelagg
@@ -0,0 +1,369 @@
+package gospelagg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+)
+
+// DefaultMaxRetries is the default number of times that Repository.Update()
+// retries a command after a conflict. It is used if no specific number is set
+// via MaxRetries().
+const DefaultMaxRetries = 3
+
+// Repository loads and saves aggregates stored in an event store.
+type Repository struct {
+	// store is the event store that contains the aggregates' streams.
+	store gospel.EventStore
+
+	// snapshots is the store used to persist snapshots. It is nil if snapshots
+	// are disabled.
+	snapshots SnapshotStore
+
+	// snapshotEvery is the number of facts between each snapshot.
+	snapshotEvery uint64
+
+	// maxRetries is the number of times Update() retries a command after a
+	// conflict.
+	maxRetries int
+
+	// onConflict is called before Update() retries a command, if it is non-nil.
+	onConflict ConflictHook
+
+	// onSnapshotError is called when a snapshot can not be saved, if it is
+	// non-nil.
+	onSnapshotError SnapshotErrorHook
+}
+
+// ConflictHook is a function that is called when Repository.Update() fails to
+// append events due to a conflict, before the command is retried.
+//
+// attempt is the number of attempts made so far, beginning at 1. If the hook
+// returns an error, Update() returns that error instead of retrying. Hooks are
+// typically used to log conflicts, or to back off before retrying.
+type ConflictHook func(ctx context.Context, err gospel.ConflictError, attempt int) error
+
+// SnapshotErrorHook is a function that is called when a repository fails to
+// save a snapshot of the aggregate stored in the given stream.
+//
+// Snapshots are an optimization, so the failure does not cause the operation
+// that attempted to save the snapshot to fail. Hooks are typically used to log
+// the failure.
+type SnapshotErrorHook func(ctx context.Context, stream string, err error)
+
+// ApplyError is the error returned by Repository.Save() when events have been
+// appended to an aggregate's stream, but could not be applied to the aggregate.
+//
+// The aggregate's state no longer reflects its stream, so it must be discarded
+// and loaded again before it is used.
+type ApplyError struct {
+	// Err is the error returned by the aggregate's Apply() method.
+	Err error
+}
+
+func (e ApplyError) Error() string {
+	return "the events were appended, but could not be applied to the aggregate: " + e.Err.Error()
+}
+
+// Option is a function that applies an option to a repository.
+type Option func(r *Repository)
+
+// Snapshots is a repository option that enables snapshots for aggregates that
+// implement Snapshotter.
+//
+// A snapshot is saved to ss each time the facts appended by Save() cross a
+// multiple of n offsets, and each time Load() replays n or more facts that are
+// not reflected in a snapshot, such as facts appended by other means. The
+// minimum value of n is 1.
+//
+// Failing to save a snapshot does not cause the operation to fail. Use
+// OnSnapshotError() to be notified of such failures.
+func Snapshots(ss SnapshotStore, n uint64) Option {
+	if n < 1 {
+		n = 1
+	}
+
+	return func(r *Repository) {
+		r.snapshots = ss
+		r.snapshotEvery = n
+	}
+}
+
+// MaxRetries is a repository option that sets the number of times that
+// Update() retries a command after a conflict.
+func MaxRetries(n int) Option {
+	if n < 0 {
+		n = 0
+	}
+
+	return func(r *Repository) {
+		r.maxRetries = n
+	}
+}
+
+// OnConflict is a repository option that sets a hook that is called before
+// Update() retries a command after a conflict.
+func OnConflict(fn ConflictHook) Option {
+	return func(r *Repository) {
+		r.onConflict = fn
+	}
+}
+
+// OnSnapshotError is a repository option that sets a hook that is called when
+// a snapshot can not be saved.
+func OnSnapshotError(fn SnapshotErrorHook) Option {
+	return func(r *Repository) {
+		r.onSnapshotError = fn
+	}
+}
+
+// NewRepository returns a repository for aggregates stored in es.
+func NewRepository(es gospel.EventStore, opts ...Option) *Repository {
+	r := &Repository{
+		store:      es,
+		maxRetries: DefaultMaxRetries,
+	}
+
+	for _, fn := range opts {
+		fn(r)
+	}
+
+	return r
+}
+
+// Load replays the facts on stream into agg.
+//
+// If agg implements Snapshotter and a snapshot is available, agg is first
+// restored from the snapshot, and only the facts after the snapshot are
+// replayed.
+//
+// If snapshots are enabled and n or more facts are replayed, where n is the
+// snapshot interval, a new snapshot is saved. If it can not be saved, the
+// error is passed to the hook set by OnSnapshotError(), if any, and Load()
+// still succeeds.
+//
+// nx is the next unused offset of the stream, as at the last fact replayed. It
+// is passed to Save() to append new events.
+//
+// It returns an error if stream begins with SnapshotStreamPrefix, as such
+// streams are reserved for snapshots.
+func (r *Repository) Load(
+	ctx context.Context,
+	stream string,
+	agg Aggregate,
+) (nx gospel.Address, err error) {
+	nx = gospel.Address{Stream: stream}
+
+	if err := checkStream(stream); err != nil {
+		return nx, err
+	}
+
+	s, ok := agg.(Snapshotter)
+	if !ok || r.snapshots == nil {
+		s = nil
+	}
+
+	if s != nil {
+		snap, ok, err := r.snapshots.LoadSnapshot(ctx, stream)
+		if err != nil {
+			return nx, err
+		}
+
+		if ok {
+			if err := s.UnmarshalSnapshot(snap.Data); err != nil {
+				return nx, err
+			}
+
+			nx.Offset = snap.Offset
+		}
+	}
+
+	reader, err := r.store.Open(ctx, nx, gospel.StopAtHead())
+	if err != nil {
+		return nx, err
+	}
+	defer reader.Close()
+
+	from := nx
+
+	err = gospel.ForEach(ctx, reader, func(f gospel.Fact, _ gospel.Address) error {
+		if err := agg.Apply(f); err != nil {
+			return err
+		}
+
+		nx = f.Addr.Next()
+
+		return nil
+	})
+	if err != nil {
+		return nx, err
+	}
+
+	if s != nil && nx.Offset-from.Offset >= r.snapshotEvery {
+		r.saveSnapshot(ctx, nx, s)
+	}
+
+	return nx, nil
+}
+
+// Save appends events to the aggregate's stream at next, which must be the
+// next unused offset of the stream, as returned by Load() or a previous call
+// to Save(). If another process has appended to the stream since, the append
+// fails, and gospel.IsConflict(err) returns true.
+//
+// Once the events are appended they are applied to agg. The facts passed to
+// agg.Apply() have a Time of the local time at which they were appended,
+// which may differ from the time recorded by the store.
+//
+// nx is the next unused offset of the stream after the append. If a snapshot
+// is due but can not be saved, the error is passed to the hook set by
+// OnSnapshotError(), if any, and Save() still succeeds, as the events have
+// been appended.
+//
+// If the events are appended but agg.Apply() fails, nx is still valid and the
+// error is an ApplyError. agg is left with only some of the events applied, so
+// it must be discarded and loaded again.
+//
+// It returns an error if the stream begins with SnapshotStreamPrefix, as such
+// streams are reserved for snapshots. Save panics if ev is empty.
+func (r *Repository) Save(
+	ctx context.Context,
+	next gospel.Address,
+	agg Aggregate,
+	ev ...gospel.Event,
+) (nx gospel.Address, err error) {
+	if err := checkStream(next.Stream); err != nil {
+		return next, err
+	}
+
+	nx, err = r.store.Append(ctx, next, ev...)
+	if err != nil {
+		return next, err
+	}
+
+	now := time.Now()
+	addr := next
+
+	for _, e := range ev {
+		if err := agg.Apply(gospel.Fact{
+			Addr:   addr,
+			Origin: addr,
+			Time:   now,
+			Event:  e,
+		}); err != nil {
+			return nx, ApplyError{err}
+		}
+
+		addr = addr.Next()
+	}
+
+	r.snapshot(ctx, next, nx, agg)
+
+	return nx, nil
+}
+
+// Update loads an aggregate, executes cmd against it, and appends the
+// resulting events to its stream.
+//
+// newAgg is called to produce a new aggregate each time the aggregate is
+// loaded. If the append conflicts with a concurrent append to the same stream,
+// the aggregate is reloaded and cmd is executed again, up to the maximum
+// number of retries set by MaxRetries().
+//
+// It returns the loaded aggregate, with the new events applied, and the next
+// unused offset of the stream. If the events are appended but can not be
+// applied to the aggregate, the error is an ApplyError, as per Save().
+func (r *Repository) Update(
+	ctx context.Context,
+	stream string,
+	newAgg Factory,
+	cmd Command,
+) (agg Aggregate, nx gospel.Address, err error) {
+	for attempt := 1; ; attempt++ {
+		agg = newAgg()
+
+		nx, err = r.Load(ctx, stream, agg)
+		if err != nil {
+			return nil, nx, err
+		}
+
+		var ev []gospel.Event
+		ev, err = cmd(agg)
+		if err != nil || len(ev) == 0 {
+			return agg, nx, err
+		}
+
+		nx, err = r.Save(ctx, nx, agg, ev...)
+
+		c, ok := err.(gospel.ConflictError)
+		if !ok || attempt > r.maxRetries {
+			return agg, nx, err
+		}
+
+		if r.onConflict != nil {
+			if err := r.onConflict(ctx, c, attempt); err != nil {
+				return agg, nx, err
+			}
+		}
+	}
+}
+
+// snapshot saves a snapshot of agg if the facts between next and nx cross a
+// multiple of the snapshot interval.
+func (r *Repository) snapshot(
+	ctx context.Context,
+	next, nx gospel.Address,
+	agg Aggregate,
+) {
+	s, ok := agg.(Snapshotter)
+	if !ok || r.snapshots == nil {
+		return
+	}
+
+	if next.Offset/r.snapshotEvery == nx.Offset/r.snapshotEvery {
+		return
+	}
+
+	r.saveSnapshot(ctx, nx, s)
+}
+
+// saveSnapshot saves a snapshot of s, which reflects the facts before nx.
+//
+// If the snapshot can not be saved, the error is passed to the snapshot error
+// hook, if any.
+func (r *Repository) saveSnapshot(
+	ctx context.Context,
+	nx gospel.Address,
+	s Snapshotter,
+) {
+	data, err := s.MarshalSnapshot()
+
+	if err == nil {
+		err = r.snapshots.SaveSnapshot(
+			ctx,
+			nx.Stream,
+			Snapshot{nx.Offset, data},
+		)
+	}
+
+	if err != nil && r.onSnapshotError != nil {
+		r.onSnapshotError(ctx, nx.Stream, err)
+	}
+}
+
+// checkStream returns an error if stream can not be used to store an
+// aggregate.
+func checkStream(stream string) error {
+	if strings.HasPrefix(stream, SnapshotStreamPrefix) {
+		return fmt.Errorf(
+			"can not use '%s' as an aggregate stream, streams beginning with '%s' are reserved for snapshots",
+			stream,
+			SnapshotStreamPrefix,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,439 @@
+package gospelagg_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/jmalloc/gospel/src/gospelagg"
+	"github.com/jmalloc/gospel/src/gospelmem"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// counter is an aggregate that counts the facts on its stream.
+type counter struct {
+	Count   int
+	Applied []string // the bodies of the facts applied to the aggregate
+}
+
+func (c *counter) Apply(f gospel.Fact) error {
+	c.Count++
+	c.Applied = append(c.Applied, string(f.Event.Body))
+	return nil
+}
+
+// snapshottingCounter is a counter that supports snapshots.
+type snapshottingCounter struct {
+	counter
+}
+
+func (c *snapshottingCounter) MarshalSnapshot() ([]byte, error) {
+	return []byte(strconv.Itoa(c.Count)), nil
+}
+
+func (c *snapshottingCounter) UnmarshalSnapshot(data []byte) error {
+	n, err := strconv.Atoi(string(data))
+	c.Count = n
+	return err
+}
+
+// events returns n events with bodies "<prefix>-1" through "<prefix>-n".
+func events(prefix string, n int) []gospel.Event {
+	var ev []gospel.Event
+
+	for i := 1; i <= n; i++ {
+		ev = append(ev, gospel.Event{
+			EventType: "event-type",
+			Body:      []byte(prefix + "-" + strconv.Itoa(i)),
+		})
+	}
+
+	return ev
+}
+
+var _ = Describe("Repository", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+
+		client *gospelmem.Client
+		store  *gospelmem.EventStore
+	)
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 5*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+
+		client = gospelmem.NewClient()
+
+		var err error
+		store, err = client.OpenStore(ctx, "test")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		cancel()
+		client.Close()
+	})
+
+	Describe("Load", func() {
+		It("replays the facts on the stream", func() {
+			_, err := store.AppendUnchecked(ctx, "test-stream", events("event", 3)...)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			repo := NewRepository(store)
+			agg := &counter{}
+
+			nx, err := repo.Load(ctx, "test-stream", agg)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(nx).To(Equal(gospel.Address{Stream: "test-stream", Offset: 3}))
+			Expect(agg.Applied).To(Equal([]string{"event-1", "event-2", "event-3"}))
+		})
+
+		It("returns the first offset if the stream does not exist", func() {
+			repo := NewRepository(store)
+			agg := &counter{}
+
+			nx, err := repo.Load(ctx, "test-stream", agg)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(nx).To(Equal(gospel.Address{Stream: "test-stream", Offset: 0}))
+			Expect(agg.Count).To(Equal(0))
+		})
+
+		It("returns the error returned by the aggregate", func() {
+			_, err := store.AppendUnchecked(ctx, "test-stream", events("event", 1)...)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			repo := NewRepository(store)
+
+			_, err = repo.Load(ctx, "test-stream", failingAggregate{})
+
+			Expect(err).To(MatchError("<error>"))
+		})
+
+		It("returns an error if the stream is reserved for snapshots", func() {
+			repo := NewRepository(store)
+
+			_, err := repo.Load(ctx, SnapshotStreamPrefix+"test-stream", &counter{})
+
+			Expect(err).To(MatchError("can not use '$snapshot/test-stream' as an aggregate stream, streams beginning with '$snapshot/' are reserved for snapshots"))
+		})
+
+		Context("when snapshots are enabled", func() {
+			It("replays only the facts after the most recent snapshot", func() {
+				repo := NewRepository(
+					store,
+					Snapshots(NewStreamSnapshotStore(store), 3),
+				)
+
+				agg := &snapshottingCounter{}
+				nx, err := repo.Save(ctx, gospel.Address{Stream: "test-stream"}, agg, events("event", 3)...)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				_, err = repo.Save(ctx, nx, agg, events("more", 1)...)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				agg = &snapshottingCounter{}
+				nx, err = repo.Load(ctx, "test-stream", agg)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(nx).To(Equal(gospel.Address{Stream: "test-stream", Offset: 4}))
+				Expect(agg.Count).To(Equal(4))
+				Expect(agg.Applied).To(Equal([]string{"more-1"}))
+			})
+
+			It("saves a snapshot if it replays enough facts", func() {
+				_, err := store.AppendUnchecked(ctx, "test-stream", events("event", 3)...)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				snapshots := NewStreamSnapshotStore(store)
+				repo := NewRepository(
+					store,
+					Snapshots(snapshots, 3),
+				)
+
+				_, err = repo.Load(ctx, "test-stream", &snapshottingCounter{})
+				Expect(err).ShouldNot(HaveOccurred())
+
+				snap, ok, err := snapshots.LoadSnapshot(ctx, "test-stream")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(ok).To(BeTrue())
+				Expect(snap).To(Equal(Snapshot{3, []byte("3")}))
+			})
+
+			It("does not save a snapshot if it replays too few facts", func() {
+				_, err := store.AppendUnchecked(ctx, "test-stream", events("event", 2)...)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				snapshots := NewStreamSnapshotStore(store)
+				repo := NewRepository(
+					store,
+					Snapshots(snapshots, 3),
+				)
+
+				_, err = repo.Load(ctx, "test-stream", &snapshottingCounter{})
+				Expect(err).ShouldNot(HaveOccurred())
+
+				_, ok, err := snapshots.LoadSnapshot(ctx, "test-stream")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(ok).To(BeFalse())
+			})
+
+			It("replays the entire stream for aggregates that do not support snapshots", func() {
+				repo := NewRepository(
+					store,
+					Snapshots(NewStreamSnapshotStore(store), 1),
+				)
+
+				_, err := repo.Save(ctx, gospel.Address{Stream: "test-stream"}, &counter{}, events("event", 2)...)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				agg := &counter{}
+				_, err = repo.Load(ctx, "test-stream", agg)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(agg.Applied).To(Equal([]string{"event-1", "event-2"}))
+			})
+
+			It("calls the snapshot error hook if the snapshot can not be saved", func() {
+				_, err := store.AppendUnchecked(ctx, "test-stream", events("event", 3)...)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				var errs []error
+
+				repo := NewRepository(
+					store,
+					Snapshots(failingSnapshotStore{}, 3),
+					OnSnapshotError(func(_ context.Context, stream string, err error) {
+						Expect(stream).To(Equal("test-stream"))
+						errs = append(errs, err)
+					}),
+				)
+
+				agg := &snapshottingCounter{}
+				nx, err := repo.Load(ctx, "test-stream", agg)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(nx).To(Equal(gospel.Address{Stream: "test-stream", Offset: 3}))
+				Expect(agg.Count).To(Equal(3))
+				Expect(errs).To(Equal([]error{errors.New("<error>")}))
+			})
+		})
+	})
+
+	Describe("Save", func() {
+		It("appends the events and applies them to the aggregate", func() {
+			repo := NewRepository(store)
+			agg := &counter{}
+
+			nx, err := repo.Save(ctx, gospel.Address{Stream: "test-stream"}, agg, events("event", 2)...)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(nx).To(Equal(gospel.Address{Stream: "test-stream", Offset: 2}))
+			Expect(agg.Applied).To(Equal([]string{"event-1", "event-2"}))
+
+			info, err := store.StreamInfo(ctx, "test-stream")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(info.Next).To(Equal(nx))
+		})
+
+		It("returns a conflict error if the stream has been modified", func() {
+			repo := NewRepository(store)
+			agg := &counter{}
+
+			nx, err := repo.Load(ctx, "test-stream", agg)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = store.AppendUnchecked(ctx, "test-stream", events("other", 1)...)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = repo.Save(ctx, nx, agg, events("event", 1)...)
+
+			Expect(gospel.IsConflict(err)).To(BeTrue())
+			Expect(agg.Count).To(Equal(0))
+		})
+
+		It("returns an apply error and the new offset if the aggregate fails to apply the events", func() {
+			repo := NewRepository(store)
+
+			nx, err := repo.Save(ctx, gospel.Address{Stream: "test-stream"}, failingAggregate{}, events("event", 2)...)
+
+			Expect(err).To(Equal(ApplyError{errors.New("<error>")}))
+			Expect(nx).To(Equal(gospel.Address{Stream: "test-stream", Offset: 2}))
+
+			info, err := store.StreamInfo(ctx, "test-stream")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(info.Next).To(Equal(nx))
+		})
+
+		It("does not return an error if the snapshot can not be saved", func() {
+			var errs []error
+
+			repo := NewRepository(
+				store,
+				Snapshots(failingSnapshotStore{}, 2),
+				OnSnapshotError(func(_ context.Context, _ string, err error) {
+					errs = append(errs, err)
+				}),
+			)
+
+			nx, err := repo.Save(ctx, gospel.Address{Stream: "test-stream"}, &snapshottingCounter{}, events("event", 2)...)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(nx).To(Equal(gospel.Address{Stream: "test-stream", Offset: 2}))
+			Expect(errs).To(Equal([]error{errors.New("<error>")}))
+		})
+
+		It("returns an error if the stream is reserved for snapshots", func() {
+			repo := NewRepository(store)
+
+			_, err := repo.Save(ctx, gospel.Address{Stream: SnapshotStreamPrefix + "test-stream"}, &counter{}, events("event", 1)...)
+			Expect(err).Should(HaveOccurred())
+
+			info, err := store.StreamInfo(ctx, SnapshotStreamPrefix+"test-stream")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(info.Exists).To(BeFalse())
+		})
+	})
+
+	Describe("Update", func() {
+		newCounter := func() Aggregate {
+			return &counter{}
+		}
+
+		It("appends the events returned by the command", func() {
+			repo := NewRepository(store)
+
+			agg, nx, err := repo.Update(ctx, "test-stream", newCounter, func(Aggregate) ([]gospel.Event, error) {
+				return events("event", 1), nil
+			})
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(nx).To(Equal(gospel.Address{Stream: "test-stream", Offset: 1}))
+			Expect(agg.(*counter).Count).To(Equal(1))
+		})
+
+		It("does not append anything if the command returns no events", func() {
+			repo := NewRepository(store)
+
+			_, nx, err := repo.Update(ctx, "test-stream", newCounter, func(Aggregate) ([]gospel.Event, error) {
+				return nil, nil
+			})
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(nx.Offset).To(BeNumerically("==", 0))
+
+			info, err := store.StreamInfo(ctx, "test-stream")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(info.Exists).To(BeFalse())
+		})
+
+		It("reloads the aggregate and retries the command after a conflict", func() {
+			var attempts []int
+
+			repo := NewRepository(
+				store,
+				OnConflict(func(_ context.Context, _ gospel.ConflictError, attempt int) error {
+					attempts = append(attempts, attempt)
+					return nil
+				}),
+			)
+
+			var counts []int
+
+			agg, _, err := repo.Update(ctx, "test-stream", newCounter, func(agg Aggregate) ([]gospel.Event, error) {
+				counts = append(counts, agg.(*counter).Count)
+
+				if len(counts) == 1 {
+					// simulate a concurrent append
+					if _, err := store.AppendUnchecked(ctx, "test-stream", events("other", 1)...); err != nil {
+						return nil, err
+					}
+				}
+
+				return events("event", 1), nil
+			})
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(counts).To(Equal([]int{0, 1}))
+			Expect(attempts).To(Equal([]int{1}))
+			Expect(agg.(*counter).Applied).To(Equal([]string{"other-1", "event-1"}))
+		})
+
+		It("returns the conflict error once the retries are exhausted", func() {
+			repo := NewRepository(store, MaxRetries(1))
+
+			var calls int
+
+			_, _, err := repo.Update(ctx, "test-stream", newCounter, func(Aggregate) ([]gospel.Event, error) {
+				calls++
+
+				if _, err := store.AppendUnchecked(ctx, "test-stream", events("other", 1)...); err != nil {
+					return nil, err
+				}
+
+				return events("event", 1), nil
+			})
+
+			Expect(gospel.IsConflict(err)).To(BeTrue())
+			Expect(calls).To(Equal(2))
+		})
+
+		It("returns an error if the stream is reserved for snapshots", func() {
+			repo := NewRepository(store)
+
+			agg, _, err := repo.Update(ctx, SnapshotStreamPrefix+"test-stream", newCounter, func(Aggregate) ([]gospel.Event, error) {
+				return events("event", 1), nil
+			})
+
+			Expect(err).Should(HaveOccurred())
+			Expect(agg).To(BeNil())
+		})
+
+		It("returns the error returned by the conflict hook", func() {
+			e := errors.New("<error>")
+
+			repo := NewRepository(
+				store,
+				OnConflict(func(context.Context, gospel.ConflictError, int) error {
+					return e
+				}),
+			)
+
+			_, _, err := repo.Update(ctx, "test-stream", newCounter, func(Aggregate) ([]gospel.Event, error) {
+				if _, err := store.AppendUnchecked(ctx, "test-stream", events("other", 1)...); err != nil {
+					return nil, err
+				}
+
+				return events("event", 1), nil
+			})
+
+			Expect(err).To(Equal(e))
+		})
+	})
+})
+
+// failingAggregate is an aggregate that fails to apply any fact.
+type failingAggregate struct{}
+
+func (failingAggregate) Apply(gospel.Fact) error {
+	return errors.New("<error>")
+}
+
+// failingSnapshotStore is a snapshot store that has no snapshots, and fails to
+// save any snapshot.
+type failingSnapshotStore struct{}
+
+func (failingSnapshotStore) LoadSnapshot(context.Context, string) (Snapshot, bool, error) {
+	return Snapshot{}, false, nil
+}
+
+func (failingSnapshotStore) SaveSnapshot(context.Context, string, Snapshot) error {
+	return errors.New("<error>")
+}
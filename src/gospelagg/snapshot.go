@@ -0,0 +1,132 @@
+package gospelagg
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jmalloc/gospel/src/gospel"
+)
+
+// Snapshot is a binary representation of an aggregate's state.
+type Snapshot struct {
+	// Offset is the next unused offset of the aggregate's stream at the time
+	// the snapshot was taken. Facts from this offset onwards are not reflected
+	// in the snapshot.
+	Offset uint64
+
+	// Data is the aggregate's state, as returned by its MarshalSnapshot()
+	// method.
+	Data []byte
+}
+
+// SnapshotStore is an interface for persisting aggregate snapshots.
+type SnapshotStore interface {
+	// LoadSnapshot returns the most recent snapshot of the aggregate stored in
+	// the given stream.
+	//
+	// ok is false if no snapshot has been saved.
+	LoadSnapshot(ctx context.Context, stream string) (s Snapshot, ok bool, err error)
+
+	// SaveSnapshot saves a snapshot of the aggregate stored in the given
+	// stream.
+	SaveSnapshot(ctx context.Context, stream string, s Snapshot) error
+}
+
+const (
+	// SnapshotStreamPrefix is prepended to the name of an aggregate's stream to
+	// produce the name of the stream that contains its snapshots. Stream names
+	// that begin with this prefix are reserved for snapshots, and can not be
+	// used as aggregate streams by a Repository.
+	SnapshotStreamPrefix = "$snapshot/"
+
+	// SnapshotEventType and SnapshotContentType are the event type and content
+	// type of the events used to store snapshots on a snapshot stream.
+	SnapshotEventType   = "gospel.snapshot"
+	SnapshotContentType = "application/vnd.gospel.snapshot.v1"
+
+	// snapshotOffsetKey is the metadata key that contains the snapshot's
+	// offset.
+	snapshotOffsetKey = "snapshot-offset"
+)
+
+// StreamSnapshotStore is a SnapshotStore that stores snapshots as events on a
+// separate stream of an event store.
+//
+// The snapshots of each aggregate are stored on a stream with the same name
+// as the aggregate's stream, preceded by SnapshotStreamPrefix.
+type StreamSnapshotStore struct {
+	// store is the event store that contains the snapshot streams.
+	store gospel.EventStore
+}
+
+// NewStreamSnapshotStore returns a snapshot store that stores snapshots on
+// streams of es.
+func NewStreamSnapshotStore(es gospel.EventStore) *StreamSnapshotStore {
+	return &StreamSnapshotStore{es}
+}
+
+// LoadSnapshot returns the most recent snapshot of the aggregate stored in
+// the given stream.
+//
+// ok is false if no snapshot has been saved.
+func (ss *StreamSnapshotStore) LoadSnapshot(
+	ctx context.Context,
+	stream string,
+) (Snapshot, bool, error) {
+	stream = SnapshotStreamPrefix + stream
+
+	info, err := ss.store.StreamInfo(ctx, stream)
+	if err != nil || !info.Exists {
+		return Snapshot{}, false, err
+	}
+
+	// Read the stream in reverse from its head, so that the most recent
+	// snapshot is the first fact read.
+	r, err := ss.store.Open(
+		ctx,
+		info.Next,
+		gospel.FilterByEventType(SnapshotEventType),
+		gospel.Reverse(),
+	)
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+	defer r.Close()
+
+	if _, err := r.Next(ctx); err == gospel.ErrEndOfRange {
+		return Snapshot{}, false, nil
+	} else if err != nil {
+		return Snapshot{}, false, err
+	}
+
+	ev := r.Get().Event
+
+	offset, err := strconv.ParseUint(ev.Metadata[snapshotOffsetKey], 10, 64)
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+
+	return Snapshot{offset, ev.Body}, true, nil
+}
+
+// SaveSnapshot saves a snapshot of the aggregate stored in the given stream.
+func (ss *StreamSnapshotStore) SaveSnapshot(
+	ctx context.Context,
+	stream string,
+	s Snapshot,
+) error {
+	_, err := ss.store.AppendUnchecked(
+		ctx,
+		SnapshotStreamPrefix+stream,
+		gospel.Event{
+			EventType:   SnapshotEventType,
+			ContentType: SnapshotContentType,
+			Body:        s.Data,
+			Metadata: map[string]string{
+				snapshotOffsetKey: strconv.FormatUint(s.Offset, 10),
+			},
+		},
+	)
+
+	return err
+}
@@ -0,0 +1,88 @@
+package gospelagg_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/jmalloc/gospel/src/gospelagg"
+	"github.com/jmalloc/gospel/src/gospelmem"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StreamSnapshotStore", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+
+		client    *gospelmem.Client
+		store     *gospelmem.EventStore
+		snapshots *StreamSnapshotStore
+	)
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 5*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+
+		client = gospelmem.NewClient()
+
+		var err error
+		store, err = client.OpenStore(ctx, "test")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		snapshots = NewStreamSnapshotStore(store)
+	})
+
+	AfterEach(func() {
+		cancel()
+		client.Close()
+	})
+
+	Describe("LoadSnapshot", func() {
+		It("returns ok == false if no snapshot has been saved", func() {
+			_, ok, err := snapshots.LoadSnapshot(ctx, "test-stream")
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("returns the most recent snapshot", func() {
+			err := snapshots.SaveSnapshot(ctx, "test-stream", Snapshot{10, []byte("<first>")})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			err = snapshots.SaveSnapshot(ctx, "test-stream", Snapshot{20, []byte("<second>")})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			s, ok, err := snapshots.LoadSnapshot(ctx, "test-stream")
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(s).To(Equal(Snapshot{20, []byte("<second>")}))
+		})
+	})
+
+	Describe("SaveSnapshot", func() {
+		It("appends the snapshot to the snapshot stream", func() {
+			err := snapshots.SaveSnapshot(ctx, "test-stream", Snapshot{10, []byte("<snapshot>")})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			info, err := store.StreamInfo(ctx, SnapshotStreamPrefix+"test-stream")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(info.Next).To(Equal(gospel.Address{
+				Stream: SnapshotStreamPrefix + "test-stream",
+				Offset: 1,
+			}))
+		})
+
+		It("does not modify the aggregate's stream", func() {
+			err := snapshots.SaveSnapshot(ctx, "test-stream", Snapshot{10, []byte("<snapshot>")})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			info, err := store.StreamInfo(ctx, "test-stream")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(info.Exists).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,76 @@
+package gospelcodec
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec is an interface for marshaling Go values to and from event bodies.
+type Codec interface {
+	// ContentType returns the content type of the event bodies produced by the
+	// codec, such as "application/json".
+	ContentType() string
+
+	// Marshal returns the binary representation of v.
+	Marshal(v interface{}) ([]byte, error)
+
+	// Unmarshal populates v, which must be a pointer, from its binary
+	// representation.
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSON is a codec that marshals values using encoding/json.
+var JSON = NewCodec("application/json", json.Marshal, json.Unmarshal)
+
+// Gob is a codec that marshals values using encoding/gob.
+//
+// Each value is encoded as a self-contained gob stream, so that it can be
+// decoded independently of any other event.
+var Gob = NewCodec("application/x-gob", marshalGob, unmarshalGob)
+
+// NewCodec returns a codec that uses the given functions to marshal values.
+//
+// It is used to adapt third-party marshaling packages, for example:
+//
+//	NewCodec("application/x-protobuf", marshalProto, unmarshalProto)
+//	NewCodec("application/msgpack", msgpack.Marshal, msgpack.Unmarshal)
+//
+// where marshalProto and unmarshalProto convert between interface{} and
+// proto.Message before calling proto.Marshal() and proto.Unmarshal().
+func NewCodec(
+	contentType string,
+	marshal func(v interface{}) ([]byte, error),
+	unmarshal func(data []byte, v interface{}) error,
+) Codec {
+	return &funcCodec{contentType, marshal, unmarshal}
+}
+
+// funcCodec is a Codec implemented by a pair of functions.
+type funcCodec struct {
+	contentType string
+	marshal     func(v interface{}) ([]byte, error)
+	unmarshal   func(data []byte, v interface{}) error
+}
+
+func (c *funcCodec) ContentType() string {
+	return c.contentType
+}
+
+func (c *funcCodec) Marshal(v interface{}) ([]byte, error) {
+	return c.marshal(v)
+}
+
+func (c *funcCodec) Unmarshal(data []byte, v interface{}) error {
+	return c.unmarshal(data, v)
+}
+
+func marshalGob(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func unmarshalGob(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
@@ -0,0 +1,56 @@
+package gospelcodec_test
+
+import (
+	"errors"
+
+	. "github.com/jmalloc/gospel/src/gospelcodec"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+// point is a value used to test codecs and registries.
+type point struct {
+	X, Y int
+}
+
+var _ = Describe("Codec", func() {
+	DescribeTable(
+		"it round-trips values",
+		func(c Codec, contentType string) {
+			Expect(c.ContentType()).To(Equal(contentType))
+
+			data, err := c.Marshal(point{1, 2})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			var p point
+			err = c.Unmarshal(data, &p)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(p).To(Equal(point{1, 2}))
+		},
+		Entry("JSON", JSON, "application/json"),
+		Entry("Gob", Gob, "application/x-gob"),
+	)
+
+	Describe("NewCodec", func() {
+		It("returns a codec that uses the given functions", func() {
+			e := errors.New("<error>")
+
+			c := NewCodec(
+				"<content-type>",
+				func(interface{}) ([]byte, error) { return []byte("<data>"), nil },
+				func([]byte, interface{}) error { return e },
+			)
+
+			Expect(c.ContentType()).To(Equal("<content-type>"))
+
+			data, err := c.Marshal(nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(data).To(Equal([]byte("<data>")))
+
+			err = c.Unmarshal(data, nil)
+			Expect(err).To(Equal(e))
+		})
+	})
+})
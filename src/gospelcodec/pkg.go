@@ -0,0 +1,21 @@
+// Package gospelcodec provides a registry that maps Go types to gospel event
+// types and content types, so that applications can append Go values and
+// read them back without hand-rolling the marshaling of each event body.
+//
+// Only two codecs are provided: JSON, which uses encoding/json, and Gob, which
+// uses encoding/gob. Codecs for other formats, such as protocol buffers or
+// MessagePack, are deliberately not included, so that this package does not
+// depend on any third-party marshaling packages.
+//
+// Other formats are supported by implementing the Codec interface, or by
+// adapting a package's marshaling functions with NewCodec(), for example:
+//
+//	var MsgPack = gospelcodec.NewCodec(
+//		"application/msgpack",
+//		msgpack.Marshal,
+//		msgpack.Unmarshal,
+//	)
+//
+// The resulting codec is passed to Registry.Register() in the same way as the
+// built-in codecs. Each codec must have a distinct content type.
+package gospelcodec
@@ -0,0 +1,26 @@
+package gospelcodec
+
+import "github.com/jmalloc/gospel/src/gospel"
+
+// Reader is a gospel.Reader that unmarshals the events of the facts that it
+// reads.
+type Reader struct {
+	gospel.Reader
+
+	// registry is used to unmarshal the event of the current fact.
+	registry *Registry
+}
+
+// NewReader returns a reader that reads facts from r and unmarshals their
+// events using reg.
+func NewReader(r gospel.Reader, reg *Registry) *Reader {
+	return &Reader{r, reg}
+}
+
+// Value returns the value contained in the event of the "current" fact.
+//
+// It panics if Next() or TryNext() has not returned successfully at least
+// once. See Registry.Unmarshal() for details of the errors it returns.
+func (r *Reader) Value() (interface{}, error) {
+	return r.registry.Unmarshal(r.Get().Event)
+}
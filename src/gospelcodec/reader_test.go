@@ -0,0 +1,74 @@
+package gospelcodec_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/jmalloc/gospel/src/gospelcodec"
+	"github.com/jmalloc/gospel/src/gospelmem"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reader", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+
+		client *gospelmem.Client
+		store  *gospelmem.EventStore
+		reg    *Registry
+	)
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 5*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+
+		client = gospelmem.NewClient()
+
+		var err error
+		store, err = client.OpenStore(ctx, "test")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		reg = NewRegistry()
+		reg.Register("point-moved", JSON, point{})
+		reg.Register("string-value", Gob, "")
+	})
+
+	AfterEach(func() {
+		cancel()
+		client.Close()
+	})
+
+	Describe("Value", func() {
+		It("returns the values that were appended", func() {
+			ev, err := reg.Events(point{1, 2}, "<value>")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = store.AppendUnchecked(ctx, "test-stream", ev...)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			r, err := store.Open(
+				ctx,
+				gospel.Address{Stream: "test-stream"},
+				gospel.StopAtHead(),
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			cr := NewReader(r, reg)
+			defer cr.Close()
+
+			var values []interface{}
+			err = gospel.ForEach(ctx, cr, func(gospel.Fact, gospel.Address) error {
+				v, err := cr.Value()
+				values = append(values, v)
+				return err
+			})
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(values).To(Equal([]interface{}{point{1, 2}, "<value>"}))
+		})
+	})
+})
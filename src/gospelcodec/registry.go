@@ -0,0 +1,200 @@
+package gospelcodec
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/jmalloc/gospel/src/gospel"
+)
+
+// Registry maps Go types to event types and content types.
+//
+// It is safe for concurrent use, although types are typically registered once,
+// during application startup.
+type Registry struct {
+	m       sync.RWMutex
+	byType  map[reflect.Type]registration
+	byEvent map[eventKey]registration
+}
+
+// registration associates a Go type with an event type and codec.
+type registration struct {
+	Type      reflect.Type
+	EventType string
+	Codec     Codec
+}
+
+// eventKey uniquely identifies the format of an event body.
+type eventKey struct {
+	EventType   string
+	ContentType string
+}
+
+// NewRegistry returns a new, empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		byType:  map[reflect.Type]registration{},
+		byEvent: map[eventKey]registration{},
+	}
+}
+
+// Register associates the type of v with an event type and codec.
+//
+// v is used only to determine the type, its value is ignored. Values of that
+// type are marshaled to events with the given event type and the codec's
+// content type, and events with that event type and content type are
+// unmarshaled to values of the same type.
+//
+// It panics if the type, or the combination of event type and content type,
+// is already registered, if eventType is empty, or if c is nil.
+func (r *Registry) Register(eventType string, c Codec, v interface{}) {
+	r.register(eventType, c, v, true)
+}
+
+// RegisterDecoder associates an event type and codec with the type of v, for
+// unmarshaling only.
+//
+// It is used to continue reading events that were appended in a different
+// format to the one that is now registered for the type, such as after
+// migrating from gob to JSON.
+//
+// It panics if the combination of event type and content type is already
+// registered, if eventType is empty, or if c is nil.
+func (r *Registry) RegisterDecoder(eventType string, c Codec, v interface{}) {
+	r.register(eventType, c, v, false)
+}
+
+func (r *Registry) register(eventType string, c Codec, v interface{}, encode bool) {
+	if eventType == "" {
+		panic("event type must not be empty")
+	}
+
+	if c == nil {
+		panic("codec must not be nil")
+	}
+
+	reg := registration{reflect.TypeOf(v), eventType, c}
+	key := eventKey{eventType, c.ContentType()}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if encode {
+		if _, ok := r.byType[reg.Type]; ok {
+			panic(fmt.Sprintf("%s is already registered", reg.Type))
+		}
+	}
+
+	if _, ok := r.byEvent[key]; ok {
+		panic(fmt.Sprintf(
+			"%s events with content type %s are already registered",
+			key.EventType,
+			key.ContentType,
+		))
+	}
+
+	if encode {
+		r.byType[reg.Type] = reg
+	}
+
+	r.byEvent[key] = reg
+}
+
+// Marshal returns an event that contains the binary representation of v.
+//
+// If the type of v is a pointer, and the pointer type is not registered, the
+// registration for the type that it points to is used instead.
+//
+// It returns an UnregisteredTypeError if the type of v is not registered.
+func (r *Registry) Marshal(v interface{}) (gospel.Event, error) {
+	t := reflect.TypeOf(v)
+
+	r.m.RLock()
+	reg, ok := r.byType[t]
+	if !ok && t != nil && t.Kind() == reflect.Ptr {
+		reg, ok = r.byType[t.Elem()]
+	}
+	r.m.RUnlock()
+
+	if !ok {
+		return gospel.Event{}, UnregisteredTypeError{t}
+	}
+
+	body, err := reg.Codec.Marshal(v)
+	if err != nil {
+		return gospel.Event{}, err
+	}
+
+	return gospel.Event{
+		EventType:   reg.EventType,
+		ContentType: reg.Codec.ContentType(),
+		Body:        body,
+	}, nil
+}
+
+// Events returns events containing the binary representations of the given
+// values, in order. The result can be passed to any of the
+// gospel.EventStore.Append() methods.
+func (r *Registry) Events(v ...interface{}) ([]gospel.Event, error) {
+	ev := make([]gospel.Event, len(v))
+
+	for i, x := range v {
+		e, err := r.Marshal(x)
+		if err != nil {
+			return nil, err
+		}
+
+		ev[i] = e
+	}
+
+	return ev, nil
+}
+
+// Unmarshal returns the value contained in the body of ev.
+//
+// The value has the type that was registered for ev's event type and content
+// type. It returns an UnregisteredEventError if that combination is not
+// registered.
+func (r *Registry) Unmarshal(ev gospel.Event) (interface{}, error) {
+	r.m.RLock()
+	reg, ok := r.byEvent[eventKey{ev.EventType, ev.ContentType}]
+	r.m.RUnlock()
+
+	if !ok {
+		return nil, UnregisteredEventError{ev.EventType, ev.ContentType}
+	}
+
+	p := reflect.New(reg.Type)
+
+	if err := reg.Codec.Unmarshal(ev.Body, p.Interface()); err != nil {
+		return nil, err
+	}
+
+	return p.Elem().Interface(), nil
+}
+
+// UnregisteredTypeError is returned by Registry.Marshal() when the type of the
+// value has not been registered.
+type UnregisteredTypeError struct {
+	Type reflect.Type
+}
+
+func (e UnregisteredTypeError) Error() string {
+	return fmt.Sprintf("%v is not registered", e.Type)
+}
+
+// UnregisteredEventError is returned by Registry.Unmarshal() when no type has
+// been registered for the event's event type and content type.
+type UnregisteredEventError struct {
+	EventType   string
+	ContentType string
+}
+
+func (e UnregisteredEventError) Error() string {
+	return fmt.Sprintf(
+		"no type is registered for %s events with content type %s",
+		e.EventType,
+		e.ContentType,
+	)
+}
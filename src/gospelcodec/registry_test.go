@@ -0,0 +1,169 @@
+package gospelcodec_test
+
+import (
+	"reflect"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/jmalloc/gospel/src/gospelcodec"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Registry", func() {
+	var reg *Registry
+
+	BeforeEach(func() {
+		reg = NewRegistry()
+		reg.Register("point-moved", JSON, point{})
+	})
+
+	Describe("Register", func() {
+		It("panics if the type is already registered", func() {
+			Expect(func() {
+				reg.Register("point-created", Gob, point{})
+			}).To(Panic())
+		})
+
+		It("panics if the event type and content type are already registered", func() {
+			Expect(func() {
+				reg.Register("point-moved", JSON, "")
+			}).To(Panic())
+		})
+
+		It("panics if the event type is empty", func() {
+			Expect(func() {
+				reg.Register("", JSON, "")
+			}).To(Panic())
+		})
+
+		It("panics if the codec is nil", func() {
+			Expect(func() {
+				reg.Register("point-created", nil, "")
+			}).To(Panic())
+		})
+
+		It("allows the same event type to be registered with different content types", func() {
+			Expect(func() {
+				reg.RegisterDecoder("point-moved", Gob, point{})
+			}).NotTo(Panic())
+		})
+	})
+
+	Describe("Marshal", func() {
+		It("returns an event containing the value", func() {
+			ev, err := reg.Marshal(point{1, 2})
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ev).To(Equal(gospel.Event{
+				EventType:   "point-moved",
+				ContentType: "application/json",
+				Body:        []byte(`{"X":1,"Y":2}`),
+			}))
+		})
+
+		It("uses the registration of the pointed-to type for unregistered pointers", func() {
+			ev, err := reg.Marshal(&point{1, 2})
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ev.EventType).To(Equal("point-moved"))
+			Expect(ev.Body).To(Equal([]byte(`{"X":1,"Y":2}`)))
+		})
+
+		It("returns an error if the type is not registered", func() {
+			_, err := reg.Marshal("<value>")
+
+			Expect(err).To(Equal(UnregisteredTypeError{reflect.TypeOf("")}))
+			Expect(err).To(MatchError("string is not registered"))
+		})
+
+		It("does not register the type when registering a decoder", func() {
+			reg.RegisterDecoder("string-value", JSON, "")
+
+			_, err := reg.Marshal("<value>")
+
+			Expect(err).To(BeAssignableToTypeOf(UnregisteredTypeError{}))
+		})
+	})
+
+	Describe("Events", func() {
+		It("returns an event for each value", func() {
+			reg.Register("string-value", Gob, "")
+
+			ev, err := reg.Events(point{1, 2}, "<value>")
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ev).To(HaveLen(2))
+			Expect(ev[0].EventType).To(Equal("point-moved"))
+			Expect(ev[1].EventType).To(Equal("string-value"))
+			Expect(ev[1].ContentType).To(Equal("application/x-gob"))
+		})
+
+		It("returns an error if any of the types are not registered", func() {
+			_, err := reg.Events(point{1, 2}, "<value>")
+
+			Expect(err).To(BeAssignableToTypeOf(UnregisteredTypeError{}))
+		})
+	})
+
+	Describe("Unmarshal", func() {
+		It("returns the value contained in the event", func() {
+			v, err := reg.Unmarshal(gospel.Event{
+				EventType:   "point-moved",
+				ContentType: "application/json",
+				Body:        []byte(`{"X":1,"Y":2}`),
+			})
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(v).To(Equal(point{1, 2}))
+		})
+
+		It("returns values of registered pointer types as pointers", func() {
+			reg.Register("point-created", Gob, &point{})
+
+			ev, err := reg.Marshal(&point{1, 2})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ev.EventType).To(Equal("point-created"))
+
+			v, err := reg.Unmarshal(ev)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(v).To(Equal(&point{1, 2}))
+		})
+
+		It("unmarshals events in formats registered with RegisterDecoder", func() {
+			reg.RegisterDecoder("point-moved", Gob, point{})
+
+			data, err := Gob.Marshal(point{1, 2})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			v, err := reg.Unmarshal(gospel.Event{
+				EventType:   "point-moved",
+				ContentType: "application/x-gob",
+				Body:        data,
+			})
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(v).To(Equal(point{1, 2}))
+		})
+
+		It("returns an error if the event type and content type are not registered", func() {
+			_, err := reg.Unmarshal(gospel.Event{
+				EventType:   "point-moved",
+				ContentType: "text/plain",
+			})
+
+			Expect(err).To(Equal(UnregisteredEventError{"point-moved", "text/plain"}))
+			Expect(err).To(MatchError("no type is registered for point-moved events with content type text/plain"))
+		})
+
+		It("returns an error if the body can not be unmarshaled", func() {
+			_, err := reg.Unmarshal(gospel.Event{
+				EventType:   "point-moved",
+				ContentType: "application/json",
+				Body:        []byte(`<invalid>`),
+			})
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,193 @@
+package gospelfile
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/internal/options"
+	"github.com/jmalloc/twelf/src/twelf"
+	"go.uber.org/multierr"
+)
+
+// storeDirExt is the extension added to the name of each store directory.
+const storeDirExt = ".store"
+
+// Client provides access to the event stores within a directory.
+//
+// Each directory supports an arbitrary number of named event stores.
+type Client struct {
+	// dir is the directory that contains the store directories.
+	dir string
+
+	// m protects stores and is held while a store is being opened.
+	m sync.Mutex
+
+	// stores is a map of store name to the open store.
+	stores map[string]*store
+
+	// segmentSize is the size at which a new segment file is started.
+	segmentSize int64
+
+	// syncInterval is the interval at which appends are flushed to stable
+	// storage, or one of the special values syncAlways or syncNever.
+	syncInterval time.Duration
+
+	// done is a signaling channel that is closed when Close() is called. It is
+	// shared by all event stores and readers created through this client.
+	done chan struct{}
+
+	// syncDone is a signaling channel that is closed when the goroutine that
+	// periodically flushes appends has returned, if there is one.
+	syncDone chan struct{}
+
+	// logger is the logger to use for activity and debug logging. It is
+	// inherited by all event stores and their readers.
+	logger twelf.Logger
+}
+
+// errClientClosed is an error returned by any operation that is performed
+// after the client has been closed.
+var errClientClosed = errors.New("client is closed")
+
+// Open returns a new Client instance for the event stores within dir, creating
+// the directory if it does not already exist.
+func Open(dir string, opts ...gospel.Option) (*Client, error) {
+	o := options.NewClientOptions(opts)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		dir:          dir,
+		stores:       map[string]*store{},
+		segmentSize:  getSegmentSize(o),
+		syncInterval: getSyncInterval(o),
+		done:         make(chan struct{}),
+		syncDone:     make(chan struct{}),
+		logger:       o.Logger,
+	}
+
+	if c.syncInterval > 0 {
+		go c.syncPeriodically()
+	} else {
+		close(c.syncDone)
+	}
+
+	o.Logger.Log(
+		"opened file-based event store at %s",
+		dir,
+	)
+
+	return c, nil
+}
+
+// OpenStore returns an event store by name, creating it if it does not already
+// exist.
+//
+// ctx applies to the opening of the store, and not to the store itself.
+func (c *Client) OpenStore(ctx context.Context, name string) (*EventStore, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	select {
+	case <-c.done:
+		return nil, errClientClosed
+	default:
+	}
+
+	s, ok := c.stores[name]
+
+	if !ok {
+		var err error
+		s, err = openStore(
+			name,
+			filepath.Join(c.dir, url.PathEscape(name)+storeDirExt),
+			c.segmentSize,
+			c.syncInterval == syncAlways,
+			c.done,
+			c.logger,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		c.stores[name] = s
+	}
+
+	c.logger.Debug("opened '%s' event store", name)
+
+	return &EventStore{
+		s,
+		c.logger,
+	}, nil
+}
+
+// Close flushes all appends to stable storage and closes the segment files.
+//
+// Any operations performed on the client's event stores or readers after it is
+// closed return an error.
+func (c *Client) Close() error {
+	c.m.Lock()
+
+	select {
+	case <-c.done:
+		c.m.Unlock()
+		return nil
+	default:
+		close(c.done)
+	}
+
+	c.m.Unlock()
+
+	// No stores can be opened once c.done is closed, so it's safe to use
+	// c.stores without the lock once the sync goroutine has stopped.
+	<-c.syncDone
+
+	var err error
+	for _, s := range c.stores {
+		err = multierr.Append(err, s.close())
+	}
+
+	return err
+}
+
+// syncPeriodically flushes the appends made to each store to stable storage
+// at the configured interval, until the client is closed.
+func (c *Client) syncPeriodically() {
+	defer close(c.syncDone)
+
+	t := time.NewTicker(c.syncInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+		case <-c.done:
+			return
+		}
+
+		c.m.Lock()
+		stores := make([]*store, 0, len(c.stores))
+		for _, s := range c.stores {
+			stores = append(stores, s)
+		}
+		c.m.Unlock()
+
+		for _, s := range stores {
+			if err := s.sync(); err != nil {
+				c.logger.Log("unable to sync '%s' event store: %s", s.name, err)
+			}
+		}
+	}
+}
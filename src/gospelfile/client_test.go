@@ -0,0 +1,113 @@
+package gospelfile_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/jmalloc/gospel/src/gospelfile"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+		dir    string
+		client *Client
+	)
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 3*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+
+		dir = getTestDir()
+		client = getTestClient(dir)
+	})
+
+	AfterEach(func() {
+		cancel()
+		client.Close()
+		destroyTestDir(dir)
+	})
+
+	Describe("OpenStore", func() {
+		It("returns a gospel.EventStore", func() {
+			var es gospel.EventStore // static interface check
+			es, err := client.OpenStore(ctx, "test")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(es).NotTo(BeNil())
+		})
+
+		It("allows store names that are not valid file names", func() {
+			_, err := client.OpenStore(ctx, "../test/")
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("returns an error if the client is closed", func() {
+			client.Close()
+			_, err := client.OpenStore(ctx, "test")
+			Expect(err).Should(MatchError("client is closed"))
+		})
+	})
+
+	Describe("Close", func() {
+		It("causes appends to return an error", func() {
+			es, err := client.OpenStore(ctx, "test")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			client.Close()
+
+			_, err = es.AppendUnchecked(ctx, "test-stream", gospel.Event{EventType: "event-type-1"})
+			Expect(err).Should(MatchError("client is closed"))
+		})
+
+		It("persists facts so that they are available to a new client", func() {
+			es, err := client.OpenStore(ctx, "test")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = es.AppendUnchecked(
+				ctx,
+				"test-stream",
+				gospel.Event{EventType: "event-type-1", ContentType: "text/plain", Body: []byte("event-1")},
+				gospel.Event{EventType: "event-type-2", ContentType: "text/plain"},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			client.Close()
+
+			c, es := getTestStore(dir)
+			defer c.Close()
+
+			nx, err := es.Append(ctx, gospel.Address{Stream: "test-stream", Offset: 2}, gospel.Event{EventType: "event-type-3"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(nx.Offset).To(BeEquivalentTo(3))
+
+			r, err := es.Open(ctx, gospel.Address{})
+			Expect(err).ShouldNot(HaveOccurred())
+			defer r.Close()
+
+			var types []string
+			for {
+				_, ok, err := r.TryNext(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				if !ok {
+					break
+				}
+
+				types = append(types, r.Get().Event.EventType)
+			}
+
+			Expect(types).To(Equal([]string{
+				"$store.created",
+				"$stream.created",
+				"event-type-1",
+				"event-type-2",
+				"event-type-3",
+			}))
+		})
+	})
+})
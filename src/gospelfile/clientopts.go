@@ -0,0 +1,94 @@
+package gospelfile
+
+import (
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/internal/options"
+)
+
+const (
+	// DefaultSegmentSize is the default size at which a new segment file is
+	// started. It is used if no specific size is set via SegmentSize().
+	DefaultSegmentSize = 64 * 1024 * 1024
+
+	// syncAlways and syncNever are the special values for the sync interval
+	// used to represent the SyncAlways() and SyncNever() policies.
+	syncAlways time.Duration = 0
+	syncNever  time.Duration = -1
+)
+
+// clientOptionKey is a custom type used to ensure that file-specific keys can
+// not clash with custom options from other systems.
+type clientOptionKey int
+
+const (
+	segmentSizeKey clientOptionKey = iota
+	syncIntervalKey
+)
+
+// SegmentSize is a client option that sets the size at which a new segment
+// file is started.
+//
+// A single append is never split across segments, so segments may exceed this
+// size when a large append is made.
+func SegmentSize(n int64) gospel.Option {
+	return func(o *options.ClientOptions) {
+		o.Set(segmentSizeKey, n)
+	}
+}
+
+// getSegmentSize returns the segment size to use for the given client options,
+// falling back to the default if necessary.
+func getSegmentSize(o *options.ClientOptions) int64 {
+	if v, ok := o.Get(segmentSizeKey); ok {
+		return v.(int64)
+	}
+
+	return DefaultSegmentSize
+}
+
+// SyncAlways is a client option that causes every append to be flushed to
+// stable storage before it returns.
+//
+// This is the default sync policy. It guarantees that a successful append
+// survives a crash of the process or the operating system.
+func SyncAlways() gospel.Option {
+	return func(o *options.ClientOptions) {
+		o.Set(syncIntervalKey, syncAlways)
+	}
+}
+
+// SyncInterval is a client option that causes appends to be flushed to stable
+// storage periodically, rather than after each append.
+//
+// Appends that were made within the interval preceding a crash of the
+// operating system may be lost. Appends are not lost if only the process
+// crashes.
+func SyncInterval(d time.Duration) gospel.Option {
+	if d <= 0 {
+		return SyncAlways()
+	}
+
+	return func(o *options.ClientOptions) {
+		o.Set(syncIntervalKey, d)
+	}
+}
+
+// SyncNever is a client option that leaves the flushing of appends to stable
+// storage entirely to the operating system, except when the client is closed.
+func SyncNever() gospel.Option {
+	return func(o *options.ClientOptions) {
+		o.Set(syncIntervalKey, syncNever)
+	}
+}
+
+// getSyncInterval returns the sync interval to use for the given client
+// options, falling back to the default if necessary.
+func getSyncInterval(o *options.ClientOptions) time.Duration {
+	if v, ok := o.Get(syncIntervalKey); ok {
+		return v.(time.Duration)
+	}
+
+	return syncAlways
+}
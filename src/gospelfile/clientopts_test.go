@@ -0,0 +1,82 @@
+package gospelfile
+
+import (
+	"time"
+
+	"github.com/jmalloc/gospel/src/internal/options"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("segment size option", func() {
+	Describe("SegmentSize", func() {
+		It("sets the segment size", func() {
+			opts := &options.ClientOptions{}
+
+			SegmentSize(1024)(opts)
+
+			Expect(getSegmentSize(opts)).To(
+				BeNumerically("==", 1024),
+			)
+		})
+	})
+
+	Describe("getSegmentSize", func() {
+		It("returns the default segment size if none is set", func() {
+			opts := &options.ClientOptions{}
+
+			Expect(getSegmentSize(opts)).To(
+				BeNumerically("==", DefaultSegmentSize),
+			)
+		})
+	})
+})
+
+var _ = Describe("sync policy options", func() {
+	Describe("SyncAlways", func() {
+		It("sets the policy to sync after every append", func() {
+			opts := &options.ClientOptions{}
+
+			SyncNever()(opts)
+			SyncAlways()(opts)
+
+			Expect(getSyncInterval(opts)).To(Equal(syncAlways))
+		})
+	})
+
+	Describe("SyncInterval", func() {
+		It("sets the sync interval", func() {
+			opts := &options.ClientOptions{}
+
+			SyncInterval(time.Second)(opts)
+
+			Expect(getSyncInterval(opts)).To(Equal(time.Second))
+		})
+
+		It("syncs after every append if the interval is not positive", func() {
+			opts := &options.ClientOptions{}
+
+			SyncInterval(0)(opts)
+
+			Expect(getSyncInterval(opts)).To(Equal(syncAlways))
+		})
+	})
+
+	Describe("SyncNever", func() {
+		It("sets the policy to never sync", func() {
+			opts := &options.ClientOptions{}
+
+			SyncNever()(opts)
+
+			Expect(getSyncInterval(opts)).To(Equal(syncNever))
+		})
+	})
+
+	Describe("getSyncInterval", func() {
+		It("syncs after every append if no policy is set", func() {
+			opts := &options.ClientOptions{}
+
+			Expect(getSyncInterval(opts)).To(Equal(syncAlways))
+		})
+	})
+})
@@ -0,0 +1,23 @@
+package gospelfile_test
+
+import (
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/gospeltest"
+)
+
+var (
+	_ = gospeltest.DescribeEventStore(newConformanceStore)
+	_ = gospeltest.DescribeReader(newConformanceStore)
+)
+
+// newConformanceStore is a gospeltest.Factory that returns an EventStore that
+// uses a new temporary directory.
+func newConformanceStore() (gospel.EventStore, func()) {
+	dir := getTestDir()
+	c, es := getTestStore(dir)
+
+	return es, func() {
+		c.Close()
+		destroyTestDir(dir)
+	}
+}
@@ -0,0 +1,340 @@
+package gospelfile
+
+import (
+	"context"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/internal/apierror"
+	"github.com/jmalloc/gospel/src/internal/logging"
+	"github.com/jmalloc/gospel/src/internal/options"
+	"github.com/jmalloc/gospel/src/internal/plan"
+	"github.com/jmalloc/twelf/src/twelf"
+)
+
+// EventStore an interface for reading and writing streams of events stored in
+// segment files.
+type EventStore struct {
+	// store is the segment files and indexes for the store.
+	store *store
+
+	// logger is the logger to use for activity and debug logging.
+	logger twelf.Logger
+}
+
+// Append atomically writes one or more events to the end of a stream,
+// producing a contiguous block of facts.
+//
+// addr.Offset must refer to the next unused offset within the stream,
+// otherwise the append fails, and IsConflict(err) returns true.
+//
+// nx is the address of the next unused offset after the facts have been
+// appended.
+//
+// Append panics if ev is empty.
+func (es *EventStore) Append(
+	ctx context.Context,
+	addr gospel.Address,
+	ev ...gospel.Event,
+) (gospel.Address, error) {
+	nx, err := es.append(ctx, addr.Stream, ev, func() error {
+		if es.store.next(addr.Stream) != addr.Offset {
+			return apierror.NewConflict(
+				addr,
+				ev[0],
+				gospel.Address{
+					Stream: addr.Stream,
+					Offset: es.store.next(addr.Stream),
+				},
+			)
+		}
+
+		return nil
+	})
+
+	if err == nil {
+		logging.AppendChecked(
+			es.logger,
+			gospel.Address{
+				Stream: es.store.name + "::" + nx.Stream,
+				Offset: nx.Offset,
+			},
+			ev,
+		)
+	} else if e, ok := err.(gospel.ConflictError); ok {
+		logging.Conflict(es.logger, e)
+	}
+
+	return nx, err
+}
+
+// AppendUnchecked atomically writes one or more events to the end of a
+// stream, producing a contiguous block of facts.
+//
+// Unlike Append(), the caller is not required to know the next unused
+// offset of the stream, hence the offset is said to be "unchecked".
+//
+// nx is the address of the next unused offset after the facts have been
+// appended.
+//
+// AppendUnchecked panics if ev is empty.
+func (es *EventStore) AppendUnchecked(
+	ctx context.Context,
+	stream string,
+	ev ...gospel.Event,
+) (gospel.Address, error) {
+	nx, err := es.append(ctx, stream, ev, nil)
+
+	if err == nil {
+		logging.AppendUnchecked(
+			es.logger,
+			gospel.Address{
+				Stream: es.store.name + "::" + nx.Stream,
+				Offset: nx.Offset,
+			},
+			ev,
+		)
+	}
+
+	return nx, err
+}
+
+// AppendExpected atomically writes one or more events to the end of a
+// stream, producing a contiguous block of facts.
+//
+// The stream must satisfy the expectation e, otherwise the append fails,
+// and IsConflict(err) returns true.
+//
+// nx is the address of the next unused offset after the facts have been
+// appended.
+//
+// AppendExpected panics if ev is empty.
+func (es *EventStore) AppendExpected(
+	ctx context.Context,
+	stream string,
+	e gospel.Expectation,
+	ev ...gospel.Event,
+) (gospel.Address, error) {
+	nx, err := es.append(ctx, stream, ev, func() error {
+		next := es.store.next(stream)
+
+		if !e.IsSatisfiedBy(next) {
+			return apierror.NewExpectationConflict(
+				stream,
+				e,
+				ev[0],
+				gospel.Address{
+					Stream: stream,
+					Offset: next,
+				},
+			)
+		}
+
+		return nil
+	})
+
+	if err == nil {
+		logging.AppendExpected(
+			es.logger,
+			gospel.Address{
+				Stream: es.store.name + "::" + nx.Stream,
+				Offset: nx.Offset,
+			},
+			e,
+			ev,
+		)
+	} else if c, ok := err.(gospel.ConflictError); ok {
+		logging.Conflict(es.logger, c)
+	}
+
+	return nx, err
+}
+
+// AppendMulti atomically writes events to one or more streams. Either all
+// of the events are appended, or none of them are.
+//
+// Each stream must satisfy the expectation in its StreamAppend, otherwise the
+// append fails, and IsConflict(err) returns true. The address returned by the
+// error's ConflictDetails() method identifies the stream that conflicted.
+//
+// nx contains the address of the next unused offset of each stream after its
+// facts have been appended, in the same order as a.
+//
+// AppendMulti panics if a is empty, if any element of a has no events, or if
+// any element of a refers to the ε-stream.
+func (es *EventStore) AppendMulti(
+	ctx context.Context,
+	a ...gospel.StreamAppend,
+) ([]gospel.Address, error) {
+	nx, err := es.appendMulti(ctx, a)
+
+	if err == nil {
+		for i, x := range a {
+			logging.AppendExpected(
+				es.logger,
+				gospel.Address{
+					Stream: es.store.name + "::" + nx[i].Stream,
+					Offset: nx[i].Offset,
+				},
+				x.Expectation,
+				x.Events,
+			)
+		}
+	} else if c, ok := err.(gospel.ConflictError); ok {
+		logging.Conflict(es.logger, c)
+	}
+
+	return nx, err
+}
+
+// Open returns a reader that begins reading facts at addr.
+//
+// ctx applies to the opening of the reader, and not to the reader itself.
+func (es *EventStore) Open(
+	ctx context.Context,
+	addr gospel.Address,
+	opts ...gospel.ReaderOption,
+) (gospel.Reader, error) {
+	if err := es.check(ctx); err != nil {
+		return nil, err
+	}
+
+	return openReader(
+		es.store,
+		addr,
+		es.logger,
+		options.NewReaderOptions(opts),
+	), nil
+}
+
+// StreamInfo returns information about the current state of a stream,
+// including the next unused offset.
+//
+// It does not return an error if the stream does not exist, instead
+// info.Exists is false.
+func (es *EventStore) StreamInfo(
+	ctx context.Context,
+	stream string,
+) (gospel.StreamInfo, error) {
+	if err := es.check(ctx); err != nil {
+		return gospel.StreamInfo{}, err
+	}
+
+	es.store.m.RLock()
+	defer es.store.m.RUnlock()
+
+	return es.store.info(stream), nil
+}
+
+// append writes events to a stream after verifying that the write is allowed
+// using the check function, if it is non-nil.
+//
+// check is called while the store's write lock is held.
+func (es *EventStore) append(
+	ctx context.Context,
+	stream string,
+	events []gospel.Event,
+	check func() error,
+) (gospel.Address, error) {
+	plan.Check([]gospel.StreamAppend{
+		{Stream: stream, Events: events},
+	})
+
+	if err := es.check(ctx); err != nil {
+		return gospel.Address{}, err
+	}
+
+	es.store.m.Lock()
+
+	if es.store.closed {
+		es.store.m.Unlock()
+		return gospel.Address{}, errClientClosed
+	}
+
+	nx, repeat, err := plan.Append(es.store, stream, events)
+	if repeat || err != nil {
+		es.store.m.Unlock()
+		return nx, err
+	}
+
+	if check != nil {
+		if err := check(); err != nil {
+			es.store.m.Unlock()
+			return gospel.Address{}, err
+		}
+	}
+
+	nx, err = es.store.append(stream, events)
+
+	es.store.m.Unlock()
+
+	if err != nil {
+		return gospel.Address{}, err
+	}
+
+	es.store.notify(stream)
+
+	return nx, nil
+}
+
+// appendMulti writes events to several streams as a single batch after
+// verifying that every stream satisfies its expectation.
+func (es *EventStore) appendMulti(
+	ctx context.Context,
+	appends []gospel.StreamAppend,
+) ([]gospel.Address, error) {
+	plan.Check(appends)
+
+	if err := es.check(ctx); err != nil {
+		return nil, err
+	}
+
+	es.store.m.Lock()
+
+	if es.store.closed {
+		es.store.m.Unlock()
+		return nil, errClientClosed
+	}
+
+	addrs, repeats, err := plan.Appends(es.store, appends)
+	if err != nil {
+		es.store.m.Unlock()
+		return nil, err
+	}
+
+	var todo []gospel.StreamAppend
+	for i, a := range appends {
+		if !repeats[i] {
+			todo = append(todo, a)
+		}
+	}
+
+	if len(todo) > 0 {
+		_, err = es.store.appendMulti(todo)
+	}
+
+	es.store.m.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range todo {
+		es.store.notify(a.Stream)
+	}
+
+	return addrs, nil
+}
+
+// check returns an error if ctx is canceled or the client has been closed.
+func (es *EventStore) check(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	select {
+	case <-es.store.done:
+		return errClientClosed
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,65 @@
+package gospelfile_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/gospelfile"
+	"github.com/jmalloc/twelf/src/twelf"
+)
+
+// getTestDir returns the path to a new temporary directory.
+func getTestDir() string {
+	dir, err := ioutil.TempDir("", "gospelfile")
+	if err != nil {
+		panic(err)
+	}
+
+	return dir
+}
+
+// getTestClient returns a Client that uses the given directory.
+func getTestClient(dir string, opts ...gospel.Option) *gospelfile.Client {
+	opts = append(
+		[]gospel.Option{
+			gospel.Logger(
+				&twelf.StandardLogger{
+					CaptureDebug: true,
+				},
+			),
+		},
+		opts...,
+	)
+
+	c, err := gospelfile.Open(dir, opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// getTestStore returns an EventStore that uses the given directory.
+func getTestStore(dir string, opts ...gospel.Option) (*gospelfile.Client, *gospelfile.EventStore) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	c := getTestClient(dir, opts...)
+	es, err := c.OpenStore(ctx, "test")
+	if err != nil {
+		c.Close()
+		panic(err)
+	}
+
+	return c, es
+}
+
+// destroyTestDir removes the given directory.
+func destroyTestDir(dir string) {
+	if err := os.RemoveAll(dir); err != nil {
+		panic(err)
+	}
+}
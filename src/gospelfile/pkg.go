@@ -0,0 +1,12 @@
+// Package gospelfile is an implementation of the gospel public API that stores
+// facts in append-only segment files on the local filesystem.
+//
+// Each event store is a directory containing one or more segment files. Every
+// append is written to the active segment as a single checksummed batch, so
+// that a partially written append can be detected and discarded when the store
+// is next opened. An index of each stream, and of the ε-stream, is rebuilt in
+// memory from the segment files when a store is opened.
+//
+// A store directory must not be opened by more than one client at a time,
+// either within a single process or across multiple processes.
+package gospelfile
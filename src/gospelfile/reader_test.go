@@ -0,0 +1,176 @@
+package gospelfile_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/jmalloc/gospel/src/gospelfile"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reader", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+
+		dir    string
+		client *Client
+		store  *EventStore
+		reader gospel.Reader
+
+		addr gospel.Address
+		opts []gospel.ReaderOption
+	)
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 3*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+
+		dir = getTestDir()
+		client, store = getTestStore(dir)
+
+		_, err := store.AppendUnchecked(
+			ctx,
+			"test-stream",
+			gospel.Event{EventType: "event-type-1", Body: []byte("event-1")},
+			gospel.Event{EventType: "event-type-2", Body: []byte("event-2")},
+			gospel.Event{EventType: "event-type-3", Body: []byte("event-3")},
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		addr = gospel.Address{
+			Stream: "test-stream",
+			Offset: 0,
+		}
+		opts = nil
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		reader, err = store.Open(ctx, addr, opts...)
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	AfterEach(func() {
+		cancel()
+		reader.Close()
+		client.Close()
+		destroyTestDir(dir)
+	})
+
+	Describe("Next", func() {
+		It("wakes immediately when a fact is appended", func() {
+			for i := 0; i < 3; i++ {
+				_, err := reader.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+			}
+
+			go func() {
+				defer GinkgoRecover()
+
+				time.Sleep(50 * time.Millisecond)
+
+				_, err := store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{EventType: "event-type-4", Body: []byte("event-4")},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+			}()
+
+			nextCtx, cancel := context.WithTimeout(ctx, 250*time.Millisecond)
+			defer cancel()
+
+			_, err := reader.Next(nextCtx)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(reader.Get().Event.Body).To(Equal([]byte("event-4")))
+		})
+
+		It("returns an error if the reader is closed", func() {
+			reader.Close()
+
+			_, err := reader.Next(ctx)
+			Expect(err).To(MatchError("reader is closed"))
+		})
+
+		It("returns an error if the reader is closed while blocked", func() {
+			for i := 0; i < 3; i++ {
+				_, err := reader.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+			}
+
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				reader.Close()
+			}()
+
+			_, err := reader.Next(ctx)
+			Expect(err).To(MatchError("reader is closed"))
+		})
+
+		It("returns an error if the client is closed while blocked", func() {
+			for i := 0; i < 3; i++ {
+				_, err := reader.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+			}
+
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				client.Close()
+			}()
+
+			_, err := reader.Next(ctx)
+			Expect(err).To(MatchError("client is closed"))
+		})
+	})
+
+	Describe("TryNext", func() {
+		It("does not return facts appended to other streams", func() {
+			for i := 0; i < 3; i++ {
+				_, ok, err := reader.TryNext(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(ok).To(BeTrue())
+			}
+
+			_, err := store.AppendUnchecked(
+				ctx,
+				"other-stream",
+				gospel.Event{},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, ok, err := reader.TryNext(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("returns an error if the reader is closed", func() {
+			reader.Close()
+
+			_, _, err := reader.TryNext(ctx)
+			Expect(err).To(MatchError("reader is closed"))
+		})
+	})
+
+	Context("when using an event-type filter", func() {
+		BeforeEach(func() {
+			opts = append(opts, gospel.FilterByEventType(
+				"event-type-1",
+				"event-type-3",
+			))
+		})
+
+		Describe("Next", func() {
+			It("returns the address of the next unfiltered fact", func() {
+				nx, err := reader.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(nx).To(Equal(addr.Next().Next())) // second fact is filtered out
+			})
+		})
+	})
+})
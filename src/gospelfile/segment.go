@@ -0,0 +1,399 @@
+package gospelfile
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+)
+
+const (
+	// segmentExt is the file extension used for segment files.
+	segmentExt = ".log"
+
+	// batchHeaderSize is the size of the header that precedes each batch. It
+	// contains the size of the batch payload, followed by its CRC-32 checksum.
+	batchHeaderSize = 8
+
+	// maxBatchSize is the maximum size of a batch payload. Larger sizes found
+	// in a batch header are assumed to be the result of a torn write.
+	maxBatchSize = 1 << 30
+)
+
+// errCorruptBatch is an error returned when a batch can not be decoded.
+var errCorruptBatch = errors.New("batch is corrupt")
+
+// crcTable is the table used to compute batch checksums.
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// record is a single fact as it is stored in a segment file.
+type record struct {
+	// Time is the time at which the fact was appended.
+	Time time.Time
+
+	// Stream is the name of the stream that the event was appended to. It is
+	// empty for facts that only appear on the ε-stream, such as those
+	// describing the creation of a stream.
+	Stream string
+
+	// Event is the event itself.
+	Event gospel.Event
+}
+
+// segment is a single append-only file that contains a contiguous range of
+// the facts on the ε-stream.
+//
+// A segment consists of a sequence of batches. Each batch holds the records
+// produced by a single append operation, and is written with a single write
+// call. The batch header contains a checksum that is used to detect batches
+// that were only partially written.
+type segment struct {
+	// first is the ε-stream offset of the first fact in the segment.
+	first uint64
+
+	// file is the underlying segment file.
+	file *os.File
+
+	// size is the size of the valid data in the file, and hence the position
+	// at which the next batch is written.
+	size int64
+}
+
+// segmentPath returns the path to the segment file within dir that begins at
+// the ε-stream offset first.
+func segmentPath(dir string, first uint64) string {
+	return filepath.Join(
+		dir,
+		fmt.Sprintf("%020d%s", first, segmentExt),
+	)
+}
+
+// createSegment creates a new, empty segment file in dir.
+func createSegment(dir string, first uint64) (*segment, error) {
+	f, err := os.OpenFile(
+		segmentPath(dir, first),
+		os.O_RDWR|os.O_CREATE|os.O_EXCL,
+		0644,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &segment{first, f, 0}, nil
+}
+
+// openSegment opens an existing segment file.
+func openSegment(path string, first uint64) (*segment, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &segment{first, f, 0}, nil
+}
+
+// scan reads each batch in the segment, calling fn for each record. pos and n
+// are the position and length of the encoded record within the file.
+//
+// It stops at the first batch that is incomplete or fails its checksum and
+// sets s.size to the position of that batch. It returns true if there is
+// trailing data in the file that is not part of a valid batch.
+func (s *segment) scan(fn func(rec record, pos int64, n int)) (bool, error) {
+	info, err := s.file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	s.size = 0
+	header := make([]byte, batchHeaderSize)
+
+	for s.size < info.Size() {
+		if _, err := s.file.ReadAt(header, s.size); err != nil {
+			if err == io.EOF {
+				return true, nil
+			}
+			return false, err
+		}
+
+		size := binary.BigEndian.Uint32(header)
+		sum := binary.BigEndian.Uint32(header[4:])
+
+		if size > maxBatchSize {
+			return true, nil
+		}
+
+		payload := make([]byte, size)
+		if _, err := s.file.ReadAt(payload, s.size+batchHeaderSize); err != nil {
+			if err == io.EOF {
+				return true, nil
+			}
+			return false, err
+		}
+
+		if crc32.Checksum(payload, crcTable) != sum {
+			return true, nil
+		}
+
+		// Decode the entire batch before calling fn, so that records from a
+		// batch that is later found to be corrupt are never indexed.
+		entries, err := decodeBatch(payload)
+		if err != nil {
+			return true, nil
+		}
+
+		for _, e := range entries {
+			fn(e.rec, s.size+batchHeaderSize+int64(e.pos), e.n)
+		}
+
+		s.size += batchHeaderSize + int64(size)
+	}
+
+	return false, nil
+}
+
+// truncate discards any data in the file beyond s.size.
+func (s *segment) truncate() error {
+	return s.file.Truncate(s.size)
+}
+
+// write writes an encoded batch to the end of the segment. It returns the
+// position at which the batch was written.
+//
+// If the write fails, any partially written data is discarded.
+func (s *segment) write(batch []byte) (int64, error) {
+	pos := s.size
+
+	if _, err := s.file.WriteAt(batch, pos); err != nil {
+		s.file.Truncate(pos) // best-effort, the batch is discarded on open anyway
+		return 0, err
+	}
+
+	s.size += int64(len(batch))
+
+	return pos, nil
+}
+
+// read returns the record that is n bytes long at position pos.
+func (s *segment) read(pos int64, n int) (record, error) {
+	buf := make([]byte, n)
+
+	if _, err := s.file.ReadAt(buf, pos); err != nil {
+		return record{}, err
+	}
+
+	return decodeRecord(buf)
+}
+
+// close closes the segment file.
+func (s *segment) close() error {
+	return s.file.Close()
+}
+
+// encodeBatch returns the encoded representation of a batch containing the
+// given records, including the batch header.
+//
+// offsets is populated with the position and length of each encoded record,
+// relative to the beginning of the batch.
+func encodeBatch(records []record, offsets [][2]int) []byte {
+	buf := make([]byte, batchHeaderSize, 256)
+	buf = appendUvarint(buf, uint64(len(records)))
+
+	for i, rec := range records {
+		r := encodeRecord(rec)
+		buf = appendUvarint(buf, uint64(len(r)))
+		offsets[i] = [2]int{len(buf), len(r)}
+		buf = append(buf, r...)
+	}
+
+	payload := buf[batchHeaderSize:]
+	binary.BigEndian.PutUint32(buf, uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:], crc32.Checksum(payload, crcTable))
+
+	return buf
+}
+
+// batchEntry is a record decoded from a batch payload, along with the position
+// and length of the encoded record within the payload.
+type batchEntry struct {
+	rec    record
+	pos, n int
+}
+
+// decodeBatch decodes a batch payload, returning all of its records. It fails
+// if any record in the batch can not be decoded.
+func decodeBatch(payload []byte) ([]batchEntry, error) {
+	count, i := binary.Uvarint(payload)
+	if i <= 0 {
+		return nil, errCorruptBatch
+	}
+
+	var entries []batchEntry
+
+	for ; count > 0; count-- {
+		n, w := binary.Uvarint(payload[i:])
+		if w <= 0 || n > uint64(len(payload)-i-w) {
+			return nil, errCorruptBatch
+		}
+
+		i += w
+
+		rec, err := decodeRecord(payload[i : i+int(n)])
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, batchEntry{rec, i, int(n)})
+		i += int(n)
+	}
+
+	if i != len(payload) {
+		return nil, errCorruptBatch
+	}
+
+	return entries, nil
+}
+
+// encodeRecord returns the encoded representation of rec.
+//
+// A record consists of the time as nanoseconds since the Unix epoch, followed
+// by the length-prefixed stream name, event type, content type and body. The
+// length prefix of the body is offset by one so that a nil body can be
+// distinguished from an empty one.
+//
+// If the event has metadata or an ID, the body is followed by the number of
+// metadata entries and the length-prefixed key and value of each entry. If the
+// event has an ID, the metadata is followed by the length-prefixed ID. Records
+// without metadata or an ID end immediately after the body.
+func encodeRecord(rec record) []byte {
+	buf := make([]byte, 8, 64+len(rec.Event.Body))
+	binary.BigEndian.PutUint64(buf, uint64(rec.Time.UnixNano()))
+
+	buf = appendString(buf, rec.Stream)
+	buf = appendString(buf, rec.Event.EventType)
+	buf = appendString(buf, rec.Event.ContentType)
+
+	if rec.Event.Body == nil {
+		buf = appendUvarint(buf, 0)
+	} else {
+		buf = appendUvarint(buf, uint64(len(rec.Event.Body))+1)
+		buf = append(buf, rec.Event.Body...)
+	}
+
+	if len(rec.Event.Metadata) > 0 || rec.Event.ID != "" {
+		buf = appendUvarint(buf, uint64(len(rec.Event.Metadata)))
+
+		for k, v := range rec.Event.Metadata {
+			buf = appendString(buf, k)
+			buf = appendString(buf, v)
+		}
+	}
+
+	if rec.Event.ID != "" {
+		buf = appendString(buf, rec.Event.ID)
+	}
+
+	return buf
+}
+
+// decodeRecord decodes a record that was encoded by encodeRecord().
+func decodeRecord(buf []byte) (rec record, err error) {
+	if len(buf) < 8 {
+		return rec, errCorruptBatch
+	}
+
+	rec.Time = time.Unix(0, int64(binary.BigEndian.Uint64(buf)))
+	buf = buf[8:]
+
+	if rec.Stream, buf, err = readString(buf); err != nil {
+		return
+	}
+
+	if rec.Event.EventType, buf, err = readString(buf); err != nil {
+		return
+	}
+
+	if rec.Event.ContentType, buf, err = readString(buf); err != nil {
+		return
+	}
+
+	n, w := binary.Uvarint(buf)
+	if w <= 0 || n > uint64(len(buf)-w)+1 {
+		return rec, errCorruptBatch
+	}
+
+	buf = buf[w:]
+
+	if n > 0 {
+		rec.Event.Body = make([]byte, n-1)
+		copy(rec.Event.Body, buf)
+		buf = buf[n-1:]
+	}
+
+	if len(buf) == 0 {
+		return rec, nil
+	}
+
+	n, w = binary.Uvarint(buf)
+	if w <= 0 || n > uint64(len(buf)-w) {
+		return rec, errCorruptBatch
+	}
+	buf = buf[w:]
+
+	if n > 0 {
+		rec.Event.Metadata = make(map[string]string, n)
+	}
+
+	for i := uint64(0); i < n; i++ {
+		var k, v string
+
+		if k, buf, err = readString(buf); err != nil {
+			return
+		}
+
+		if v, buf, err = readString(buf); err != nil {
+			return
+		}
+
+		rec.Event.Metadata[k] = v
+	}
+
+	if len(buf) > 0 {
+		if rec.Event.ID, buf, err = readString(buf); err != nil {
+			return
+		}
+	}
+
+	return rec, nil
+}
+
+// appendUvarint appends the varint encoding of v to buf.
+func appendUvarint(buf []byte, v uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	return append(buf, b[:n]...)
+}
+
+// appendString appends the length-prefixed string s to buf.
+func appendString(buf []byte, s string) []byte {
+	buf = appendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// readString reads a length-prefixed string from buf, returning the remainder
+// of the buffer.
+func readString(buf []byte) (string, []byte, error) {
+	n, w := binary.Uvarint(buf)
+	if w <= 0 || n > uint64(len(buf)-w) {
+		return "", nil, errCorruptBatch
+	}
+
+	end := w + int(n)
+
+	return string(buf[w:end]), buf[end:], nil
+}
@@ -0,0 +1,505 @@
+package gospelfile
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/internal/notify"
+	"github.com/jmalloc/twelf/src/twelf"
+	"go.uber.org/multierr"
+)
+
+// entry is an index entry that locates a single fact within a segment file.
+type entry struct {
+	// segment is the segment that contains the record.
+	segment *segment
+
+	// pos and size are the position and length of the encoded record within
+	// the segment file.
+	pos  int64
+	size int
+
+	// time is the time at which the fact was appended.
+	time time.Time
+
+	// eventType and contentType are the event type and content type of the
+	// fact's event, retained in the index so that readers can skip over facts
+	// without reading them from disk.
+	eventType   string
+	contentType string
+
+	// origin is the address of the fact on the named stream it was originally
+	// appended to. Facts recorded by the store itself only appear on the
+	// ε-stream, so their origin is their ε-stream address.
+	origin gospel.Address
+}
+
+// store contains the segment files and in-memory indexes for a single named
+// event store.
+type store struct {
+	// name is the name of the store.
+	name string
+
+	// dir is the directory that contains the store's segment files.
+	dir string
+
+	// m protects the segments and indexes. It is held for writing while facts
+	// are appended, which guarantees that appends are atomic.
+	m sync.RWMutex
+
+	// segments is the list of segment files, in order. The last segment is the
+	// "active" segment, to which new batches are written.
+	segments []*segment
+
+	// streams is a map of stream name to the index of the facts on that stream,
+	// in order. The ε-stream is stored under the empty string.
+	streams map[string][]*entry
+
+	// ids is a map of event ID to the address at which the event with that ID
+	// was appended to a named stream.
+	ids map[string]gospel.Address
+
+	// segmentSize is the size at which a new segment is started.
+	segmentSize int64
+
+	// syncAlways is true if each append is flushed to stable storage before
+	// the append is considered complete.
+	syncAlways bool
+
+	// dirty is true if there are appends that have not been flushed to stable
+	// storage.
+	dirty bool
+
+	// closed is true once the store has been closed.
+	closed bool
+
+	// hub is used to wake readers that are waiting for new facts. Readers wait
+	// on the stream name as the key.
+	hub notify.Hub
+
+	// done is a signaling channel that is closed when the client is closed.
+	done <-chan struct{}
+}
+
+// errStoreCorrupt is an error returned when a segment other than the active
+// segment contains data that is not a valid batch.
+var errStoreCorrupt = errors.New("segment is corrupt")
+
+// openStore opens the store in dir, creating it if necessary, and rebuilds its
+// indexes from the segment files.
+//
+// If the active segment ends with a partially written batch, the segment is
+// truncated to discard it.
+func openStore(
+	name, dir string,
+	segmentSize int64,
+	syncAlways bool,
+	done <-chan struct{},
+	logger twelf.Logger,
+) (*store, error) {
+	s := &store{
+		name:        name,
+		dir:         dir,
+		streams:     map[string][]*entry{},
+		ids:         map[string]gospel.Address{},
+		segmentSize: segmentSize,
+		syncAlways:  syncAlways,
+		done:        done,
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := s.load(logger); err != nil {
+		return nil, multierr.Append(err, s.close())
+	}
+
+	if len(s.streams[""]) == 0 {
+		if err := s.write([]record{
+			{
+				Time: time.Now(),
+				Event: gospel.Event{
+					EventType:   "$store.created",
+					ContentType: "application/vnd.gospel.store.created.v1",
+					Body:        []byte(name),
+				},
+			},
+		}); err != nil {
+			return nil, multierr.Append(err, s.close())
+		}
+	}
+
+	return s, nil
+}
+
+// load opens the existing segment files and rebuilds the indexes.
+func (s *store) load(logger twelf.Logger) error {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+
+	for _, fi := range files {
+		if !fi.IsDir() && strings.HasSuffix(fi.Name(), segmentExt) {
+			names = append(names, fi.Name())
+		}
+	}
+
+	for i, n := range names {
+		first, err := strconv.ParseUint(strings.TrimSuffix(n, segmentExt), 10, 64)
+		if err != nil || first != s.next("") {
+			return fmt.Errorf("%s: %s", n, errStoreCorrupt)
+		}
+
+		seg, err := openSegment(filepath.Join(s.dir, n), first)
+		if err != nil {
+			return err
+		}
+
+		s.segments = append(s.segments, seg)
+
+		torn, err := seg.scan(func(rec record, pos int64, n int) {
+			s.index(seg, rec, pos, n)
+		})
+		if err != nil {
+			return err
+		}
+
+		if !torn {
+			continue
+		}
+
+		// Only the active segment can contain a partially written batch, data
+		// in any earlier segment was flushed before the next one was started.
+		if i != len(names)-1 {
+			return fmt.Errorf("%s: %s", n, errStoreCorrupt)
+		}
+
+		logger.Log(
+			"discarding partially written data at the end of %s, from position %d",
+			seg.file.Name(),
+			seg.size,
+		)
+
+		if err := seg.truncate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// next returns the next unused offset of the given stream.
+//
+// s.m must be held, for reading or writing.
+func (s *store) next(stream string) uint64 {
+	return uint64(len(s.streams[stream]))
+}
+
+// NextOffset returns the next unused offset of the given stream. It is
+// equivalent to next(), and implements plan.State.
+//
+// s.m must be held, for reading or writing.
+func (s *store) NextOffset(stream string) uint64 {
+	return s.next(stream)
+}
+
+// LookupID returns the address of the event with the given ID. It implements
+// plan.State.
+//
+// s.m must be held, for reading or writing.
+func (s *store) LookupID(id string) (gospel.Address, bool) {
+	addr, ok := s.ids[id]
+	return addr, ok
+}
+
+// info returns information about the given stream.
+//
+// s.m must be held, for reading or writing.
+func (s *store) info(stream string) gospel.StreamInfo {
+	entries := s.streams[stream]
+
+	info := gospel.StreamInfo{
+		Next: gospel.Address{
+			Stream: stream,
+			Offset: uint64(len(entries)),
+		},
+		FactCount: uint64(len(entries)),
+	}
+
+	if len(entries) > 0 {
+		info.Exists = true
+		info.FirstFactTime = entries[0].time
+		info.LastFactTime = entries[len(entries)-1].time
+	}
+
+	return info
+}
+
+// index adds a record at the given position within seg to the indexes.
+//
+// Every record is indexed on the ε-stream. Records that belong to a named
+// stream are additionally indexed on that stream, and by their event ID, if
+// any.
+func (s *store) index(seg *segment, rec record, pos int64, n int) {
+	e := &entry{
+		segment:     seg,
+		pos:         pos,
+		size:        n,
+		time:        rec.Time,
+		eventType:   rec.Event.EventType,
+		contentType: rec.Event.ContentType,
+		origin: gospel.Address{
+			Offset: s.next(""),
+		},
+	}
+
+	s.streams[""] = append(s.streams[""], e)
+
+	if rec.Stream != "" {
+		e.origin = gospel.Address{
+			Stream: rec.Stream,
+			Offset: s.next(rec.Stream),
+		}
+
+		if rec.Event.ID != "" {
+			s.ids[rec.Event.ID] = e.origin
+		}
+
+		s.streams[rec.Stream] = append(s.streams[rec.Stream], e)
+	}
+}
+
+// append appends events to a stream beginning at the stream's next unused
+// offset, and records the corresponding facts on the ε-stream.
+//
+// s.m must be held for writing. It returns the address of the next unused
+// offset after the append.
+func (s *store) append(stream string, events []gospel.Event) (gospel.Address, error) {
+	addrs, err := s.appendMulti([]gospel.StreamAppend{
+		{Stream: stream, Events: events},
+	})
+	if err != nil {
+		return gospel.Address{}, err
+	}
+
+	return addrs[0], nil
+}
+
+// appendMulti appends events to several streams, each beginning at the
+// stream's next unused offset, and records the corresponding facts on the
+// ε-stream. All of the records are written as a single batch, so that either
+// all or none of them survive a crash.
+//
+// The expectations in appends are not checked. s.m must be held for writing.
+// It returns the address of the next unused offset of each stream after its
+// append.
+func (s *store) appendMulti(appends []gospel.StreamAppend) ([]gospel.Address, error) {
+	now := time.Now()
+	addrs := make([]gospel.Address, len(appends))
+	pending := map[string]uint64{}
+
+	var records []record
+
+	for i, a := range appends {
+		next := s.next(a.Stream) + pending[a.Stream]
+
+		if next == 0 {
+			records = append(records, record{
+				Time: now,
+				Event: gospel.Event{
+					EventType:   "$stream.created",
+					ContentType: "application/vnd.gospel.stream.created.v1",
+					Body:        []byte(a.Stream),
+				},
+			})
+		}
+
+		for _, ev := range a.Events {
+			records = append(records, record{
+				Time:   now,
+				Stream: a.Stream,
+				Event:  ev,
+			})
+		}
+
+		pending[a.Stream] += uint64(len(a.Events))
+		addrs[i] = gospel.Address{
+			Stream: a.Stream,
+			Offset: next + uint64(len(a.Events)),
+		}
+	}
+
+	if err := s.write(records); err != nil {
+		return nil, err
+	}
+
+	return addrs, nil
+}
+
+// write writes records to the active segment as a single batch and adds them
+// to the indexes.
+//
+// s.m must be held for writing.
+func (s *store) write(records []record) error {
+	offsets := make([][2]int, len(records))
+	batch := encodeBatch(records, offsets)
+
+	seg, err := s.activeSegment(int64(len(batch)))
+	if err != nil {
+		return err
+	}
+
+	pos, err := seg.write(batch)
+	if err != nil {
+		return err
+	}
+
+	if s.syncAlways {
+		if err := seg.file.Sync(); err != nil {
+			// The batch may or may not have reached stable storage, discard it
+			// so that the contents of the file matches the indexes.
+			seg.size = pos
+			seg.truncate()
+			return err
+		}
+	} else {
+		s.dirty = true
+	}
+
+	for i, rec := range records {
+		s.index(seg, rec, pos+int64(offsets[i][0]), offsets[i][1])
+	}
+
+	return nil
+}
+
+// activeSegment returns the segment to which a batch of n bytes should be
+// written, starting a new segment if necessary.
+//
+// s.m must be held for writing.
+func (s *store) activeSegment(n int64) (*segment, error) {
+	if c := len(s.segments); c > 0 {
+		seg := s.segments[c-1]
+
+		if seg.size == 0 || seg.size+n <= s.segmentSize {
+			return seg, nil
+		}
+
+		// Flush the previous segment before starting a new one, as it will not
+		// be flushed by subsequent calls to sync().
+		if !s.syncAlways {
+			if err := seg.file.Sync(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	seg, err := createSegment(s.dir, s.next(""))
+	if err != nil {
+		return nil, err
+	}
+
+	s.segments = append(s.segments, seg)
+
+	if s.syncAlways {
+		if err := syncDir(s.dir); err != nil {
+			return nil, err
+		}
+	}
+
+	return seg, nil
+}
+
+// Read returns the fact at addr, reading it from its segment file. It
+// implements indexreader.Index.
+//
+// s.m must be held, for reading or writing.
+func (s *store) Read(addr gospel.Address) (gospel.Fact, error) {
+	e := s.streams[addr.Stream][addr.Offset]
+
+	rec, err := e.segment.read(e.pos, e.size)
+	if err != nil {
+		return gospel.Fact{}, err
+	}
+
+	return gospel.Fact{
+		Addr:   addr,
+		Origin: e.origin,
+		Time:   rec.Time,
+		Event:  rec.Event,
+	}, nil
+}
+
+// sync flushes any appends to stable storage.
+func (s *store) sync() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if !s.dirty || s.closed {
+		return nil
+	}
+
+	if err := s.segments[len(s.segments)-1].file.Sync(); err != nil {
+		return err
+	}
+
+	if err := syncDir(s.dir); err != nil {
+		return err
+	}
+
+	s.dirty = false
+
+	return nil
+}
+
+// close flushes any appends to stable storage and closes the segment files.
+func (s *store) close() error {
+	err := s.sync()
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	s.closed = true
+
+	for _, seg := range s.segments {
+		err = multierr.Append(err, seg.close())
+	}
+
+	return err
+}
+
+// notify wakes any readers waiting on the given stream or the ε-stream.
+func (s *store) notify(stream string) {
+	s.hub.Notify(stream)
+	s.hub.Notify("")
+}
+
+// syncDir flushes the directory entries of dir to stable storage, so that
+// newly created files survive a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	return multierr.Append(
+		d.Sync(),
+		d.Close(),
+	)
+}
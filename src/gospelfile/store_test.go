@@ -0,0 +1,289 @@
+package gospelfile_test
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/jmalloc/gospel/src/gospelfile"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("segment files", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+		dir    string
+	)
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 3*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+
+		dir = getTestDir()
+	})
+
+	AfterEach(func() {
+		cancel()
+		destroyTestDir(dir)
+	})
+
+	// segments returns the paths of the segment files in the test store.
+	segments := func() []string {
+		matches, err := filepath.Glob(filepath.Join(dir, "test.store", "*.log"))
+		Expect(err).ShouldNot(HaveOccurred())
+		return matches
+	}
+
+	// appendEvents appends n events to the test stream.
+	appendEvents := func(es *EventStore, n int) {
+		for i := 0; i < n; i++ {
+			_, err := es.AppendUnchecked(
+				ctx,
+				"test-stream",
+				gospel.Event{EventType: "event-type-1", Body: []byte("<body>")},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+	}
+
+	// readBodies returns the bodies of the facts on the test stream.
+	readBodies := func(es *EventStore) []string {
+		r, err := es.Open(ctx, gospel.Address{Stream: "test-stream"})
+		Expect(err).ShouldNot(HaveOccurred())
+		defer r.Close()
+
+		var bodies []string
+		for {
+			_, ok, err := r.TryNext(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			if !ok {
+				return bodies
+			}
+
+			bodies = append(bodies, string(r.Get().Event.Body))
+		}
+	}
+
+	It("starts a new segment when the segment size is exceeded", func() {
+		c, es := getTestStore(dir, SegmentSize(100))
+		appendEvents(es, 5)
+		c.Close()
+
+		Expect(len(segments())).To(BeNumerically(">", 1))
+
+		c, es = getTestStore(dir)
+		defer c.Close()
+
+		Expect(readBodies(es)).To(HaveLen(5))
+	})
+
+	It("discards a partially written append when the store is opened", func() {
+		c, es := getTestStore(dir)
+		appendEvents(es, 2)
+		c.Close()
+
+		files := segments()
+		Expect(files).To(HaveLen(1))
+
+		// Simulate a torn write by removing the end of the last batch.
+		info, err := os.Stat(files[0])
+		Expect(err).ShouldNot(HaveOccurred())
+		err = os.Truncate(files[0], info.Size()-3)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		c, es = getTestStore(dir)
+		defer c.Close()
+
+		Expect(readBodies(es)).To(Equal([]string{"<body>"}))
+
+		nx, err := es.Append(
+			ctx,
+			gospel.Address{Stream: "test-stream", Offset: 1},
+			gospel.Event{EventType: "event-type-2", Body: []byte("<new>")},
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(nx.Offset).To(BeEquivalentTo(2))
+		Expect(readBodies(es)).To(Equal([]string{"<body>", "<new>"}))
+	})
+
+	It("discards trailing garbage when the store is opened", func() {
+		c, es := getTestStore(dir)
+		appendEvents(es, 2)
+		c.Close()
+
+		files := segments()
+		f, err := os.OpenFile(files[0], os.O_WRONLY|os.O_APPEND, 0644)
+		Expect(err).ShouldNot(HaveOccurred())
+		_, err = f.Write([]byte("<garbage>"))
+		Expect(err).ShouldNot(HaveOccurred())
+		f.Close()
+
+		c, es = getTestStore(dir)
+		defer c.Close()
+
+		Expect(readBodies(es)).To(HaveLen(2))
+	})
+
+	It("discards every record in a batch that contains an undecodable record", func() {
+		c, es := getTestStore(dir)
+		_, err := es.AppendUnchecked(
+			ctx,
+			"test-stream",
+			gospel.Event{EventType: "event-type-1", Body: []byte("<body>")},
+			gospel.Event{EventType: "event-type-2", Body: []byte("<body>")},
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+		c.Close()
+
+		files := segments()
+		data, err := ioutil.ReadFile(files[0])
+		Expect(err).ShouldNot(HaveOccurred())
+
+		// Find the last batch, which contains the records from the append.
+		pos := 0
+		for {
+			next := pos + 8 + int(binary.BigEndian.Uint32(data[pos:]))
+			if next == len(data) {
+				break
+			}
+			pos = next
+		}
+
+		// Overwrite the length prefix of the last record's body, then fix the
+		// checksum so that the batch is only rejected when it is decoded.
+		data[len(data)-len("<body>")-1] = 0x7f
+		binary.BigEndian.PutUint32(
+			data[pos+4:],
+			crc32.Checksum(data[pos+8:], crc32.MakeTable(crc32.Castagnoli)),
+		)
+		err = ioutil.WriteFile(files[0], data, 0644)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		c, es = getTestStore(dir)
+		defer c.Close()
+
+		Expect(readBodies(es)).To(BeEmpty())
+
+		info, err := es.StreamInfo(ctx, "test-stream")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(info.Exists).To(BeFalse())
+
+		nx, err := es.AppendUnchecked(
+			ctx,
+			"test-stream",
+			gospel.Event{EventType: "event-type-3", Body: []byte("<new>")},
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(nx.Offset).To(BeEquivalentTo(1))
+		Expect(readBodies(es)).To(Equal([]string{"<new>"}))
+	})
+
+	It("returns an error if a segment other than the last is corrupt", func() {
+		c, es := getTestStore(dir, SegmentSize(100))
+		appendEvents(es, 5)
+		c.Close()
+
+		files := segments()
+		data, err := ioutil.ReadFile(files[0])
+		Expect(err).ShouldNot(HaveOccurred())
+		data[len(data)-1] ^= 0xff
+		err = ioutil.WriteFile(files[0], data, 0644)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		c = getTestClient(dir)
+		defer c.Close()
+
+		_, err = c.OpenStore(ctx, "test")
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("preserves the distinction between nil and empty bodies", func() {
+		c, es := getTestStore(dir, SyncNever())
+		_, err := es.AppendUnchecked(
+			ctx,
+			"test-stream",
+			gospel.Event{EventType: "event-type-1"},
+			gospel.Event{EventType: "event-type-2", Body: []byte{}},
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+		c.Close()
+
+		c, es = getTestStore(dir, SyncInterval(time.Millisecond))
+		defer c.Close()
+
+		r, err := es.Open(ctx, gospel.Address{Stream: "test-stream"})
+		Expect(err).ShouldNot(HaveOccurred())
+		defer r.Close()
+
+		_, err = r.Next(ctx)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(r.Get().Event.Body).To(BeNil())
+
+		_, err = r.Next(ctx)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(r.Get().Event.Body).To(Equal([]byte{}))
+	})
+	It("persists event metadata", func() {
+		c, es := getTestStore(dir, SyncNever())
+		_, err := es.AppendUnchecked(
+			ctx,
+			"test-stream",
+			gospel.Event{
+				EventType: "event-type-1",
+				Metadata:  map[string]string{"key-1": "value-1", "key-2": ""},
+			},
+			gospel.Event{EventType: "event-type-2"},
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+		c.Close()
+
+		c, es = getTestStore(dir, SyncInterval(time.Millisecond))
+		defer c.Close()
+
+		r, err := es.Open(ctx, gospel.Address{Stream: "test-stream"})
+		Expect(err).ShouldNot(HaveOccurred())
+		defer r.Close()
+
+		_, err = r.Next(ctx)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(r.Get().Event.Metadata).To(Equal(map[string]string{"key-1": "value-1", "key-2": ""}))
+
+		_, err = r.Next(ctx)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(r.Get().Event.Metadata).To(BeNil())
+	})
+	It("recognizes repeated appends after the store is reopened", func() {
+		c, es := getTestStore(dir, SyncNever())
+		nx, err := es.AppendUnchecked(
+			ctx,
+			"test-stream",
+			gospel.Event{EventType: "event-type-1", ID: "id-1"},
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+		c.Close()
+
+		c, es = getTestStore(dir, SyncNever())
+		defer c.Close()
+
+		rx, err := es.AppendUnchecked(
+			ctx,
+			"test-stream",
+			gospel.Event{EventType: "event-type-1", ID: "id-1"},
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(rx).To(Equal(nx))
+
+		info, err := es.StreamInfo(ctx, "test-stream")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(info.Next).To(Equal(nx))
+	})
+})
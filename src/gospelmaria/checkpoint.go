@@ -0,0 +1,129 @@
+package gospelmaria
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmalloc/gospel/src/gospel"
+)
+
+// LoadCheckpoint returns the address most recently saved for the consumer
+// with the given name.
+//
+// ok is false if no checkpoint has been saved for the consumer.
+func (es *EventStore) LoadCheckpoint(
+	ctx context.Context,
+	name string,
+) (gospel.Address, bool, error) {
+	return loadCheckpoint(ctx, es.db, es.id, name)
+}
+
+// SaveCheckpoint saves addr as the checkpoint for the consumer with the given
+// name, replacing any existing checkpoint.
+func (es *EventStore) SaveCheckpoint(
+	ctx context.Context,
+	name string,
+	addr gospel.Address,
+) error {
+	return saveCheckpoint(ctx, es.db, es.id, name, addr)
+}
+
+// queryer is an interface for executing queries, satisfied by both *sql.DB and
+// *sql.Tx.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// loadCheckpoint reads a consumer's checkpoint from the 'checkpoint' table.
+func loadCheckpoint(
+	ctx context.Context,
+	db queryer,
+	storeID uint64,
+	name string,
+) (gospel.Address, bool, error) {
+	return scanCheckpoint(
+		db.QueryRowContext(
+			ctx,
+			`SELECT
+				stream,
+				offset
+			FROM checkpoint
+			WHERE store_id = ?
+				AND name = ?`,
+			storeID,
+			name,
+		),
+	)
+}
+
+// lockCheckpoint reads a consumer's checkpoint from the 'checkpoint' table,
+// locking the row until tx is committed or rolled back.
+func lockCheckpoint(
+	ctx context.Context,
+	tx *sql.Tx,
+	storeID uint64,
+	name string,
+) (gospel.Address, bool, error) {
+	return scanCheckpoint(
+		tx.QueryRowContext(
+			ctx,
+			`SELECT
+				stream,
+				offset
+			FROM checkpoint
+			WHERE store_id = ?
+				AND name = ?
+			FOR UPDATE`,
+			storeID,
+			name,
+		),
+	)
+}
+
+// scanCheckpoint scans a checkpoint from a row returned by loadCheckpoint() or
+// lockCheckpoint(). ok is false if there is no such row.
+func scanCheckpoint(row *sql.Row) (gospel.Address, bool, error) {
+	var addr gospel.Address
+
+	err := row.Scan(
+		&addr.Stream,
+		&addr.Offset,
+	)
+
+	if err == sql.ErrNoRows {
+		return addr, false, nil
+	} else if err != nil {
+		return addr, false, err
+	}
+
+	return addr, true, nil
+}
+
+// saveCheckpoint inserts or replaces a consumer's checkpoint in the
+// 'checkpoint' table.
+func saveCheckpoint(
+	ctx context.Context,
+	db queryer,
+	storeID uint64,
+	name string,
+	addr gospel.Address,
+) error {
+	_, err := db.ExecContext(
+		ctx,
+		`INSERT INTO checkpoint SET
+			store_id = ?,
+			name = ?,
+			stream = ?,
+			offset = ?
+		ON DUPLICATE KEY UPDATE
+			stream = VALUES(stream),
+			offset = VALUES(offset)`,
+		storeID,
+		name,
+		addr.Stream,
+		addr.Offset,
+	)
+
+	return err
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/go-sql-driver/mysql"
 	"github.com/jmalloc/gospel/src/gospel"
 	"github.com/jmalloc/gospel/src/gospelmaria/schema"
+	"github.com/jmalloc/gospel/src/internal/notify"
 	"github.com/jmalloc/gospel/src/internal/options"
 	"github.com/jmalloc/twelf/src/twelf"
 	"go.uber.org/multierr"
@@ -30,6 +31,11 @@ type Client struct {
 	// provides a global cap of the number of read queries per second.
 	rlimit *rate.Limiter
 
+	// hub is used to wake readers when new facts are appended via this client,
+	// so that they do not have to wait for their next poll. Readers wait on a
+	// streamKey.
+	hub *notify.Hub
+
 	// logger is the logger to use for activity and debug logging. It is
 	// inherited by all event stores and their readers.
 	logger twelf.Logger
@@ -83,6 +89,7 @@ func Open(dsn string, opts ...gospel.Option) (*Client, error) {
 	return &Client{
 		db,
 		rate.NewLimiter(500, 1), // TODO, allow configuration
+		&notify.Hub{},
 		o.Logger,
 	}, nil
 }
@@ -127,6 +134,7 @@ func (c *Client) OpenStore(ctx context.Context, name string) (*EventStore, error
 		uint64(id),
 		name,
 		c.rlimit,
+		c.hub,
 		c.logger,
 	}, nil
 }
@@ -0,0 +1,32 @@
+// +build !without_mariadb
+
+package gospelmaria_test
+
+import (
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/gospeltest"
+)
+
+var (
+	_ = gospeltest.DescribeEventStore(newConformanceStore)
+	_ = gospeltest.DescribeReader(newConformanceStore)
+	_ = gospeltest.DescribeCheckpointStore(newConformanceCheckpointStore)
+)
+
+// newConformanceStore is a gospeltest.Factory that returns an EventStore that
+// uses the test DSN.
+func newConformanceStore() (gospel.EventStore, func()) {
+	c, es := getTestStore()
+
+	return es, func() {
+		c.Close()
+		destroyTestSchema()
+	}
+}
+
+// newConformanceCheckpointStore is a gospeltest.CheckpointFactory that returns
+// the EventStore produced by newConformanceStore.
+func newConformanceCheckpointStore() (gospel.CheckpointStore, func()) {
+	es, done := newConformanceStore()
+	return es.(gospel.CheckpointStore), done
+}
@@ -0,0 +1,619 @@
+package gospelmaria
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/gospelsub"
+	"github.com/jmalloc/twelf/src/twelf"
+	"go.uber.org/multierr"
+)
+
+const (
+	// DefaultPartitionCount is the default number of partitions in a consumer
+	// group. It is used if no specific count is set via PartitionCount().
+	DefaultPartitionCount = 16
+
+	// DefaultLeaseDuration is the default duration of the leases held by
+	// consumer group members. It is used if no specific duration is set via
+	// LeaseDuration().
+	DefaultLeaseDuration = 10 * time.Second
+
+	// minLeaseDuration is the minimum duration of the leases held by consumer
+	// group members.
+	minLeaseDuration = 100 * time.Millisecond
+
+	// leaveTimeout is the time allowed for a member to release its leases and
+	// leave the group once it has stopped.
+	leaveTimeout = 5 * time.Second
+)
+
+// ConsumerGroup is a set of processes, known as members, that share the work
+// of consuming a stream.
+//
+// The stream's facts are divided into a fixed number of partitions, according
+// to the name of the stream that each fact was originally appended to, as per
+// FilterByPartition(). Each partition is leased to a single member at a time,
+// which consumes the partition's facts using a gospelsub.Subscription.
+// Membership and leases are stored in the database, and partitions are
+// rebalanced as members join and leave the group.
+//
+// When consuming a named stream, all of the facts belong to the same
+// partition, and hence only one member is active at any time.
+//
+// Facts are delivered at least once. A fact may be delivered to more than one
+// member if a member stops without committing its checkpoint, or fails to
+// renew its lease before it expires.
+//
+// A partition's checkpoint is only saved while the member holds the lease on
+// that partition. The lease is locked while the checkpoint is saved, so a
+// member that has lost its lease can not move the checkpoint of the partition's
+// new owner. A member stops consuming a partition as soon as it finds that its
+// lease has been lost, or it fails to renew the lease before the lease expires.
+//
+// The context passed to the handler is canceled as soon as the member stops
+// consuming the fact's partition, which is never later than the time at which
+// the member's lease expires. Handlers must stop promptly when their context is
+// canceled, without performing any further work that has side-effects.
+//
+// Each fact is processed by exactly one member only if handlers are idempotent.
+// Leases can not stop a handler that ignores cancellation, or that is still
+// finishing its work, from running at the same time as a handler on the
+// partition's new owner, and any fact that was handled after the last commit
+// is delivered again to the new owner. The lease duration should be long
+// enough that handlers typically complete well within it.
+type ConsumerGroup struct {
+	// store is the event store that facts are read from, and to which the
+	// checkpoint of each partition is saved.
+	store *EventStore
+
+	// group is the name of the consumer group, and member is the name of the
+	// member that this instance represents.
+	group  string
+	member string
+
+	// stream is the stream to consume.
+	stream string
+
+	// partitions is the number of partitions in the group. It must be the same
+	// for all members, and must not change once the group has been used.
+	partitions uint
+
+	// lease is the duration of the membership and partition leases. They are
+	// renewed three times per lease duration.
+	lease time.Duration
+
+	// subOpts is the set of options used for the subscription to each
+	// partition.
+	subOpts []gospelsub.Option
+
+	// logger is the logger to use for activity and debug logging.
+	logger twelf.Logger
+}
+
+// ConsumerGroupOption is a function that applies an option to a consumer
+// group.
+type ConsumerGroupOption func(g *ConsumerGroup)
+
+// PartitionCount is a consumer group option that sets the number of partitions
+// in the group, which is the maximum number of members that can be active at
+// any time.
+//
+// All members of the group must use the same partition count, and the count
+// must not change once the group has been used, as each partition has its own
+// checkpoint. The minimum partition count is 1.
+func PartitionCount(n uint) ConsumerGroupOption {
+	if n < 1 {
+		n = 1
+	}
+
+	return func(g *ConsumerGroup) {
+		g.partitions = n
+	}
+}
+
+// LeaseDuration is a consumer group option that sets the duration of the
+// membership and partition leases held by the member.
+//
+// A member that stops without leaving the group is detected once its leases
+// expire. The minimum lease duration is 100 milliseconds.
+func LeaseDuration(d time.Duration) ConsumerGroupOption {
+	if d < minLeaseDuration {
+		d = minLeaseDuration
+	}
+
+	return func(g *ConsumerGroup) {
+		g.lease = d
+	}
+}
+
+// SubscriptionOptions is a consumer group option that applies subscription
+// options to the subscription used to consume each partition.
+func SubscriptionOptions(opts ...gospelsub.Option) ConsumerGroupOption {
+	return func(g *ConsumerGroup) {
+		g.subOpts = append(g.subOpts, opts...)
+	}
+}
+
+// NewConsumerGroup returns a consumer group that consumes a stream of es,
+// acting as the member with the given name.
+//
+// Each member of the group must have a unique name.
+func NewConsumerGroup(
+	es *EventStore,
+	group, member, stream string,
+	opts ...ConsumerGroupOption,
+) *ConsumerGroup {
+	g := &ConsumerGroup{
+		store:      es,
+		group:      group,
+		member:     member,
+		stream:     stream,
+		partitions: DefaultPartitionCount,
+		lease:      DefaultLeaseDuration,
+		logger:     es.logger,
+	}
+
+	for _, fn := range opts {
+		fn(g)
+	}
+
+	return g
+}
+
+// worker consumes a single partition.
+type worker struct {
+	// ctx is the worker's context. It is canceled when the worker is told to
+	// stop, when its lease expires, or when its lease is found to be lost.
+	ctx    context.Context
+	cancel func()
+
+	// done is a signaling channel that is closed when the worker has stopped.
+	done chan struct{}
+
+	// expiry cancels ctx when the worker's lease expires, unless the lease is
+	// renewed first.
+	expiry *time.Timer
+}
+
+// stop stops the worker and waits for it to commit its checkpoint.
+func (w *worker) stop() {
+	w.expiry.Stop()
+	w.cancel()
+	<-w.done
+}
+
+// renew extends the time at which the worker is stopped to match a renewed
+// lease that expires at t.
+func (w *worker) renew(t time.Time) {
+	w.expiry.Reset(time.Until(t))
+}
+
+// stopping returns true if the worker has been told to stop.
+func (w *worker) stopping() bool {
+	return w.ctx.Err() != nil
+}
+
+// Run joins the group and passes the facts in each partition that is leased to
+// this member to h.
+//
+// h is called with a context that is canceled when the member stops consuming
+// the fact's partition, such as when the lease on the partition is lost or
+// expires. h must stop promptly once its context is canceled, and must be
+// idempotent, as described in the documentation for ConsumerGroup.
+//
+// It blocks until ctx is canceled or an error occurs, including an error
+// returned by h. Before it returns, the member's partitions are released and
+// the member leaves the group, allowing the remaining members to take over its
+// partitions.
+func (g *ConsumerGroup) Run(ctx context.Context, h gospelsub.Handler) error {
+	workers := map[uint]*worker{}
+
+	return multierr.Append(
+		g.run(ctx, h, workers),
+		g.leave(workers),
+	)
+}
+
+// run rebalances the partitions after each membership renewal until ctx is
+// canceled or an error occurs.
+func (g *ConsumerGroup) run(
+	ctx context.Context,
+	h gospelsub.Handler,
+	workers map[uint]*worker,
+) error {
+	failed := make(chan error, 1)
+
+	ticker := time.NewTicker(g.lease / 3)
+	defer ticker.Stop()
+
+	for {
+		if err := g.rebalance(ctx, h, workers, failed); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-failed:
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+// rebalance renews the member's membership, then starts and stops workers so
+// that the member consumes its share of the partitions.
+//
+// Workers for partitions that are no longer assigned to this member are
+// stopped before their leases are released, so that the next owner of each
+// partition resumes from the checkpoint committed by this member.
+func (g *ConsumerGroup) rebalance(
+	ctx context.Context,
+	h gospelsub.Handler,
+	workers map[uint]*worker,
+	failed chan<- error,
+) error {
+	members, err := g.renewMembership(ctx)
+	if err != nil {
+		return err
+	}
+
+	assigned := assignPartitions(g.partitions, members, g.member)
+
+	for p, w := range workers {
+		if !assigned[p] {
+			w.stop()
+			delete(workers, p)
+
+			if err := g.releaseLease(ctx, p); err != nil {
+				return err
+			}
+
+			g.logger.Debug("[consumer group %s] %s released partition %d", g.group, g.member, p)
+		}
+	}
+
+	for p := uint(0); p < g.partitions; p++ {
+		if !assigned[p] {
+			continue
+		}
+
+		w, running := workers[p]
+
+		if running && w.stopping() {
+			w.stop()
+			delete(workers, p)
+			running = false
+			g.logger.Log("[consumer group %s] %s lost its lease on partition %d", g.group, g.member, p)
+		}
+
+		// The lease is considered to expire one lease duration after the
+		// renewal is requested, which is never later than the expiry time
+		// recorded by the database.
+		expires := time.Now().Add(g.lease)
+
+		ok, err := g.acquireLease(ctx, p)
+		if err != nil {
+			if running {
+				w.stop()
+				delete(workers, p)
+			}
+
+			return err
+		}
+
+		if ok && running {
+			w.renew(expires)
+		} else if ok {
+			workers[p] = g.start(ctx, h, p, expires, failed)
+			g.logger.Debug("[consumer group %s] %s acquired partition %d", g.group, g.member, p)
+		} else if running {
+			w.stop()
+			delete(workers, p)
+			g.logger.Log("[consumer group %s] %s lost its lease on partition %d", g.group, g.member, p)
+		}
+	}
+
+	return nil
+}
+
+// start starts a worker that consumes partition p, which is leased to this
+// member until expires.
+//
+// The worker stops when the lease expires, unless it is renewed, or when it
+// finds that the lease has been lost while saving its checkpoint. If the
+// worker's subscription stops with any other error before the worker is
+// stopped, the error is sent to failed, if it is not already full.
+func (g *ConsumerGroup) start(
+	ctx context.Context,
+	h gospelsub.Handler,
+	p uint,
+	expires time.Time,
+	failed chan<- error,
+) *worker {
+	ctx, cancel := context.WithCancel(ctx)
+
+	w := &worker{
+		ctx,
+		cancel,
+		make(chan struct{}),
+		time.AfterFunc(time.Until(expires), cancel),
+	}
+
+	opts := append(
+		append([]gospelsub.Option(nil), g.subOpts...),
+		gospelsub.ReaderOptions(FilterByPartition(p, g.partitions)),
+	)
+
+	sub := gospelsub.New(
+		g.store,
+		&leasedCheckpointStore{g, p, cancel},
+		fmt.Sprintf("%s/%d", g.group, p),
+		g.stream,
+		opts...,
+	)
+
+	go func() {
+		defer close(w.done)
+
+		if err := sub.Run(ctx, h); err != nil && ctx.Err() == nil {
+			select {
+			case failed <- err:
+			default:
+			}
+		}
+	}()
+
+	return w
+}
+
+// leave stops all of the workers, releases their leases and removes the member
+// from the group.
+//
+// A new context is used, as the context passed to Run() has typically been
+// canceled by the time the member leaves.
+func (g *ConsumerGroup) leave(workers map[uint]*worker) error {
+	ctx, cancel := context.WithTimeout(context.Background(), leaveTimeout)
+	defer cancel()
+
+	var err error
+
+	for p, w := range workers {
+		w.stop()
+		err = multierr.Append(err, g.releaseLease(ctx, p))
+	}
+
+	_, e := g.store.db.ExecContext(
+		ctx,
+		`DELETE FROM consumer_member
+		WHERE store_id = ?
+			AND consumer_group = ?
+			AND member = ?`,
+		g.store.id,
+		g.group,
+		g.member,
+	)
+
+	return multierr.Append(err, e)
+}
+
+// renewMembership adds the member to the group, or extends its membership if
+// it is already a member. It returns the names of the current members, in
+// order.
+func (g *ConsumerGroup) renewMembership(ctx context.Context) ([]string, error) {
+	_, err := g.store.db.ExecContext(
+		ctx,
+		`INSERT INTO consumer_member SET
+			store_id = ?,
+			consumer_group = ?,
+			member = ?,
+			expires = NOW(6) + INTERVAL ? MICROSECOND
+		ON DUPLICATE KEY UPDATE
+			expires = VALUES(expires)`,
+		g.store.id,
+		g.group,
+		g.member,
+		int64(g.lease/time.Microsecond),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := g.store.db.QueryContext(
+		ctx,
+		`SELECT member
+		FROM consumer_member
+		WHERE store_id = ?
+			AND consumer_group = ?
+			AND expires > NOW(6)
+		ORDER BY member`,
+		g.store.id,
+		g.group,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []string
+
+	for rows.Next() {
+		var m string
+		if err := rows.Scan(&m); err != nil {
+			return nil, err
+		}
+
+		members = append(members, m)
+	}
+
+	return members, rows.Err()
+}
+
+// acquireLease acquires the lease on partition p, or extends it if it is
+// already held by this member. ok is false if the lease is held by another
+// member and has not expired.
+func (g *ConsumerGroup) acquireLease(ctx context.Context, p uint) (bool, error) {
+	// Note that the assignments in the UPDATE clause are performed in order,
+	// so expires is only updated if the first assignment left this member as
+	// the lease holder.
+	_, err := g.store.db.ExecContext(
+		ctx,
+		`INSERT INTO consumer_lease SET
+			store_id = ?,
+			consumer_group = ?,
+			partition_id = ?,
+			member = ?,
+			expires = NOW(6) + INTERVAL ? MICROSECOND
+		ON DUPLICATE KEY UPDATE
+			member = IF(member = VALUES(member) OR expires <= NOW(6), VALUES(member), member),
+			expires = IF(member = VALUES(member), VALUES(expires), expires)`,
+		g.store.id,
+		g.group,
+		p,
+		g.member,
+		int64(g.lease/time.Microsecond),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	var holder string
+
+	row := g.store.db.QueryRowContext(
+		ctx,
+		`SELECT member
+		FROM consumer_lease
+		WHERE store_id = ?
+			AND consumer_group = ?
+			AND partition_id = ?`,
+		g.store.id,
+		g.group,
+		p,
+	)
+
+	if err := row.Scan(&holder); err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+
+	return holder == g.member, nil
+}
+
+// releaseLease releases the lease on partition p, if it is held by this
+// member.
+func (g *ConsumerGroup) releaseLease(ctx context.Context, p uint) error {
+	_, err := g.store.db.ExecContext(
+		ctx,
+		`DELETE FROM consumer_lease
+		WHERE store_id = ?
+			AND consumer_group = ?
+			AND partition_id = ?
+			AND member = ?`,
+		g.store.id,
+		g.group,
+		p,
+		g.member,
+	)
+
+	return err
+}
+
+// leasedCheckpointStore is a gospel.CheckpointStore that saves the checkpoint
+// of a partition only while this member holds the lease on that partition.
+type leasedCheckpointStore struct {
+	group *ConsumerGroup
+
+	// partition is the partition that the checkpoints belong to.
+	partition uint
+
+	// lost is called when the lease is found to have been lost, in order to
+	// stop the worker.
+	lost func()
+}
+
+// LoadCheckpoint returns the address most recently saved for the consumer
+// with the given name.
+func (cs *leasedCheckpointStore) LoadCheckpoint(
+	ctx context.Context,
+	name string,
+) (gospel.Address, bool, error) {
+	return cs.group.store.LoadCheckpoint(ctx, name)
+}
+
+// SaveCheckpoint saves addr as the checkpoint for the consumer with the given
+// name, if the lease on the partition is still held by this member.
+//
+// The lease is locked until the checkpoint is saved, so that it can not be
+// acquired by another member in the meantime.
+func (cs *leasedCheckpointStore) SaveCheckpoint(
+	ctx context.Context,
+	name string,
+	addr gospel.Address,
+) error {
+	g := cs.group
+
+	tx, err := g.store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var n int
+	if err := tx.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*)
+		FROM consumer_lease
+		WHERE store_id = ?
+			AND consumer_group = ?
+			AND partition_id = ?
+			AND member = ?
+			AND expires > NOW(6)
+		FOR UPDATE`,
+		g.store.id,
+		g.group,
+		cs.partition,
+		g.member,
+	).Scan(&n); err != nil {
+		return err
+	}
+
+	if n == 0 {
+		cs.lost()
+
+		return fmt.Errorf(
+			"can not save checkpoint, %s no longer holds the lease on partition %d",
+			g.member,
+			cs.partition,
+		)
+	}
+
+	if err := saveCheckpoint(ctx, tx, g.store.id, name, addr); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// assignPartitions returns the set of partitions, of n, that are assigned to
+// member, given the current members of the group, in order.
+//
+// Partitions are assigned to members in a round-robin fashion. No partitions
+// are assigned if member is not in members.
+func assignPartitions(n uint, members []string, member string) map[uint]bool {
+	assigned := map[uint]bool{}
+
+	for i, m := range members {
+		if m != member {
+			continue
+		}
+
+		for p := uint(i); p < n; p += uint(len(members)) {
+			assigned[p] = true
+		}
+	}
+
+	return assigned
+}
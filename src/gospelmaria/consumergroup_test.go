@@ -0,0 +1,424 @@
+// +build !without_mariadb
+
+package gospelmaria_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/jmalloc/gospel/src/gospelmaria"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConsumerGroup", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+
+		client *Client
+		store  *EventStore
+
+		m      sync.Mutex
+		bodies map[string][]string // member name -> bodies
+	)
+
+	// handler returns a gospelsub.Handler that records the body of each fact
+	// delivered to the given member, ignoring facts recorded by the store.
+	handler := func(member string) func(context.Context, gospel.Fact) error {
+		return func(_ context.Context, f gospel.Fact) error {
+			if f.Origin.Stream == "" {
+				return nil
+			}
+
+			m.Lock()
+			defer m.Unlock()
+
+			bodies[member] = append(bodies[member], string(f.Event.Body))
+
+			return nil
+		}
+	}
+
+	// all returns the bodies delivered to any member.
+	all := func() []string {
+		m.Lock()
+		defer m.Unlock()
+
+		var all []string
+		for _, b := range bodies {
+			all = append(all, b...)
+		}
+
+		return all
+	}
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 10*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+
+		client, store = getTestStore()
+		bodies = map[string][]string{}
+
+		for _, s := range []string{"stream-a", "stream-b", "stream-c", "stream-d"} {
+			_, err := store.AppendUnchecked(
+				ctx,
+				s,
+				gospel.Event{EventType: "event-type", Body: []byte(s + "-1")},
+				gospel.Event{EventType: "event-type", Body: []byte(s + "-2")},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+	})
+
+	AfterEach(func() {
+		cancel()
+		client.Close()
+		destroyTestSchema()
+	})
+
+	Describe("Run", func() {
+		It("delivers the facts in every partition to a single member", func() {
+			g := NewConsumerGroup(
+				store,
+				"test-group",
+				"member-1",
+				"",
+				PartitionCount(4),
+				LeaseDuration(time.Second),
+			)
+
+			result := make(chan error, 1)
+			go func() {
+				result <- g.Run(ctx, handler("member-1"))
+			}()
+
+			Eventually(all, 5*time.Second).Should(ConsistOf(
+				"stream-a-1", "stream-a-2",
+				"stream-b-1", "stream-b-2",
+				"stream-c-1", "stream-c-2",
+				"stream-d-1", "stream-d-2",
+			))
+
+			cancel()
+			Expect(<-result).To(Equal(context.Canceled))
+		})
+
+		It("shares the partitions between members", func() {
+			var wg sync.WaitGroup
+
+			for _, member := range []string{"member-1", "member-2"} {
+				g := NewConsumerGroup(
+					store,
+					"test-group",
+					member,
+					"",
+					PartitionCount(4),
+					LeaseDuration(time.Second),
+				)
+
+				h := handler(member)
+
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+					g.Run(ctx, h)
+				}()
+			}
+
+			// Every fact is delivered at least once, regardless of how the
+			// partitions are distributed while the members join.
+			Eventually(func() []string {
+				seen := map[string]bool{}
+				for _, b := range all() {
+					seen[b] = true
+				}
+
+				var unique []string
+				for b := range seen {
+					unique = append(unique, b)
+				}
+
+				return unique
+			}, 5*time.Second).Should(ConsistOf(
+				"stream-a-1", "stream-a-2",
+				"stream-b-1", "stream-b-2",
+				"stream-c-1", "stream-c-2",
+				"stream-d-1", "stream-d-2",
+			))
+
+			cancel()
+			wg.Wait()
+		})
+
+		It("resumes from the checkpoint of each partition", func() {
+			g := NewConsumerGroup(
+				store,
+				"test-group",
+				"member-1",
+				"",
+				PartitionCount(4),
+				LeaseDuration(time.Second),
+			)
+
+			c, stop := context.WithCancel(ctx)
+			result := make(chan error, 1)
+			go func() {
+				result <- g.Run(c, handler("member-1"))
+			}()
+
+			Eventually(all, 5*time.Second).Should(HaveLen(8))
+			stop()
+			<-result
+
+			_, err := store.AppendUnchecked(
+				ctx,
+				"stream-a",
+				gospel.Event{EventType: "event-type", Body: []byte("stream-a-3")},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			g = NewConsumerGroup(
+				store,
+				"test-group",
+				"member-2",
+				"",
+				PartitionCount(4),
+				LeaseDuration(time.Second),
+			)
+
+			go func() {
+				result <- g.Run(ctx, handler("member-2"))
+			}()
+
+			Eventually(func() []string {
+				m.Lock()
+				defer m.Unlock()
+				return append([]string(nil), bodies["member-2"]...)
+			}, 5*time.Second).Should(Equal([]string{"stream-a-3"}))
+
+			cancel()
+			<-result
+		})
+
+		It("does not save the checkpoint of a partition after its lease is lost", func() {
+			g := NewConsumerGroup(
+				store,
+				"test-group",
+				"member-1",
+				"",
+				PartitionCount(1),
+				LeaseDuration(time.Second),
+			)
+
+			blocked := make(chan struct{})
+			release := make(chan struct{})
+			h := handler("member-1")
+
+			result := make(chan error, 1)
+			go func() {
+				result <- g.Run(ctx, func(ctx context.Context, f gospel.Fact) error {
+					if string(f.Event.Body) == "stream-a-3" {
+						close(blocked)
+						<-release
+					}
+
+					return h(ctx, f)
+				})
+			}()
+
+			Eventually(all, 5*time.Second).Should(HaveLen(8))
+
+			_, err := store.AppendUnchecked(
+				ctx,
+				"stream-a",
+				gospel.Event{EventType: "event-type", Body: []byte("stream-a-3")},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			<-blocked
+
+			// Hand the lease to another member while the fact is being
+			// handled.
+			db := getTestDB()
+			defer db.Close()
+
+			_, err = db.Exec(
+				`UPDATE consumer_lease SET
+					member = 'member-2',
+					expires = NOW(6) + INTERVAL 1 HOUR
+				WHERE consumer_group = 'test-group'`,
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			// The checkpoint is committed after every fact, so it has already
+			// been committed up to the fact that is being handled.
+			checkpoint, _, err := store.LoadCheckpoint(ctx, "test-group/0")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			close(release)
+
+			Consistently(func() gospel.Address {
+				addr, _, err := store.LoadCheckpoint(ctx, "test-group/0")
+				Expect(err).ShouldNot(HaveOccurred())
+				return addr
+			}, time.Second).Should(Equal(checkpoint))
+
+			cancel()
+			Expect(<-result).To(Equal(context.Canceled))
+		})
+
+		It("cancels the handler's context when the lease is lost", func() {
+			g := NewConsumerGroup(
+				store,
+				"test-group",
+				"member-1",
+				"",
+				PartitionCount(1),
+				LeaseDuration(time.Second),
+			)
+
+			blocked := make(chan struct{})
+			canceled := make(chan struct{})
+			h := handler("member-1")
+
+			result := make(chan error, 1)
+			go func() {
+				result <- g.Run(ctx, func(hctx context.Context, f gospel.Fact) error {
+					if string(f.Event.Body) == "stream-a-3" {
+						close(blocked)
+						<-hctx.Done()
+						close(canceled)
+						return nil
+					}
+
+					return h(hctx, f)
+				})
+			}()
+
+			Eventually(all, 5*time.Second).Should(HaveLen(8))
+
+			_, err := store.AppendUnchecked(
+				ctx,
+				"stream-a",
+				gospel.Event{EventType: "event-type", Body: []byte("stream-a-3")},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			<-blocked
+
+			db := getTestDB()
+			defer db.Close()
+
+			_, err = db.Exec(
+				`UPDATE consumer_lease SET
+					member = 'member-2',
+					expires = NOW(6) + INTERVAL 1 HOUR
+				WHERE consumer_group = 'test-group'`,
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Eventually(canceled, 2*time.Second).Should(BeClosed())
+
+			cancel()
+			Expect(<-result).To(Equal(context.Canceled))
+		})
+
+		It("returns the error returned by the handler", func() {
+			e := errors.New("<error>")
+
+			g := NewConsumerGroup(
+				store,
+				"test-group",
+				"member-1",
+				"",
+				PartitionCount(4),
+				LeaseDuration(time.Second),
+			)
+
+			err := g.Run(ctx, func(context.Context, gospel.Fact) error {
+				return e
+			})
+
+			Expect(err).To(Equal(e))
+		})
+	})
+})
+
+var _ = Describe("FilterByPartition", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+
+		client *Client
+		store  *EventStore
+	)
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 3*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+
+		client, store = getTestStore()
+
+		for _, s := range []string{"stream-a", "stream-b", "stream-c", "stream-d"} {
+			_, err := store.AppendUnchecked(
+				ctx,
+				s,
+				gospel.Event{EventType: "event-type", Body: []byte(s)},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+	})
+
+	AfterEach(func() {
+		cancel()
+		client.Close()
+		destroyTestSchema()
+	})
+
+	It("only returns ε-stream facts from streams in the partition", func() {
+		for p := uint(0); p < 4; p++ {
+			r, err := store.Open(
+				ctx,
+				gospel.Address{},
+				FilterByPartition(p, 4),
+				gospel.StopAtHead(),
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			for {
+				_, err := r.Next(ctx)
+				if err == gospel.ErrEndOfRange {
+					break
+				}
+				Expect(err).ShouldNot(HaveOccurred())
+
+				Expect(PartitionOf(r.Get().Origin.Stream, 4)).To(Equal(p))
+			}
+
+			r.Close()
+		}
+	})
+
+	It("returns no facts from a named stream in a different partition", func() {
+		p := PartitionOf("stream-a", 4)
+
+		r, err := store.Open(
+			ctx,
+			gospel.Address{Stream: "stream-a"},
+			FilterByPartition((p+1)%4, 4),
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+		defer r.Close()
+
+		_, ok, err := r.TryNext(ctx)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ok).To(BeFalse())
+	})
+})
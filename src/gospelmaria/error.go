@@ -5,7 +5,8 @@ import (
 )
 
 const (
-	mysqlDeadLock = 1213 // https://dev.mysql.com/doc/refman/5.5/en/error-messages-server.html#error_er_lock_deadlock
+	mysqlDeadLock     = 1213 // https://dev.mysql.com/doc/refman/5.5/en/error-messages-server.html#error_er_lock_deadlock
+	mysqlDuplicateKey = 1062
 )
 
 // isDeadlock returns true if err represents a MySQL deadlock condition.
@@ -13,3 +14,13 @@ func isDeadlock(err error) bool {
 	e, ok := err.(*mysql.MySQLError)
 	return ok && e.Number == mysqlDeadLock
 }
+
+// isDuplicateKey returns true if err represents a MySQL duplicate key error.
+//
+// Such an error occurs when an event with the same ID is appended by a
+// concurrent transaction. The append can be retried, at which point it is
+// identified as a repeat of the concurrent append.
+func isDuplicateKey(err error) bool {
+	e, ok := err.(*mysql.MySQLError)
+	return ok && e.Number == mysqlDuplicateKey
+}
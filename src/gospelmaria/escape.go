@@ -1,6 +1,10 @@
 package gospelmaria
 
-import "bytes"
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
 
 // escapeString returns a quoted and escaped representation of s.
 // Neither the Go 'sql' package, nor the mysql driver currently expose string
@@ -48,3 +52,23 @@ func escapeStrings(s []string) []string {
 
 	return escaped
 }
+
+// escapeTime returns an SQL expression that evaluates to the time t.
+//
+// The expression is built from a Unix timestamp, so that it is independent of
+// the session's time zone. t is rounded up to the microsecond precision of a
+// TIMESTAMP(6) column, and times before the Unix epoch are clamped to the
+// epoch, as they can not be represented by a TIMESTAMP column.
+func escapeTime(t time.Time) string {
+	t = t.Add(time.Microsecond - 1).Truncate(time.Microsecond)
+
+	if t.Unix() < 0 {
+		t = time.Unix(0, 0)
+	}
+
+	return fmt.Sprintf(
+		"FROM_UNIXTIME(%d.%06d)",
+		t.Unix(),
+		t.Nanosecond()/int(time.Microsecond),
+	)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/jmalloc/gospel/src/gospel"
 	"github.com/jmalloc/gospel/src/internal/logging"
+	"github.com/jmalloc/gospel/src/internal/notify"
 	"github.com/jmalloc/gospel/src/internal/options"
 	"github.com/jmalloc/twelf/src/twelf"
 	"golang.org/x/time/rate"
@@ -27,10 +28,20 @@ type EventStore struct {
 	// provides a global cap of the number of read queries per second.
 	rlimit *rate.Limiter
 
+	// hub is used to wake readers when new facts are appended. It is shared by
+	// all event stores created by the same client.
+	hub *notify.Hub
+
 	// logger is the logger to use for activity and debug logging.
 	logger twelf.Logger
 }
 
+// streamKey is the key used to identify a stream when waiting on a notify.Hub.
+type streamKey struct {
+	storeID uint64
+	stream  string
+}
+
 // Append atomically writes one or more events to the end of a stream,
 // producing a contiguous block of facts.
 //
@@ -96,6 +107,79 @@ func (es *EventStore) AppendUnchecked(
 	return addr, err
 }
 
+// AppendExpected atomically writes one or more events to the end of a
+// stream, producing a contiguous block of facts.
+//
+// The stream must satisfy the expectation e, otherwise the append fails,
+// and IsConflict(err) returns true.
+//
+// nx is the address of the next unused offset after the facts have been
+// appended.
+//
+// AppendExpected panics if ev is empty.
+func (es *EventStore) AppendExpected(
+	ctx context.Context,
+	stream string,
+	e gospel.Expectation,
+	ev ...gospel.Event,
+) (gospel.Address, error) {
+	addr := gospel.Address{Stream: stream}
+	err := es.append(ctx, &addr, ev, appendExpected(e))
+
+	if err == nil {
+		logging.AppendExpected(
+			es.logger,
+			gospel.Address{
+				Stream: es.store + "::" + addr.Stream,
+				Offset: addr.Offset,
+			},
+			e,
+			ev,
+		)
+	} else if c, ok := err.(gospel.ConflictError); ok {
+		logging.Conflict(es.logger, c)
+	}
+
+	return addr, err
+}
+
+// AppendMulti atomically writes events to one or more streams. Either all
+// of the events are appended, or none of them are.
+//
+// Each stream must satisfy the expectation in its StreamAppend, otherwise the
+// append fails, and IsConflict(err) returns true. The address returned by the
+// error's ConflictDetails() method identifies the stream that conflicted.
+//
+// nx contains the address of the next unused offset of each stream after its
+// facts have been appended, in the same order as a.
+//
+// AppendMulti panics if a is empty, if any element of a has no events, or if
+// any element of a refers to the ε-stream.
+func (es *EventStore) AppendMulti(
+	ctx context.Context,
+	a ...gospel.StreamAppend,
+) ([]gospel.Address, error) {
+	nx, err := es.appendMulti(ctx, a)
+
+	if err == nil {
+		for i, x := range a {
+			logging.AppendExpected(
+				es.logger,
+				gospel.Address{
+					Stream: es.store + "::" + nx[i].Stream,
+					Offset: nx[i].Offset,
+				},
+				x.Expectation,
+				x.Events,
+			)
+		}
+	} else if c, ok := err.(gospel.ConflictError); ok {
+		logging.Conflict(es.logger, c)
+	}
+
+	return nx, err
+}
+
 // Open returns a reader that begins reading facts at addr.
 //
 // ctx applies to the opening of the reader, and not to the reader itself.
@@ -110,16 +194,32 @@ func (es *EventStore) Open(
 		es.id,
 		addr,
 		es.rlimit,
+		es.hub,
 		es.logger,
 		options.NewReaderOptions(opts),
 	)
 }
 
-// append writes events to a stream using the given append strategy.
+// StreamInfo returns information about the current state of a stream,
+// including the next unused offset.
+//
+// It does not return an error if the stream does not exist, instead
+// info.Exists is false.
+func (es *EventStore) StreamInfo(
+	ctx context.Context,
+	stream string,
+) (gospel.StreamInfo, error) {
+	return queryStreamInfo(ctx, es.db, es.id, stream)
+}
+
+// append writes events to a stream using the given append strategy, then
+// wakes any readers of the stream and the ε-stream that were opened via the
+// same client.
 //
 // If a deadlock occurs (which can occur for a single statement when using
-// InnoDB!) the append is retried. There is no limit on the retries other than
-// the context deadline.
+// InnoDB!), or an event with the same ID is appended concurrently, the append
+// is retried. There is no limit on the retries other than the context
+// deadline.
 func (es *EventStore) append(
 	ctx context.Context,
 	addr *gospel.Address,
@@ -146,8 +246,62 @@ func (es *EventStore) append(
 			strategy,
 		)
 
-		if !isDeadlock(err) {
+		if err == nil {
+			es.hub.Notify(streamKey{es.id, addr.Stream})
+			es.hub.Notify(streamKey{es.id, ""})
+		}
+
+		if !isDeadlock(err) && !isDuplicateKey(err) {
 			return err
 		}
 	}
 }
+
+// appendMulti writes events to several streams in a single transaction, then
+// wakes any readers of those streams and the ε-stream that were opened via
+// the same client.
+//
+// Deadlocks and duplicate keys are retried in the same way as for append().
+func (es *EventStore) appendMulti(
+	ctx context.Context,
+	appends []gospel.StreamAppend,
+) ([]gospel.Address, error) {
+	if len(appends) == 0 {
+		panic("no appends provided")
+	}
+
+	for _, a := range appends {
+		if a.Stream == "" {
+			panic("can not append to the ε-stream")
+		}
+
+		if len(a.Events) == 0 {
+			panic("no events provided")
+		}
+	}
+
+	addrs := make([]gospel.Address, len(appends))
+
+	for {
+		err := atomicAppendMulti(
+			ctx,
+			es.db,
+			es.id,
+			appends,
+			addrs,
+		)
+
+		if err == nil {
+			for _, a := range appends {
+				es.hub.Notify(streamKey{es.id, a.Stream})
+			}
+			es.hub.Notify(streamKey{es.id, ""})
+
+			return addrs, nil
+		}
+
+		if !isDeadlock(err) && !isDuplicateKey(err) {
+			return nil, err
+		}
+	}
+}
@@ -46,6 +46,22 @@ func getTestStore() (*gospelmaria.Client, *gospelmaria.EventStore) {
 	return c, es
 }
 
+// getTestDB returns a database pool that uses the test DSN, for use by tests
+// that need to access the database directly.
+func getTestDB() *sql.DB {
+	dsn := os.Getenv("GOSPEL_MARIADB_DSN")
+	if dsn == "" {
+		dsn = gospelmaria.DefaultDSN
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		panic(err)
+	}
+
+	return db
+}
+
 // destroyTestSchema removes all tables and procedures from the the database
 // schema specified by getTestDSN().
 func destroyTestSchema() {
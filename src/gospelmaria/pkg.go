@@ -1,3 +1,8 @@
 // Package gospelmaria is an implementation of the gospel public API that uses
 // MariaDB for storage.
+//
+// It also provides ConsumerGroup, which shares the work of consuming a stream
+// between several processes. Consumer groups deliver facts at least once, so
+// their handlers must be idempotent, and must stop when their context is
+// canceled.
 package gospelmaria
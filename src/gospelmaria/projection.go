@@ -0,0 +1,161 @@
+package gospelmaria
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmalloc/gospel/src/gospel"
+)
+
+// ProjectionHandler is a function that applies a fact to a read-model.
+//
+// All changes to the read-model must be made within tx. The transaction is
+// committed along with the projection's checkpoint after the handler returns.
+// If the handler returns an error, the transaction is rolled back and the
+// projection stops.
+//
+// The handler may be called more than once for the same fact if the
+// transaction is retried after a deadlock, but the changes are only committed
+// once.
+type ProjectionHandler func(ctx context.Context, tx *sql.Tx, f gospel.Fact) error
+
+// ErrProjectionConflict is returned by Projection.Run() if the projection's
+// checkpoint is modified by another process while the projection is running,
+// such as when two instances of the same projection are run concurrently.
+var ErrProjectionConflict = errors.New("projection checkpoint was modified by another process")
+
+// Projection applies the facts on a stream to read-model tables stored in the
+// same MariaDB database as the event store.
+//
+// Each fact is applied within a transaction that also updates the
+// projection's checkpoint, so that each fact is applied to the read-model
+// exactly once, even if the projection is stopped and restarted.
+type Projection struct {
+	// store is the event store that facts are read from, and to which the
+	// projection's checkpoint is saved.
+	store *EventStore
+
+	// name is the name under which the projection's checkpoint is saved.
+	name string
+
+	// stream is the stream to read when no checkpoint has been saved.
+	stream string
+
+	// readerOpts is the set of options used when opening the reader.
+	readerOpts []gospel.ReaderOption
+}
+
+// ProjectionOption is a function that applies an option to a projection.
+type ProjectionOption func(p *Projection)
+
+// ProjectionReaderOptions is a projection option that applies reader options
+// to the reader used by the projection.
+func ProjectionReaderOptions(opts ...gospel.ReaderOption) ProjectionOption {
+	return func(p *Projection) {
+		p.readerOpts = append(p.readerOpts, opts...)
+	}
+}
+
+// NewProjection returns a projection that reads facts from a stream of es,
+// saving its checkpoint under the given name.
+//
+// Reading begins at the start of the stream if no checkpoint has been saved,
+// otherwise it resumes from the checkpoint. The checkpoint is stored in the
+// same table as those saved via EventStore.SaveCheckpoint(), so the name must
+// not be shared with any other consumer.
+func NewProjection(
+	es *EventStore,
+	name, stream string,
+	opts ...ProjectionOption,
+) *Projection {
+	p := &Projection{
+		store:  es,
+		name:   name,
+		stream: stream,
+	}
+
+	for _, fn := range opts {
+		fn(p)
+	}
+
+	return p
+}
+
+// Run reads facts and applies them to the read-model using h.
+//
+// It blocks until the reader reaches the end of the range it was opened to
+// read, ctx is canceled, or an error occurs. It returns nil if the reader
+// reaches the end of its range, otherwise it returns the error that caused it
+// to stop.
+func (p *Projection) Run(ctx context.Context, h ProjectionHandler) error {
+	cp, exists, err := loadCheckpoint(ctx, p.store.db, p.store.id, p.name)
+	if err != nil {
+		return err
+	}
+
+	addr := cp
+	if !exists {
+		addr = gospel.Address{Stream: p.stream}
+	}
+
+	r, err := p.store.Open(ctx, addr, p.readerOpts...)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return gospel.ForEach(ctx, r, func(f gospel.Fact, nx gospel.Address) error {
+		for {
+			err := p.apply(ctx, h, f, cp, exists, nx)
+
+			if err == nil {
+				cp, exists = nx, true
+			}
+
+			if !isDeadlock(err) {
+				return err
+			}
+		}
+	})
+}
+
+// apply applies f to the read-model and advances the checkpoint to nx within
+// a single transaction.
+//
+// cp and exists are the checkpoint as last committed by this projection. If
+// the checkpoint has been changed by another process, it returns
+// ErrProjectionConflict without calling h.
+func (p *Projection) apply(
+	ctx context.Context,
+	h ProjectionHandler,
+	f gospel.Fact,
+	cp gospel.Address,
+	exists bool,
+	nx gospel.Address,
+) error {
+	tx, err := p.store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, ok, err := lockCheckpoint(ctx, tx, p.store.id, p.name)
+	if err != nil {
+		return err
+	}
+
+	if ok != exists || current != cp {
+		return ErrProjectionConflict
+	}
+
+	if err := h(ctx, tx, f); err != nil {
+		return err
+	}
+
+	if err := saveCheckpoint(ctx, tx, p.store.id, p.name, nx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
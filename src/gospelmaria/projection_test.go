@@ -0,0 +1,184 @@
+// +build !without_mariadb
+
+package gospelmaria_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/jmalloc/gospel/src/gospelmaria"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Projection", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+
+		client *Client
+		store  *EventStore
+		db     *sql.DB
+	)
+
+	// project is a ProjectionHandler that inserts the body of each fact into
+	// the read-model table.
+	project := func(ctx context.Context, tx *sql.Tx, f gospel.Fact) error {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO read_model SET body = ?`,
+			f.Event.Body,
+		)
+		return err
+	}
+
+	// readModel returns the bodies stored in the read-model table.
+	readModel := func() []string {
+		rows, err := db.Query(`SELECT body FROM read_model ORDER BY id`)
+		Expect(err).ShouldNot(HaveOccurred())
+		defer rows.Close()
+
+		var bodies []string
+		for rows.Next() {
+			var b string
+			Expect(rows.Scan(&b)).To(Succeed())
+			bodies = append(bodies, b)
+		}
+
+		return bodies
+	}
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 3*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+
+		client, store = getTestStore()
+		db = getTestDB()
+
+		_, err := db.Exec(
+			`CREATE TABLE read_model
+			(
+				id   BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+				body VARBINARY(255) NOT NULL
+			)`,
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = store.AppendUnchecked(
+			ctx,
+			"test-stream",
+			gospel.Event{EventType: "event-type-1", Body: []byte("event-1")},
+			gospel.Event{EventType: "event-type-2", Body: []byte("event-2")},
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		cancel()
+		db.Close()
+		client.Close()
+		destroyTestSchema()
+	})
+
+	Describe("Run", func() {
+		It("applies each fact to the read-model", func() {
+			p := NewProjection(
+				store,
+				"test-projection",
+				"test-stream",
+				ProjectionReaderOptions(gospel.StopAtHead()),
+			)
+
+			err := p.Run(ctx, project)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(readModel()).To(Equal([]string{"event-1", "event-2"}))
+		})
+
+		It("resumes from the checkpoint committed with the read-model", func() {
+			p := NewProjection(
+				store,
+				"test-projection",
+				"test-stream",
+				ProjectionReaderOptions(gospel.StopAtHead()),
+			)
+
+			err := p.Run(ctx, project)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = store.AppendUnchecked(
+				ctx,
+				"test-stream",
+				gospel.Event{EventType: "event-type-3", Body: []byte("event-3")},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			err = p.Run(ctx, project)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(readModel()).To(Equal([]string{"event-1", "event-2", "event-3"}))
+
+			addr, ok, err := store.LoadCheckpoint(ctx, "test-projection")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(addr).To(Equal(gospel.Address{Stream: "test-stream", Offset: 3}))
+		})
+
+		It("rolls back the read-model and checkpoint if the handler fails", func() {
+			e := errors.New("<error>")
+
+			p := NewProjection(store, "test-projection", "test-stream")
+
+			err := p.Run(ctx, func(ctx context.Context, tx *sql.Tx, f gospel.Fact) error {
+				if err := project(ctx, tx, f); err != nil {
+					return err
+				}
+
+				if f.Addr.Offset == 1 {
+					return e
+				}
+
+				return nil
+			})
+
+			Expect(err).To(Equal(e))
+			Expect(readModel()).To(Equal([]string{"event-1"}))
+
+			addr, _, err := store.LoadCheckpoint(ctx, "test-projection")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(addr).To(Equal(gospel.Address{Stream: "test-stream", Offset: 1}))
+		})
+
+		It("returns ErrProjectionConflict if the checkpoint is modified by another process", func() {
+			p := NewProjection(store, "test-projection", "test-stream")
+
+			result := make(chan error, 1)
+			go func() {
+				result <- p.Run(ctx, project)
+			}()
+
+			Eventually(readModel).Should(HaveLen(2))
+
+			err := store.SaveCheckpoint(
+				ctx,
+				"test-projection",
+				gospel.Address{Stream: "test-stream", Offset: 100},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = store.AppendUnchecked(
+				ctx,
+				"test-stream",
+				gospel.Event{EventType: "event-type-3", Body: []byte("event-3")},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(<-result).To(Equal(ErrProjectionConflict))
+			Expect(readModel()).To(HaveLen(2))
+		})
+	})
+})
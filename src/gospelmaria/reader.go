@@ -10,7 +10,10 @@ import (
 
 	"github.com/VividCortex/ewma"
 	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/internal/match"
+	"github.com/jmalloc/gospel/src/internal/metadata"
 	"github.com/jmalloc/gospel/src/internal/metrics"
+	"github.com/jmalloc/gospel/src/internal/notify"
 	"github.com/jmalloc/gospel/src/internal/options"
 	"github.com/jmalloc/twelf/src/twelf"
 	"golang.org/x/time/rate"
@@ -69,8 +72,43 @@ type Reader struct {
 	cancel func()
 
 	// addr is the starting address for the next database poll.
+	//
+	// If reverse is true, the next poll begins at the fact immediately before
+	// addr.
 	addr gospel.Address
 
+	// reverse is true if the reader reads facts in reverse order.
+	reverse bool
+
+	// bounded is true if the reader ends once it reaches the offset in stop,
+	// as per the StopAt() and StopAtHead() options.
+	bounded bool
+	stop    uint64
+
+	// headStmt is a prepared statement used to query for the next unused
+	// offset of the stream. It is only used by forward readers that are
+	// bounded, and is nil otherwise.
+	headStmt *sql.Stmt
+
+	// head is the next unused offset of the stream, as at the most recent
+	// query using headStmt.
+	head uint64
+
+	// storeID is the ID of the store that contains the stream.
+	storeID uint64
+
+	// hub is used to wait for notifications of facts appended via the same
+	// client.
+	hub *notify.Hub
+
+	// wake is a channel that is closed when a fact is appended to the stream
+	// via the same client. It is obtained before each poll, and interrupts the
+	// wait for the adaptive rate-limiter before the next poll.
+	wake <-chan struct{}
+
+	// releaseWake stops waiting on wake. It is nil until the first poll.
+	releaseWake func()
+
 	// globalLimit is a rate-limiter that limits the number of polling queries
 	// that can be performed each second. It is shared by all readers, and hence
 	// provides a global cap of the number of read queries per second.
@@ -139,6 +177,7 @@ func openReader(
 	storeID uint64,
 	addr gospel.Address,
 	limit *rate.Limiter,
+	hub *notify.Hub,
 	logger twelf.Logger,
 	opts *options.ReaderOptions,
 ) (*Reader, error) {
@@ -156,6 +195,9 @@ func openReader(
 		ctx:               runCtx,
 		cancel:            cancel,
 		addr:              addr,
+		reverse:           opts.Reverse,
+		storeID:           storeID,
+		hub:               hub,
 		globalLimit:       limit,
 		adaptiveLimit:     rate.NewLimiter(rate.Every(accetableLatency), 1),
 		acceptableLatency: accetableLatency,
@@ -171,7 +213,25 @@ func openReader(
 		}
 	}
 
+	if err := r.prepareBound(ctx, db, storeID, opts); err != nil {
+		return nil, err
+	}
+
+	if opts.FilterByTime && !opts.Reverse {
+		if err := r.seek(ctx, db, opts.FromTime); err != nil {
+			if r.headStmt != nil {
+				r.headStmt.Close()
+			}
+
+			return nil, err
+		}
+	}
+
 	if err := r.prepareStatement(ctx, db, storeID, opts); err != nil {
+		if r.headStmt != nil {
+			r.headStmt.Close()
+		}
+
 		return nil, err
 	}
 
@@ -214,7 +274,12 @@ func (r *Reader) TryNext(ctx context.Context) (nx gospel.Address, ok bool, err e
 func (r *Reader) tryNext(ctx context.Context, end <-chan struct{}) (nx gospel.Address, ok bool, err error) {
 	if r.next == nil {
 		select {
-		case f := <-r.facts:
+		case f, open := <-r.facts:
+			if !open {
+				err = gospel.ErrEndOfRange
+				return
+			}
+
 			r.current = &f
 			ok = true
 		case <-end:
@@ -237,12 +302,28 @@ func (r *Reader) tryNext(ctx context.Context, end <-chan struct{}) (nx gospel.Ad
 
 	// Perform a non-blocking lookahead to see if we have the next fact already.
 	select {
-	case f := <-r.facts:
-		r.next = &f
-		nx = r.next.Addr
+	case f, open := <-r.facts:
+		if !open {
+			// the reader has reached the end of its range
+			nx = gospel.Address{Stream: r.current.Addr.Stream}
+			if r.bounded {
+				nx.Offset = r.stop
+			}
+		} else if r.reverse {
+			r.next = &f
+			nx = r.next.Addr.Next()
+		} else {
+			r.next = &f
+			nx = r.next.Addr
+		}
 	default:
-		// assume next is literally the next fact on the stream
-		nx = r.current.Addr.Next()
+		if r.reverse {
+			// assume next is literally the previous fact on the stream
+			nx = r.current.Addr
+		} else {
+			// assume next is literally the next fact on the stream
+			nx = r.current.Addr.Next()
+		}
 	}
 
 	return
@@ -271,6 +352,103 @@ func (r *Reader) Close() error {
 	}
 }
 
+// prepareBound sets the offset at which the reader ends, if any.
+//
+// Forward readers that are bounded use r.headStmt to determine when all of the
+// facts before the stop offset have been appended, so it is prepared here. If
+// the StopAtHead() option is used the head of the stream is queried
+// immediately.
+func (r *Reader) prepareBound(
+	ctx context.Context,
+	db *sql.DB,
+	storeID uint64,
+	opts *options.ReaderOptions,
+) error {
+	r.bounded = opts.StopAtOffset
+	r.stop = opts.StopOffset
+
+	if opts.Reverse || !(opts.StopAtOffset || opts.StopAtHead) {
+		return nil
+	}
+
+	stmt, err := db.PrepareContext(
+		ctx,
+		`SELECT next FROM stream WHERE store_id = ? AND name = ?`,
+	)
+	if err != nil {
+		return err
+	}
+
+	r.headStmt = stmt
+
+	if opts.StopAtHead {
+		if err := r.queryHead(ctx); err != nil {
+			stmt.Close()
+			return err
+		}
+
+		if !r.bounded || r.head < r.stop {
+			r.bounded = true
+			r.stop = r.head
+		}
+	}
+
+	return nil
+}
+
+// seek advances r.addr to the lowest offset of any fact on the stream that
+// occurred at or after t, or to the head of the stream if there is no such
+// fact, so that the facts before it are never read.
+//
+// Fact times do not necessarily increase with the offset, so the reader still
+// filters out any facts after that offset that occurred before t.
+func (r *Reader) seek(ctx context.Context, db *sql.DB, t time.Time) error {
+	var offset uint64
+
+	if err := db.QueryRowContext(
+		ctx,
+		`SELECT COALESCE(
+			(
+				SELECT MIN(offset)
+				FROM fact
+				WHERE store_id = ?
+					AND stream = ?
+					AND time >= `+escapeTime(t)+`
+			),
+			(
+				SELECT next
+				FROM stream
+				WHERE store_id = ?
+					AND name = ?
+			),
+			0
+		)`,
+		r.storeID,
+		r.addr.Stream,
+		r.storeID,
+		r.addr.Stream,
+	).Scan(&offset); err != nil {
+		return err
+	}
+
+	if offset > r.addr.Offset {
+		r.addr.Offset = offset
+	}
+
+	return nil
+}
+
+// queryHead updates r.head to the next unused offset of the stream.
+func (r *Reader) queryHead(ctx context.Context) error {
+	row := r.headStmt.QueryRowContext(ctx, r.storeID, r.addr.Stream)
+
+	if err := row.Scan(&r.head); err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	return nil
+}
+
 // prepareStatement creates r.stmt, an SQL prepared statement used to poll
 // for new facts.
 func (r *Reader) prepareStatement(
@@ -281,8 +459,89 @@ func (r *Reader) prepareStatement(
 ) error {
 	filter := ""
 	if opts.FilterByEventType {
-		types := strings.Join(escapeStrings(opts.EventTypes), `, `)
-		filter = `AND e.event_type IN (` + types + `)`
+		filter = `AND ` + typeFilter(
+			`e.event_type`,
+			opts.EventTypes,
+			opts.EventTypePrefixes,
+			opts.EventTypePatterns,
+		)
+	}
+
+	if len(opts.ExcludedEventTypes) != 0 {
+		types := strings.Join(escapeStrings(opts.ExcludedEventTypes), `, `)
+		filter += ` AND e.event_type NOT IN (` + types + `)`
+	}
+
+	if opts.FilterByContentType {
+		filter += ` AND ` + typeFilter(
+			`e.content_type`,
+			opts.ContentTypes,
+			opts.ContentTypePrefixes,
+			nil,
+		)
+	}
+
+	if opts.FilterByTime {
+		filter += ` AND f.time >= ` + escapeTime(opts.FromTime)
+	}
+
+	// Facts on the ε-stream are joined to the same fact on the stream they
+	// were originally appended to, in order to populate the fact's origin and
+	// to apply the stream filter. Facts recorded by the store itself do not
+	// have an originating stream, so they are excluded by the stream filter.
+	origin := `f.stream, f.offset`
+	join := ""
+
+	if r.addr.Stream == "" {
+		kind, cond := `LEFT`, ``
+		if opts.FilterByStream {
+			kind = `INNER`
+			cond = ` AND ` + typeFilter(
+				`o.stream`,
+				opts.Streams,
+				opts.StreamPrefixes,
+				opts.StreamPatterns,
+			)
+		}
+
+		origin = `COALESCE(o.stream, ""), COALESCE(o.offset, f.offset)`
+		join = kind + ` JOIN fact AS o
+			ON o.store_id = f.store_id
+			AND o.event_id = f.event_id
+			AND o.time = f.time
+			AND o.stream != ""` + cond
+	} else if opts.FilterByStream && !match.Stream(opts, r.addr.Stream) {
+		filter += ` AND (1 = 0)`
+	}
+
+	// The partition of each fact is determined by its originating stream, so
+	// on named streams either all of the facts are in the partition, or none
+	// of them are.
+	partition := ""
+
+	if p, ok := getPartition(opts); ok {
+		if r.addr.Stream == "" {
+			partition = fmt.Sprintf(
+				` AND CRC32(COALESCE(o.stream, "")) %% %d = %d`,
+				p.count,
+				p.index,
+			)
+		} else if PartitionOf(r.addr.Stream, p.count) != p.index {
+			filter += ` AND (1 = 0)`
+		}
+	}
+
+	if r.bounded {
+		if opts.Reverse {
+			filter += fmt.Sprintf(` AND f.offset >= %d`, r.stop)
+		} else {
+			filter += fmt.Sprintf(` AND f.offset < %d`, r.stop)
+		}
+	}
+
+	bound, order := `>=`, `ASC`
+	if opts.Reverse {
+		bound, order = `<`, `DESC`
 	}
 
 	query := fmt.Sprintf(
@@ -292,19 +551,29 @@ func (r *Reader) prepareStatement(
 			e.event_type,
 			e.content_type,
 			e.body,
+			e.metadata,
+			COALESCE(e.uid, ""),
+			%s,
 			CURRENT_TIMESTAMP(6)
 		FROM fact AS f
 		INNER JOIN event AS e
 		ON e.id = f.event_id
 		%s
+		%s
 		WHERE f.store_id = %d
 			AND f.stream = %s
-			AND f.offset >= ?
-		ORDER BY offset
+			AND f.offset %s ?
+			%s
+		ORDER BY f.offset %s
 		LIMIT %d`,
+		origin,
 		filter,
+		join,
 		storeID,
 		escapeString(r.addr.Stream),
+		bound,
+		partition,
+		order,
 		cap(r.facts),
 	)
 
@@ -318,6 +587,36 @@ func (r *Reader) prepareStatement(
 	return nil
 }
 
+// typeFilter returns an SQL condition that matches rows where the value of
+// column is one of the values in exact, begins with one of the given prefixes,
+// or matches one of the given glob patterns.
+func typeFilter(column string, exact, prefixes, patterns []string) string {
+	var conds []string
+
+	if len(exact) != 0 {
+		conds = append(
+			conds,
+			column+` IN (`+strings.Join(escapeStrings(exact), `, `)+`)`,
+		)
+	}
+
+	for _, p := range prefixes {
+		conds = append(conds, column+` LIKE `+escapeString(match.LikePrefix(p)))
+	}
+
+	// The columns are binary strings, so '?' matches a single byte, as it does
+	// in the other event store implementations.
+	for _, p := range patterns {
+		conds = append(conds, column+` LIKE `+escapeString(match.Like(p)))
+	}
+
+	if len(conds) == 0 {
+		return `(1 = 0)`
+	}
+
+	return `(` + strings.Join(conds, ` OR `) + `)`
+}
+
 // run polls the database for facts and sends them to r.facts until r.ctx is
 // canceled or an error occurs.
 func (r *Reader) run() {
@@ -325,12 +624,29 @@ func (r *Reader) run() {
 	defer close(r.done)
 	defer r.stmt.Close()
 
+	if r.headStmt != nil {
+		defer r.headStmt.Close()
+	}
+
 	var err error
 
 	for err == nil {
 		err = r.tick()
 	}
 
+	if r.releaseWake != nil {
+		r.releaseWake()
+	}
+
+	if err == gospel.ErrEndOfRange {
+		// Closing r.facts causes Next() to return ErrEndOfRange once the
+		// buffered facts have been consumed. r.done is left open until the
+		// reader is closed.
+		close(r.facts)
+		<-r.ctx.Done()
+		return
+	}
+
 	if err != context.Canceled {
 		r.done <- err
 	}
@@ -342,10 +658,18 @@ func (r *Reader) tick() error {
 		return err
 	}
 
-	if err := r.adaptiveLimit.Wait(r.ctx); err != nil {
+	if err := r.waitAdaptive(); err != nil {
 		return err
 	}
 
+	// Begin waiting for notifications before polling, so that any facts
+	// appended during the poll cause the next poll to occur immediately.
+	if r.releaseWake != nil {
+		r.releaseWake()
+	}
+
+	r.wake, r.releaseWake = r.hub.Wait(streamKey{r.storeID, r.addr.Stream})
+
 	count, err := r.poll()
 	if err != nil {
 		return err
@@ -357,8 +681,44 @@ func (r *Reader) tick() error {
 	return nil
 }
 
+// waitAdaptive blocks until the adaptive rate-limiter allows another poll, or
+// until a fact is appended to the stream via the same client.
+func (r *Reader) waitAdaptive() error {
+	res := r.adaptiveLimit.Reserve()
+
+	d := res.Delay()
+	if d == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-r.wake:
+		// Return the reservation so that the poll that we are about to
+		// perform does not delay the subsequent one.
+		res.Cancel()
+		return nil
+	case <-r.ctx.Done():
+		res.Cancel()
+		return r.ctx.Err()
+	}
+}
+
 // fetch queries the database for facts beginning at r.addr.
 func (r *Reader) poll() (int, error) {
+	// The head must be queried before polling for facts, otherwise facts
+	// appended between the two queries could be mistaken for facts that do
+	// not match the reader's filter.
+	if r.headStmt != nil && r.head < r.stop {
+		if err := r.queryHead(r.ctx); err != nil {
+			return 0, err
+		}
+	}
+
 	rows, err := r.stmt.QueryContext(
 		r.ctx,
 		r.addr.Offset,
@@ -375,6 +735,7 @@ func (r *Reader) poll() (int, error) {
 	count := 0
 
 	var first, now time.Time
+	var md []byte
 
 	for rows.Next() {
 		if err := rows.Scan(
@@ -383,18 +744,31 @@ func (r *Reader) poll() (int, error) {
 			&f.Event.EventType,
 			&f.Event.ContentType,
 			&f.Event.Body,
+			&md,
+			&f.Event.ID,
+			&f.Origin.Stream,
+			&f.Origin.Offset,
 			&now,
 		); err != nil {
 			return count, err
 		}
 
+		f.Event.Metadata, err = metadata.Unmarshal(md)
+		if err != nil {
+			return count, err
+		}
+
 		select {
 		case r.facts <- f:
 		case <-r.ctx.Done():
 			return count, r.ctx.Err()
 		}
 
-		r.addr = f.Addr.Next()
+		if r.reverse {
+			r.addr = f.Addr
+		} else {
+			r.addr = f.Addr.Next()
+		}
 
 		// keep the time of the first fact in the result  to compute the maximum
 		// instantaneous latency for this poll.
@@ -413,6 +787,10 @@ func (r *Reader) poll() (int, error) {
 	r.instantaneousLatency = now.Sub(first)
 	r.averageLatency.Add(r.instantaneousLatency.Seconds())
 
+	if count < cap(r.facts) && r.exhausted() {
+		return count, gospel.ErrEndOfRange
+	}
+
 	if count == 0 {
 		select {
 		case r.end <- struct{}{}:
@@ -423,6 +801,18 @@ func (r *Reader) poll() (int, error) {
 	return count, nil
 }
 
+// exhausted returns true if a poll that did not fill the buffer indicates
+// that the reader has read all of the facts in its range.
+func (r *Reader) exhausted() bool {
+	// A reverse reader never waits for new facts, so the beginning of the
+	// stream (or the stop offset) has been reached.
+	if r.reverse {
+		return true
+	}
+
+	return r.bounded && (r.head >= r.stop || r.addr.Offset >= r.stop)
+}
+
 // setRate sets the adaptive polling rate, capped between the mininum (set by
 // r.starvationLatency) and the maximum (set by the global rate limit).
 func (r *Reader) setRate(lim rate.Limit) bool {
@@ -493,11 +883,6 @@ func (r *Reader) logInitialization() {
 		return
 	}
 
-	filter := "*"
-	if r.debug.opts.FilterByEventType {
-		filter = strings.Join(r.debug.opts.EventTypes, ", ")
-	}
-
 	r.logger.Debug(
 		"[reader %p] %s | global poll limit: %s | acceptable latency: %s | starvation latency: %s | read-buffer: %d | filter: %s",
 		r,
@@ -506,7 +891,7 @@ func (r *Reader) logInitialization() {
 		formatDuration(r.acceptableLatency),
 		formatDuration(r.starvationLatency),
 		getReadBufferSize(r.debug.opts),
-		filter,
+		match.Format(r.debug.opts),
 	)
 }
 
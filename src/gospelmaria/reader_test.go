@@ -91,6 +91,39 @@ var _ = Describe("Reader", func() {
 			_, err := reader.Next(ctx)
 			Expect(err).To(MatchError("reader is closed"))
 		})
+
+		Context("when the poll rate is low", func() {
+			BeforeEach(func() {
+				opts = append(opts, AcceptableLatency(time.Hour))
+			})
+
+			It("wakes immediately when a fact is appended via the same client", func() {
+				for i := 0; i < 3; i++ {
+					_, err := reader.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+				}
+
+				go func() {
+					defer GinkgoRecover()
+
+					time.Sleep(50 * time.Millisecond)
+
+					_, err := store.AppendUnchecked(
+						ctx,
+						"test-stream",
+						gospel.Event{EventType: "event-type-4", Body: []byte("event-4")},
+					)
+					Expect(err).ShouldNot(HaveOccurred())
+				}()
+
+				nextCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+				defer cancel()
+
+				_, err := reader.Next(nextCtx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(reader.Get().Event.Body).To(Equal([]byte("event-4")))
+			})
+		})
 	})
 
 	Describe("TryNext", func() {
@@ -1,6 +1,7 @@
 package gospelmaria
 
 import (
+	"hash/crc32"
 	"time"
 
 	"github.com/jmalloc/gospel/src/internal/options"
@@ -31,6 +32,7 @@ const (
 	readBufferKey readerOptionKey = iota
 	acceptableLatencyKey
 	starvationLatencyKey
+	partitionKey
 )
 
 // ReadBufferSize is a reader option that sets the number of facts to buffer
@@ -85,6 +87,9 @@ func getAcceptableLatency(o *options.ReaderOptions) time.Duration {
 // acceptable once the reader has reached the end of the stream and is "starving"
 // for facts.
 //
+// Facts that are appended via the same client as the reader are delivered
+// without waiting for the next poll, and hence are not subject to this latency.
+//
 // The setting is ignored if latency is less than the acceptable latency value.
 func StarvationLatency(latency time.Duration) options.ReaderOption {
 	if latency < 0 {
@@ -111,3 +116,46 @@ func getStarvationLatency(o *options.ReaderOptions) time.Duration {
 
 	return acceptable * StarvationLatencyFactor
 }
+
+// partition identifies one of a fixed number of partitions of a stream.
+type partition struct {
+	index uint
+	count uint
+}
+
+// FilterByPartition is a reader option that limits the reader to facts that
+// belong to partition p of n.
+//
+// Facts are assigned to partitions according to the name of the stream they
+// were originally appended to, as per PartitionOf(). Facts recorded by the
+// store itself belong to partition 0.
+//
+// It panics if n is zero, or p is not less than n.
+func FilterByPartition(p, n uint) options.ReaderOption {
+	if p >= n {
+		panic("partition must be less than the partition count")
+	}
+
+	return func(o *options.ReaderOptions) {
+		o.Set(partitionKey, partition{p, n})
+	}
+}
+
+// getPartition returns the partition set for the given reader options.
+// ok is false if the reader is not limited to a partition.
+func getPartition(o *options.ReaderOptions) (p partition, ok bool) {
+	if v, ok := o.Get(partitionKey); ok {
+		return v.(partition), true
+	}
+
+	return partition{}, false
+}
+
+// PartitionOf returns the partition that facts originally appended to stream
+// belong to, of n partitions.
+//
+// The partition is the CRC-32 (IEEE) checksum of the stream name modulo n,
+// which is equivalent to MariaDB's CRC32() function.
+func PartitionOf(stream string, n uint) uint {
+	return uint(uint64(crc32.ChecksumIEEE([]byte(stream))) % uint64(n))
+}
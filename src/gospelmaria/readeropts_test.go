@@ -118,3 +118,47 @@ var _ = Describe("starvation latency option", func() {
 		})
 	})
 })
+
+var _ = Describe("partition option", func() {
+	Describe("FilterByPartition", func() {
+		It("sets the partition", func() {
+			opts := &options.ReaderOptions{}
+
+			FilterByPartition(3, 8)(opts)
+
+			p, ok := getPartition(opts)
+			Expect(ok).To(BeTrue())
+			Expect(p).To(Equal(partition{3, 8}))
+		})
+
+		It("panics if the partition is not less than the partition count", func() {
+			Expect(func() {
+				FilterByPartition(8, 8)
+			}).To(Panic())
+		})
+	})
+
+	Describe("getPartition", func() {
+		It("returns ok == false if no partition is set", func() {
+			opts := &options.ReaderOptions{}
+
+			_, ok := getPartition(opts)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("PartitionOf", func() {
+		It("returns the CRC-32 checksum of the stream name modulo the partition count", func() {
+			// SELECT CRC32("test-stream") = 482834480
+			Expect(PartitionOf("test-stream", 1000)).To(
+				BeNumerically("==", 480),
+			)
+		})
+
+		It("places the ε-stream in partition 0", func() {
+			Expect(PartitionOf("", 16)).To(
+				BeNumerically("==", 0),
+			)
+		})
+	})
+})
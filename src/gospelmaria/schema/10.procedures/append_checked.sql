@@ -11,7 +11,9 @@ CREATE FUNCTION IF NOT EXISTS append_checked
     p_offset       BIGINT UNSIGNED,
     p_event_type   VARBINARY(255),
     p_content_type VARBINARY(255),
-    p_body         LONGBLOB
+    p_body         LONGBLOB,
+    p_metadata     LONGBLOB,
+    p_uid          VARBINARY(255)
 )
 RETURNS BOOLEAN
 NOT DETERMINISTIC
@@ -61,7 +63,9 @@ BEGIN
         p_store_id,
         p_event_type,
         p_content_type,
-        p_body
+        p_body,
+        p_metadata,
+        p_uid
     );
 
     -- Record a fact on the ε-stream.
@@ -0,0 +1,68 @@
+--
+-- append_expected records a new fact to a named stream and to the ε-stream,
+-- provided that the stream satisfies an expectation.
+--
+-- p_mode is the numeric value of a gospel.ExpectationMode:
+--
+--   0 = any offset
+--   1 = next unused offset is exactly p_offset
+--   2 = stream does not exist
+--   3 = stream exists
+--   4 = next unused offset is at most p_offset
+--
+-- It returns the offset at which the event is appended, or NULL if there is a
+-- conflict.
+--
+CREATE FUNCTION IF NOT EXISTS append_expected
+(
+    p_store_id     BIGINT UNSIGNED,
+    p_stream       VARBINARY(255),
+    p_mode         TINYINT UNSIGNED,
+    p_offset       BIGINT UNSIGNED,
+    p_event_type   VARBINARY(255),
+    p_content_type VARBINARY(255),
+    p_body         LONGBLOB,
+    p_metadata     LONGBLOB,
+    p_uid          VARBINARY(255)
+)
+RETURNS BIGINT UNSIGNED
+NOT DETERMINISTIC
+MODIFIES SQL DATA
+SQL SECURITY DEFINER
+BEGIN
+    DECLARE v_next BIGINT UNSIGNED DEFAULT 0;
+    DECLARE CONTINUE HANDLER FOR NOT FOUND SET v_next = 0;
+
+    -- Lock the stream row (or the gap where it would be) so that the offset
+    -- can not change between checking the expectation and appending.
+    SELECT next
+        INTO v_next
+        FROM stream
+    WHERE store_id = p_store_id
+        AND name   = p_stream
+    FOR UPDATE;
+
+    IF (p_mode = 1 AND v_next != p_offset)
+        OR (p_mode = 2 AND v_next != 0)
+        OR (p_mode = 3 AND v_next = 0)
+        OR (p_mode = 4 AND v_next > p_offset) THEN
+        RETURN NULL;
+    END IF;
+
+    -- append_checked still guards against a concurrent creation of the stream,
+    -- which is not prevented by the lock above.
+    IF NOT append_checked(
+        p_store_id,
+        p_stream,
+        v_next,
+        p_event_type,
+        p_content_type,
+        p_body,
+        p_metadata,
+        p_uid
+    ) THEN
+        RETURN NULL;
+    END IF;
+
+    RETURN v_next;
+END;
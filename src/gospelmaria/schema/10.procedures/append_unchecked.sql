@@ -7,7 +7,9 @@ CREATE FUNCTION IF NOT EXISTS append_unchecked
     p_stream       VARBINARY(255),
     p_event_type   VARBINARY(255),
     p_content_type VARBINARY(255),
-    p_body         LONGBLOB
+    p_body         LONGBLOB,
+    p_metadata     LONGBLOB,
+    p_uid          VARBINARY(255)
 )
 RETURNS BIGINT UNSIGNED
 NOT DETERMINISTIC
@@ -30,7 +32,9 @@ BEGIN
         p_store_id,
         p_event_type,
         p_content_type,
-        p_body
+        p_body,
+        p_metadata,
+        p_uid
     );
 
     -- We don't care what the offset is now, we just want to find it so we can
@@ -22,7 +22,9 @@ BEGIN
             p_store_id,
             "$store.created",
             "application/vnd.gospel.store.created.v1",
-            p_store
+            p_store,
+            NULL,
+            NULL
         )
     );
 END;
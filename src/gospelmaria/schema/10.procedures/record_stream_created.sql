@@ -22,7 +22,9 @@ BEGIN
             p_store_id,
             "$stream.created",
             "application/vnd.gospel.stream.created.v1",
-            p_stream
+            p_stream,
+            NULL,
+            NULL
         )
     );
 END;
@@ -9,7 +9,9 @@ CREATE FUNCTION IF NOT EXISTS store_event
     p_store_id     BIGINT UNSIGNED,
     p_event_type   VARBINARY(255),
     p_content_type VARBINARY(255),
-    p_body         LONGBLOB
+    p_body         LONGBLOB,
+    p_metadata     LONGBLOB,
+    p_uid          VARBINARY(255)
 )
 RETURNS BIGINT UNSIGNED
 NOT DETERMINISTIC
@@ -21,7 +23,9 @@ BEGIN
         store_id     = p_store_id,
         event_type   = p_event_type,
         content_type = p_content_type,
-        body         = p_body;
+        body         = p_body,
+        metadata     = p_metadata,
+        uid          = p_uid;
 
     RETURN LAST_INSERT_ID();
 END;
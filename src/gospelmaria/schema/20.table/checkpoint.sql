@@ -0,0 +1,14 @@
+--
+-- checkpoint contains the address that each named consumer has reached while
+-- reading from an event store, so that it can resume reading after a restart.
+--
+CREATE TABLE IF NOT EXISTS checkpoint
+(
+    store_id BIGINT UNSIGNED NOT NULL,
+    name     VARBINARY(255) NOT NULL,
+    stream   VARBINARY(255) NOT NULL,
+    offset   BIGINT UNSIGNED NOT NULL,
+
+    PRIMARY KEY (store_id, name)
+)
+ROW_FORMAT=COMPRESSED;
@@ -0,0 +1,17 @@
+--
+-- consumer_lease contains the leases held by consumer group members. Each
+-- partition of a consumer group is leased to at most one member at a time. A
+-- lease that is not renewed before it expires can be acquired by another
+-- member.
+--
+CREATE TABLE IF NOT EXISTS consumer_lease
+(
+    store_id       BIGINT UNSIGNED NOT NULL,
+    consumer_group VARBINARY(255) NOT NULL,
+    partition_id   INT UNSIGNED NOT NULL,
+    member         VARBINARY(255) NOT NULL,
+    expires        TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (store_id, consumer_group, partition_id)
+)
+ROW_FORMAT=COMPRESSED;
@@ -0,0 +1,15 @@
+--
+-- consumer_member contains the members of each consumer group. A member that
+-- does not renew its membership before it expires is considered to have left
+-- the group.
+--
+CREATE TABLE IF NOT EXISTS consumer_member
+(
+    store_id       BIGINT UNSIGNED NOT NULL,
+    consumer_group VARBINARY(255) NOT NULL,
+    member         VARBINARY(255) NOT NULL,
+    expires        TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (store_id, consumer_group, member)
+)
+ROW_FORMAT=COMPRESSED;
@@ -10,6 +10,8 @@ CREATE TABLE IF NOT EXISTS event
     event_type   VARBINARY(255) NOT NULL,
     content_type VARBINARY(255) NOT NULL,
     body         LONGBLOB,
+    metadata     LONGBLOB, -- JSON object, NULL if the event has no metadata
+    uid          VARBINARY(255), -- application-defined ID, NULL if the event has no ID
 
     PRIMARY KEY (id, time) -- PK must include all partitioning columns.
 )
@@ -0,0 +1,18 @@
+--
+-- event_uid maps application-defined event IDs to the address at which the
+-- event was appended. It is used to detect appends that are repeated, such as
+-- when a client retries after a network failure.
+--
+-- Event IDs are stored here rather than as a unique key on the 'event' table,
+-- as unique keys on partitioned tables must include the partitioning columns.
+--
+CREATE TABLE IF NOT EXISTS event_uid
+(
+    store_id BIGINT UNSIGNED NOT NULL,
+    uid      VARBINARY(255) NOT NULL,
+    stream   VARBINARY(255) NOT NULL,
+    offset   BIGINT UNSIGNED NOT NULL,
+
+    PRIMARY KEY (store_id, uid)
+)
+ROW_FORMAT=COMPRESSED;
@@ -16,7 +16,14 @@ CREATE TABLE IF NOT EXISTS fact
 
     -- There is deliberately no PK defined. Any custom PK would need to include
     -- the time column, since it's used as a partitioning key.
-    INDEX (store_id, stream, offset)
+    INDEX (store_id, stream, offset),
+
+    -- Used to find the fact on the originating stream of ε-stream facts.
+    INDEX fact_event_id (event_id),
+
+    -- Used to find the first fact at or after a given time, as per FromTime().
+    -- The offset is included so that the lookup reads only the index.
+    INDEX fact_time (store_id, stream, time, offset)
 )
 ROW_FORMAT=COMPRESSED
 PARTITION BY RANGE (FLOOR(UNIX_TIMESTAMP(time)))
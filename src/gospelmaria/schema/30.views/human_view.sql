@@ -13,7 +13,8 @@ VIEW IF NOT EXISTS human_view AS
         f.offset,
         e.event_type,
         e.content_type,
-        e.body
+        e.body,
+        e.metadata
     FROM store AS o
     INNER JOIN fact AS f
         ON f.store_id = o.id
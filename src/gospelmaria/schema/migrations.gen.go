@@ -0,0 +1,43 @@
+package schema
+
+var migrations = []string{
+	`--
+-- 01.event_metadata adds event metadata to schemas created by gospel 0.1.0.
+--
+-- The routines that store events are dropped so that they are re-created with
+-- their current signatures when the rest of the schema is created, once all
+-- migrations have been applied.
+--
+ALTER TABLE event ADD COLUMN IF NOT EXISTS metadata LONGBLOB AFTER body;
+
+DROP FUNCTION IF EXISTS append_checked;
+DROP FUNCTION IF EXISTS append_expected;
+DROP FUNCTION IF EXISTS append_unchecked;
+DROP FUNCTION IF EXISTS store_event;
+DROP PROCEDURE IF EXISTS record_store_created;
+DROP PROCEDURE IF EXISTS record_stream_created;
+DROP VIEW IF EXISTS human_view;
+`,
+	`--
+-- 02.event_uid adds application-defined event IDs to schemas created by gospel
+-- 0.1.0.
+--
+-- The 'event_uid' table did not previously exist, so it is created along with
+-- the rest of the schema once all migrations have been applied. The routines
+-- that store events were dropped by 01.event_metadata.
+--
+ALTER TABLE event ADD COLUMN IF NOT EXISTS uid VARBINARY(255) AFTER metadata;
+`,
+	`--
+-- 03.fact_event_id adds the index used to find the fact on the originating
+-- stream of ε-stream facts to schemas created by gospel 0.1.0.
+--
+CREATE INDEX IF NOT EXISTS fact_event_id ON fact (event_id);
+`,
+	`--
+-- 04.fact_time adds the index used to find the first fact at or after a given
+-- time to schemas created before readers could seek by time.
+--
+CREATE INDEX IF NOT EXISTS fact_time ON fact (store_id, stream, time, offset);
+`,
+}
@@ -0,0 +1,16 @@
+--
+-- 01.event_metadata adds event metadata to schemas created by gospel 0.1.0.
+--
+-- The routines that store events are dropped so that they are re-created with
+-- their current signatures when the rest of the schema is created, once all
+-- migrations have been applied.
+--
+ALTER TABLE event ADD COLUMN IF NOT EXISTS metadata LONGBLOB AFTER body;
+
+DROP FUNCTION IF EXISTS append_checked;
+DROP FUNCTION IF EXISTS append_expected;
+DROP FUNCTION IF EXISTS append_unchecked;
+DROP FUNCTION IF EXISTS store_event;
+DROP PROCEDURE IF EXISTS record_store_created;
+DROP PROCEDURE IF EXISTS record_stream_created;
+DROP VIEW IF EXISTS human_view;
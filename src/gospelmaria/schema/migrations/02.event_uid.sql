@@ -0,0 +1,9 @@
+--
+-- 02.event_uid adds application-defined event IDs to schemas created by gospel
+-- 0.1.0.
+--
+-- The 'event_uid' table did not previously exist, so it is created along with
+-- the rest of the schema once all migrations have been applied. The routines
+-- that store events were dropped by 01.event_metadata.
+--
+ALTER TABLE event ADD COLUMN IF NOT EXISTS uid VARBINARY(255) AFTER metadata;
@@ -0,0 +1,5 @@
+--
+-- 03.fact_event_id adds the index used to find the fact on the originating
+-- stream of ε-stream facts to schemas created by gospel 0.1.0.
+--
+CREATE INDEX IF NOT EXISTS fact_event_id ON fact (event_id);
@@ -0,0 +1,5 @@
+--
+-- 04.fact_time adds the index used to find the first fact at or after a given
+-- time to schemas created before readers could seek by time.
+--
+CREATE INDEX IF NOT EXISTS fact_time ON fact (store_id, stream, time, offset);
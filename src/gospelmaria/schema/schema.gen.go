@@ -68,7 +68,9 @@ CREATE FUNCTION IF NOT EXISTS append_checked
     p_offset       BIGINT UNSIGNED,
     p_event_type   VARBINARY(255),
     p_content_type VARBINARY(255),
-    p_body         LONGBLOB
+    p_body         LONGBLOB,
+    p_metadata     LONGBLOB,
+    p_uid          VARBINARY(255)
 )
 RETURNS BOOLEAN
 NOT DETERMINISTIC
@@ -118,7 +120,9 @@ BEGIN
         p_store_id,
         p_event_type,
         p_content_type,
-        p_body
+        p_body,
+        p_metadata,
+        p_uid
     );
 
     -- Record a fact on the ε-stream.
@@ -135,6 +139,74 @@ BEGIN
     RETURN TRUE;
 END;
 --
+-- append_expected records a new fact to a named stream and to the ε-stream,
+-- provided that the stream satisfies an expectation.
+--
+-- p_mode is the numeric value of a gospel.ExpectationMode:
+--
+--   0 = any offset
+--   1 = next unused offset is exactly p_offset
+--   2 = stream does not exist
+--   3 = stream exists
+--   4 = next unused offset is at most p_offset
+--
+-- It returns the offset at which the event is appended, or NULL if there is a
+-- conflict.
+--
+CREATE FUNCTION IF NOT EXISTS append_expected
+(
+    p_store_id     BIGINT UNSIGNED,
+    p_stream       VARBINARY(255),
+    p_mode         TINYINT UNSIGNED,
+    p_offset       BIGINT UNSIGNED,
+    p_event_type   VARBINARY(255),
+    p_content_type VARBINARY(255),
+    p_body         LONGBLOB,
+    p_metadata     LONGBLOB,
+    p_uid          VARBINARY(255)
+)
+RETURNS BIGINT UNSIGNED
+NOT DETERMINISTIC
+MODIFIES SQL DATA
+SQL SECURITY DEFINER
+BEGIN
+    DECLARE v_next BIGINT UNSIGNED DEFAULT 0;
+    DECLARE CONTINUE HANDLER FOR NOT FOUND SET v_next = 0;
+
+    -- Lock the stream row (or the gap where it would be) so that the offset
+    -- can not change between checking the expectation and appending.
+    SELECT next
+        INTO v_next
+        FROM stream
+    WHERE store_id = p_store_id
+        AND name   = p_stream
+    FOR UPDATE;
+
+    IF (p_mode = 1 AND v_next != p_offset)
+        OR (p_mode = 2 AND v_next != 0)
+        OR (p_mode = 3 AND v_next = 0)
+        OR (p_mode = 4 AND v_next > p_offset) THEN
+        RETURN NULL;
+    END IF;
+
+    -- append_checked still guards against a concurrent creation of the stream,
+    -- which is not prevented by the lock above.
+    IF NOT append_checked(
+        p_store_id,
+        p_stream,
+        v_next,
+        p_event_type,
+        p_content_type,
+        p_body,
+        p_metadata,
+        p_uid
+    ) THEN
+        RETURN NULL;
+    END IF;
+
+    RETURN v_next;
+END;
+--
 -- append_unchecked records a new fact to a named stream and to the ε-stream.
 --
 CREATE FUNCTION IF NOT EXISTS append_unchecked
@@ -143,7 +215,9 @@ CREATE FUNCTION IF NOT EXISTS append_unchecked
     p_stream       VARBINARY(255),
     p_event_type   VARBINARY(255),
     p_content_type VARBINARY(255),
-    p_body         LONGBLOB
+    p_body         LONGBLOB,
+    p_metadata     LONGBLOB,
+    p_uid          VARBINARY(255)
 )
 RETURNS BIGINT UNSIGNED
 NOT DETERMINISTIC
@@ -166,7 +240,9 @@ BEGIN
         p_store_id,
         p_event_type,
         p_content_type,
-        p_body
+        p_body,
+        p_metadata,
+        p_uid
     );
 
     -- We don't care what the offset is now, we just want to find it so we can
@@ -307,7 +383,9 @@ BEGIN
             p_store_id,
             "$store.created",
             "application/vnd.gospel.store.created.v1",
-            p_store
+            p_store,
+            NULL,
+            NULL
         )
     );
 END;
@@ -335,7 +413,9 @@ BEGIN
             p_store_id,
             "$stream.created",
             "application/vnd.gospel.stream.created.v1",
-            p_stream
+            p_stream,
+            NULL,
+            NULL
         )
     );
 END;
@@ -350,7 +430,9 @@ CREATE FUNCTION IF NOT EXISTS store_event
     p_store_id     BIGINT UNSIGNED,
     p_event_type   VARBINARY(255),
     p_content_type VARBINARY(255),
-    p_body         LONGBLOB
+    p_body         LONGBLOB,
+    p_metadata     LONGBLOB,
+    p_uid          VARBINARY(255)
 )
 RETURNS BIGINT UNSIGNED
 NOT DETERMINISTIC
@@ -362,11 +444,59 @@ BEGIN
         store_id     = p_store_id,
         event_type   = p_event_type,
         content_type = p_content_type,
-        body         = p_body;
+        body         = p_body,
+        metadata     = p_metadata,
+        uid          = p_uid;
 
     RETURN LAST_INSERT_ID();
 END;
 --
+-- checkpoint contains the address that each named consumer has reached while
+-- reading from an event store, so that it can resume reading after a restart.
+--
+CREATE TABLE IF NOT EXISTS checkpoint
+(
+    store_id BIGINT UNSIGNED NOT NULL,
+    name     VARBINARY(255) NOT NULL,
+    stream   VARBINARY(255) NOT NULL,
+    offset   BIGINT UNSIGNED NOT NULL,
+
+    PRIMARY KEY (store_id, name)
+)
+ROW_FORMAT=COMPRESSED;
+--
+-- consumer_lease contains the leases held by consumer group members. Each
+-- partition of a consumer group is leased to at most one member at a time. A
+-- lease that is not renewed before it expires can be acquired by another
+-- member.
+--
+CREATE TABLE IF NOT EXISTS consumer_lease
+(
+    store_id       BIGINT UNSIGNED NOT NULL,
+    consumer_group VARBINARY(255) NOT NULL,
+    partition_id   INT UNSIGNED NOT NULL,
+    member         VARBINARY(255) NOT NULL,
+    expires        TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (store_id, consumer_group, partition_id)
+)
+ROW_FORMAT=COMPRESSED;
+--
+-- consumer_member contains the members of each consumer group. A member that
+-- does not renew its membership before it expires is considered to have left
+-- the group.
+--
+CREATE TABLE IF NOT EXISTS consumer_member
+(
+    store_id       BIGINT UNSIGNED NOT NULL,
+    consumer_group VARBINARY(255) NOT NULL,
+    member         VARBINARY(255) NOT NULL,
+    expires        TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (store_id, consumer_group, member)
+)
+ROW_FORMAT=COMPRESSED;
+--
 -- event contains application-defined event data.
 --
 CREATE TABLE IF NOT EXISTS event
@@ -378,6 +508,8 @@ CREATE TABLE IF NOT EXISTS event
     event_type   VARBINARY(255) NOT NULL,
     content_type VARBINARY(255) NOT NULL,
     body         LONGBLOB,
+    metadata     LONGBLOB, -- JSON object, NULL if the event has no metadata
+    uid          VARBINARY(255), -- application-defined ID, NULL if the event has no ID
 
     PRIMARY KEY (id, time) -- PK must include all partitioning columns.
 )
@@ -399,6 +531,24 @@ CREATE EVENT IF NOT EXISTS partition_event
     ON COMPLETION PRESERVE
     DO CALL alter_partitions('event');
 --
+-- event_uid maps application-defined event IDs to the address at which the
+-- event was appended. It is used to detect appends that are repeated, such as
+-- when a client retries after a network failure.
+--
+-- Event IDs are stored here rather than as a unique key on the 'event' table,
+-- as unique keys on partitioned tables must include the partitioning columns.
+--
+CREATE TABLE IF NOT EXISTS event_uid
+(
+    store_id BIGINT UNSIGNED NOT NULL,
+    uid      VARBINARY(255) NOT NULL,
+    stream   VARBINARY(255) NOT NULL,
+    offset   BIGINT UNSIGNED NOT NULL,
+
+    PRIMARY KEY (store_id, uid)
+)
+ROW_FORMAT=COMPRESSED;
+--
 -- fact contains mappings of stream and offset to events.
 --
 -- Every event appended by the client appears on both the named stream it was
@@ -416,7 +566,14 @@ CREATE TABLE IF NOT EXISTS fact
 
     -- There is deliberately no PK defined. Any custom PK would need to include
     -- the time column, since it's used as a partitioning key.
-    INDEX (store_id, stream, offset)
+    INDEX (store_id, stream, offset),
+
+    -- Used to find the fact on the originating stream of ε-stream facts.
+    INDEX fact_event_id (event_id),
+
+    -- Used to find the first fact at or after a given time, as per FromTime().
+    -- The offset is included so that the lookup reads only the index.
+    INDEX fact_time (store_id, stream, time, offset)
 )
 ROW_FORMAT=COMPRESSED
 PARTITION BY RANGE (FLOOR(UNIX_TIMESTAMP(time)))
@@ -479,7 +636,8 @@ VIEW IF NOT EXISTS human_view AS
         f.offset,
         e.event_type,
         e.content_type,
-        e.body
+        e.body,
+        e.metadata
     FROM store AS o
     INNER JOIN fact AS f
         ON f.store_id = o.id
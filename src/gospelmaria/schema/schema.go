@@ -1,9 +1,127 @@
 package schema
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
 
-// Create creates the gospel schema on the given database pool.
+const (
+	// lockName is the name of the MariaDB user-level lock that is held while
+	// the schema is created. It prevents concurrent clients from attempting to
+	// migrate the schema at the same time.
+	//
+	// A lock is used rather than a transaction because MariaDB commits DDL
+	// statements implicitly. For the same reason, each migration must be safe
+	// to apply again if a previous attempt failed part-way through.
+	lockName = "gospel.schema"
+
+	// lockTimeout is the number of seconds to wait to acquire the lock.
+	lockTimeout = 60
+)
+
+// Create creates the gospel schema on the given database pool, or migrates an
+// existing schema to the current version.
+//
+// The schema version is the number of migrations that have been applied. New
+// databases are created at the current version without applying any
+// migrations. Databases that contain a schema but no version were created
+// before versioning was introduced, and are treated as version zero.
 func Create(db *sql.DB) error {
-	_, err := db.Exec(statements)
+	ctx := context.Background()
+
+	// The lock is held by a specific connection, so all statements must be
+	// executed on the same connection.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(
+		ctx,
+		`SELECT GET_LOCK(?, ?)`,
+		lockName,
+		lockTimeout,
+	).Scan(&locked); err != nil {
+		return err
+	}
+
+	if locked.Int64 != 1 {
+		return errors.New("timed out waiting for the schema lock")
+	}
+	defer conn.ExecContext(ctx, `DO RELEASE_LOCK(?)`, lockName)
+
+	v, err := version(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	if v > len(migrations) {
+		return fmt.Errorf(
+			"schema version %d is newer than the latest supported version (%d)",
+			v,
+			len(migrations),
+		)
+	}
+
+	for _, m := range migrations[v:] {
+		if _, err := conn.ExecContext(ctx, m); err != nil {
+			return err
+		}
+	}
+
+	if _, err := conn.ExecContext(ctx, statements); err != nil {
+		return err
+	}
+
+	_, err = conn.ExecContext(
+		ctx,
+		`DELETE FROM schema_version;
+		INSERT INTO schema_version SET version = ?`,
+		len(migrations),
+	)
+
 	return err
 }
+
+// version returns the current schema version.
+func version(ctx context.Context, conn *sql.Conn) (int, error) {
+	if _, err := conn.ExecContext(
+		ctx,
+		`CREATE TABLE IF NOT EXISTS schema_version
+		(
+			version INT UNSIGNED NOT NULL
+		)`,
+	); err != nil {
+		return 0, err
+	}
+
+	var v int
+	err := conn.QueryRowContext(
+		ctx,
+		`SELECT version FROM schema_version`,
+	).Scan(&v)
+	if err != sql.ErrNoRows {
+		return v, err
+	}
+
+	var n int
+	if err := conn.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*)
+		FROM information_schema.tables
+		WHERE table_schema = DATABASE()
+			AND table_name = 'store'`,
+	).Scan(&n); err != nil {
+		return 0, err
+	}
+
+	if n == 0 {
+		return len(migrations), nil
+	}
+
+	return 0, nil
+}
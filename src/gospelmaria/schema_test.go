@@ -0,0 +1,151 @@
+// +build !without_mariadb
+
+package gospelmaria_test
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/gospelmaria"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("schema", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+		db     *sql.DB
+	)
+
+	// exec executes each of the given statements directly on the database.
+	exec := func(statements ...string) {
+		for _, s := range statements {
+			_, err := db.Exec(s)
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+	}
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 3*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+
+		db = getTestDB()
+
+		c := getTestClient()
+		c.Close()
+	})
+
+	AfterEach(func() {
+		cancel()
+		db.Close()
+		destroyTestSchema()
+	})
+
+	It("does not change the schema version when opened repeatedly", func() {
+		var before, after int
+
+		err := db.QueryRow(`SELECT version FROM schema_version`).Scan(&before)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		c := getTestClient()
+		c.Close()
+
+		err = db.QueryRow(`SELECT version FROM schema_version`).Scan(&after)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(after).To(Equal(before))
+	})
+
+	It("returns an error if the schema is newer than the client", func() {
+		exec(`UPDATE schema_version SET version = version + 1`)
+
+		_, err := gospelmaria.OpenEnv()
+		Expect(err).To(MatchError(ContainSubstring("newer than the latest supported version")))
+	})
+
+	Context("when the database was created before schema versioning", func() {
+		BeforeEach(func() {
+			// Revert the schema to the state it was in before event metadata,
+			// IDs and the fact_event_id and fact_time indexes were added. append_unchecked() is
+			// replaced with a function that has a different signature, which
+			// must not survive the migration.
+			exec(
+				`DROP TABLE schema_version`,
+				`DROP VIEW human_view`,
+				`ALTER TABLE event DROP COLUMN metadata`,
+				`ALTER TABLE event DROP COLUMN uid`,
+				`DROP TABLE event_uid`,
+				`DROP INDEX fact_event_id ON fact`,
+				`DROP INDEX fact_time ON fact`,
+				`DROP FUNCTION append_unchecked`,
+				`CREATE FUNCTION append_unchecked (p_store_id BIGINT UNSIGNED)
+				RETURNS BIGINT UNSIGNED
+				RETURN NULL`,
+			)
+		})
+
+		It("migrates the schema such that events with metadata and IDs can be appended", func() {
+			c, es := getTestStore()
+			defer c.Close()
+
+			_, err := es.AppendUnchecked(
+				ctx,
+				"test-stream",
+				gospel.Event{
+					ID:        "id-1",
+					EventType: "event-type-1",
+					Metadata:  map[string]string{"key": "value"},
+				},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			r, err := es.Open(ctx, gospel.Address{Stream: "test-stream"})
+			Expect(err).ShouldNot(HaveOccurred())
+			defer r.Close()
+
+			_, err = r.Next(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(r.Get().Event.Metadata).To(Equal(map[string]string{"key": "value"}))
+			Expect(r.Get().Event.ID).To(Equal("id-1"))
+
+			_, err = db.Exec(`SELECT metadata FROM human_view`)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("adds the index on the event ID of each fact", func() {
+			c := getTestClient()
+			c.Close()
+
+			var n int
+			err := db.QueryRow(
+				`SELECT COUNT(*)
+				FROM information_schema.statistics
+				WHERE table_schema = DATABASE()
+					AND table_name = 'fact'
+					AND index_name = 'fact_event_id'`,
+			).Scan(&n)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(n).To(Equal(1))
+		})
+
+		It("adds the index used to find facts by time", func() {
+			c := getTestClient()
+			c.Close()
+
+			var n int
+			err := db.QueryRow(
+				`SELECT COUNT(DISTINCT index_name)
+				FROM information_schema.statistics
+				WHERE table_schema = DATABASE()
+					AND table_name = 'fact'
+					AND index_name = 'fact_time'`,
+			).Scan(&n)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(n).To(Equal(1))
+		})
+	})
+})
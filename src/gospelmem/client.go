@@ -0,0 +1,112 @@
+package gospelmem
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/internal/options"
+	"github.com/jmalloc/twelf/src/twelf"
+)
+
+// Client is an in-memory "server" that hosts an arbitrary number of named event
+// stores.
+type Client struct {
+	// m protects stores and is held while a store is being created.
+	m sync.Mutex
+
+	// stores is a map of store name to the in-memory store data.
+	stores map[string]*store
+
+	// done is a signaling channel that is closed when Close() is called. It is
+	// shared by all event stores and readers created through this client.
+	done chan struct{}
+
+	// logger is the logger to use for activity and debug logging. It is
+	// inherited by all event stores and their readers.
+	logger twelf.Logger
+}
+
+// errClientClosed is an error returned by any operation that is performed
+// after the client has been closed.
+var errClientClosed = errors.New("client is closed")
+
+// NewClient returns a new in-memory client with no event stores.
+func NewClient(opts ...gospel.Option) *Client {
+	o := options.NewClientOptions(opts)
+
+	return &Client{
+		stores: map[string]*store{},
+		done:   make(chan struct{}),
+		logger: o.Logger,
+	}
+}
+
+// OpenStore returns an event store by name, creating it if it does not already
+// exist.
+//
+// ctx applies to the opening of the store, and not to the store itself.
+func (c *Client) OpenStore(ctx context.Context, name string) (*EventStore, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	select {
+	case <-c.done:
+		return nil, errClientClosed
+	default:
+	}
+
+	s, ok := c.stores[name]
+
+	if !ok {
+		s = &store{
+			name:        name,
+			streams:     map[string][]gospel.Fact{},
+			ids:         map[string]gospel.Address{},
+			checkpoints: map[string]gospel.Address{},
+			done:        c.done,
+		}
+
+		s.record(
+			"",
+			time.Now(),
+			gospel.Event{
+				EventType:   "$store.created",
+				ContentType: "application/vnd.gospel.store.created.v1",
+				Body:        []byte(name),
+			},
+		)
+
+		c.stores[name] = s
+	}
+
+	c.logger.Debug("opened '%s' event store", name)
+
+	return &EventStore{
+		s,
+		c.logger,
+	}, nil
+}
+
+// Close closes the client.
+//
+// Any operations performed on the client's event stores or readers after it is
+// closed return an error.
+func (c *Client) Close() error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+
+	return nil
+}
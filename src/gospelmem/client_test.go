@@ -0,0 +1,87 @@
+package gospelmem_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/jmalloc/gospel/src/gospelmem"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+		client *Client
+	)
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 3*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+
+		client = NewClient()
+	})
+
+	AfterEach(func() {
+		cancel()
+		client.Close()
+	})
+
+	Describe("OpenStore", func() {
+		It("returns a gospel.EventStore", func() {
+			var es gospel.EventStore // static interface check
+			es, err := client.OpenStore(ctx, "test")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(es).NotTo(BeNil())
+		})
+
+		It("returns stores that share the same facts when opened with the same name", func() {
+			es1, err := client.OpenStore(ctx, "test")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			es2, err := client.OpenStore(ctx, "test")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = es1.Append(
+				ctx,
+				gospel.Address{Stream: "test-stream"},
+				gospel.Event{},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = es2.Append(
+				ctx,
+				gospel.Address{Stream: "test-stream"},
+				gospel.Event{},
+			)
+			Expect(gospel.IsConflict(err)).To(BeTrue())
+		})
+
+		It("records a $store.created fact on the ε-stream", func() {
+			es, err := client.OpenStore(ctx, "test")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			r, err := es.Open(ctx, gospel.Address{})
+			Expect(err).ShouldNot(HaveOccurred())
+			defer r.Close()
+
+			_, err = r.Next(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(r.Get().Event).To(Equal(gospel.Event{
+				EventType:   "$store.created",
+				ContentType: "application/vnd.gospel.store.created.v1",
+				Body:        []byte("test"),
+			}))
+		})
+
+		It("returns an error if the client is closed", func() {
+			client.Close()
+			_, err := client.OpenStore(ctx, "test")
+			Expect(err).Should(MatchError("client is closed"))
+		})
+	})
+})
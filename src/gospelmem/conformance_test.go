@@ -0,0 +1,37 @@
+package gospelmem_test
+
+import (
+	"context"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/gospelmem"
+	"github.com/jmalloc/gospel/src/gospeltest"
+)
+
+var (
+	_ = gospeltest.DescribeEventStore(newConformanceStore)
+	_ = gospeltest.DescribeReader(newConformanceStore)
+	_ = gospeltest.DescribeCheckpointStore(newConformanceCheckpointStore)
+)
+
+// newConformanceStore is a gospeltest.Factory that returns an EventStore from
+// a new in-memory client.
+func newConformanceStore() (gospel.EventStore, func()) {
+	c := gospelmem.NewClient()
+
+	es, err := c.OpenStore(context.Background(), "test")
+	if err != nil {
+		panic(err)
+	}
+
+	return es, func() {
+		c.Close()
+	}
+}
+
+// newConformanceCheckpointStore is a gospeltest.CheckpointFactory that returns
+// the EventStore produced by newConformanceStore.
+func newConformanceCheckpointStore() (gospel.CheckpointStore, func()) {
+	es, done := newConformanceStore()
+	return es.(gospel.CheckpointStore), done
+}
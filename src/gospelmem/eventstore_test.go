@@ -0,0 +1,130 @@
+package gospelmem_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/jmalloc/gospel/src/gospelmem"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EventStore", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+
+		client *Client
+		store  *EventStore
+	)
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 3*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+
+		client = NewClient()
+
+		var err error
+		store, err = client.OpenStore(ctx, "test")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		cancel()
+		client.Close()
+	})
+
+	Describe("Append", func() {
+		It("returns an error if the client is closed", func() {
+			client.Close()
+
+			_, err := store.Append(
+				ctx,
+				gospel.Address{Stream: "test-stream"},
+				gospel.Event{},
+			)
+			Expect(err).Should(MatchError("client is closed"))
+		})
+
+		It("does not retain a reference to the event body", func() {
+			body := []byte("event-1")
+
+			_, err := store.Append(
+				ctx,
+				gospel.Address{Stream: "test-stream"},
+				gospel.Event{Body: body},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			body[0] = 'E'
+
+			r, err := store.Open(ctx, gospel.Address{Stream: "test-stream"})
+			Expect(err).ShouldNot(HaveOccurred())
+			defer r.Close()
+
+			_, err = r.Next(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(r.Get().Event.Body).To(Equal([]byte("event-1")))
+		})
+	})
+
+	Describe("AppendUnchecked", func() {
+		It("returns an error if the client is closed", func() {
+			client.Close()
+
+			_, err := store.AppendUnchecked(
+				ctx,
+				"test-stream",
+				gospel.Event{},
+			)
+			Expect(err).Should(MatchError("client is closed"))
+		})
+	})
+
+	Describe("Open", func() {
+		It("returns an error if the client is closed", func() {
+			client.Close()
+
+			_, err := store.Open(ctx, gospel.Address{Stream: "test-stream"})
+			Expect(err).Should(MatchError("client is closed"))
+		})
+	})
+
+	Describe("ε-stream", func() {
+		It("contains only the expected facts", func() {
+			_, err := store.Append(
+				ctx,
+				gospel.Address{Stream: "test-stream"},
+				gospel.Event{EventType: "event-type-1"},
+				gospel.Event{EventType: "event-type-2"},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			r, err := store.Open(ctx, gospel.Address{})
+			Expect(err).ShouldNot(HaveOccurred())
+			defer r.Close()
+
+			var types []string
+
+			for {
+				_, ok, err := r.TryNext(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				if !ok {
+					break
+				}
+
+				types = append(types, r.Get().Event.EventType)
+			}
+
+			Expect(types).To(Equal([]string{
+				"$store.created",
+				"$stream.created",
+				"event-type-1",
+				"event-type-2",
+			}))
+		})
+	})
+})
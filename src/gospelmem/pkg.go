@@ -0,0 +1,7 @@
+// Package gospelmem is an implementation of the gospel public API that stores
+// all facts in memory.
+//
+// It is intended for use in tests, and in applications that need to embed an
+// event store without any external dependencies. Facts are not persisted, they
+// are lost when the client is closed or the process exits.
+package gospelmem
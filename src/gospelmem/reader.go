@@ -0,0 +1,87 @@
+package gospelmem
+
+import (
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/internal/indexreader"
+	"github.com/jmalloc/gospel/src/internal/options"
+	"github.com/jmalloc/twelf/src/twelf"
+)
+
+// Reader is an interface for reading facts from a stream stored in memory.
+//
+// Unlike readers that poll a database, a Reader is woken as soon as a fact is
+// appended to its stream.
+type Reader struct {
+	*indexreader.Reader
+}
+
+// openReader returns a new reader that begins at addr.
+func openReader(
+	s *store,
+	addr gospel.Address,
+	logger twelf.Logger,
+	opts *options.ReaderOptions,
+) *Reader {
+	return &Reader{
+		indexreader.Open(s, addr, logger, opts),
+	}
+}
+
+// RLock acquires a read lock on the store. It implements indexreader.Index.
+func (s *store) RLock() {
+	s.m.RLock()
+}
+
+// RUnlock releases the read lock on the store. It implements
+// indexreader.Index.
+func (s *store) RUnlock() {
+	s.m.RUnlock()
+}
+
+// Done returns a channel that is closed when the client is closed. It
+// implements indexreader.Index.
+func (s *store) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns errClientClosed if the client has been closed. It implements
+// indexreader.Index.
+func (s *store) Err() error {
+	select {
+	case <-s.done:
+		return errClientClosed
+	default:
+		return nil
+	}
+}
+
+// Entry returns the index entry of the fact at addr. It implements
+// indexreader.Index.
+//
+// s.m must be held, for reading or writing.
+func (s *store) Entry(addr gospel.Address) indexreader.Entry {
+	f := s.streams[addr.Stream][addr.Offset]
+
+	return indexreader.Entry{
+		Origin:      f.Origin,
+		Time:        f.Time,
+		EventType:   f.Event.EventType,
+		ContentType: f.Event.ContentType,
+	}
+}
+
+// Read returns a copy of the fact at addr. It implements indexreader.Index.
+//
+// s.m must be held, for reading or writing.
+func (s *store) Read(addr gospel.Address) (gospel.Fact, error) {
+	f := s.streams[addr.Stream][addr.Offset]
+	f.Event = copyEvent(f.Event)
+
+	return f, nil
+}
+
+// Wait returns a channel that is closed when facts are appended to the given
+// stream. It implements indexreader.Index.
+func (s *store) Wait(stream string) (<-chan struct{}, func()) {
+	return s.hub.Wait(stream)
+}
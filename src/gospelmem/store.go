@@ -0,0 +1,165 @@
+package gospelmem
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/internal/metadata"
+	"github.com/jmalloc/gospel/src/internal/notify"
+)
+
+// store contains the facts for a single named event store.
+//
+// It is shared by all EventStore instances and readers that refer to the same
+// store name.
+type store struct {
+	// name is the name of the store.
+	name string
+
+	// m protects streams, ids and checkpoints. It is held for writing while
+	// facts are appended, which guarantees that appends are atomic.
+	m sync.RWMutex
+
+	// streams is a map of stream name to the facts on that stream, in order.
+	// The ε-stream is stored under the empty string.
+	streams map[string][]gospel.Fact
+
+	// ids is a map of event ID to the address at which the event with that ID
+	// was appended to a named stream.
+	ids map[string]gospel.Address
+
+	// checkpoints is a map of consumer name to the checkpoint most recently
+	// saved by that consumer.
+	checkpoints map[string]gospel.Address
+
+	// hub is used to wake readers that are waiting for new facts. Readers wait
+	// on the stream name as the key.
+	hub notify.Hub
+
+	// done is a signaling channel that is closed when the client is closed.
+	done <-chan struct{}
+}
+
+// next returns the next unused offset of the given stream.
+//
+// s.m must be held, for reading or writing.
+func (s *store) next(stream string) uint64 {
+	return uint64(len(s.streams[stream]))
+}
+
+// NextOffset returns the next unused offset of the given stream. It is
+// equivalent to next(), and implements plan.State.
+//
+// s.m must be held, for reading or writing.
+func (s *store) NextOffset(stream string) uint64 {
+	return s.next(stream)
+}
+
+// LookupID returns the address of the event with the given ID. It implements
+// plan.State.
+//
+// s.m must be held, for reading or writing.
+func (s *store) LookupID(id string) (gospel.Address, bool) {
+	addr, ok := s.ids[id]
+	return addr, ok
+}
+
+// record unconditionally appends a single fact to a stream.
+//
+// s.m must be held for writing. It does not notify readers of the new fact.
+func (s *store) record(stream string, now time.Time, ev gospel.Event) gospel.Address {
+	addr := gospel.Address{
+		Stream: stream,
+		Offset: s.next(stream),
+	}
+
+	f := gospel.Fact{
+		Addr:   addr,
+		Origin: addr,
+		Time:   now,
+		Event:  copyEvent(ev),
+	}
+
+	s.streams[stream] = append(s.streams[stream], f)
+
+	if stream != "" && ev.ID != "" {
+		s.ids[ev.ID] = f.Addr
+	}
+
+	return f.Addr
+}
+
+// append appends events to a stream beginning at the stream's next unused
+// offset, and records the corresponding facts on the ε-stream.
+//
+// s.m must be held for writing. It returns the address of the next unused
+// offset after the append.
+func (s *store) append(stream string, events []gospel.Event) gospel.Address {
+	now := time.Now()
+
+	if s.next(stream) == 0 {
+		s.record(
+			"",
+			now,
+			gospel.Event{
+				EventType:   "$stream.created",
+				ContentType: "application/vnd.gospel.stream.created.v1",
+				Body:        []byte(stream),
+			},
+		)
+	}
+
+	var addr gospel.Address
+
+	for _, ev := range events {
+		e := s.record("", now, ev)
+		addr = s.record(stream, now, ev)
+		s.streams[""][e.Offset].Origin = addr
+	}
+
+	return addr.Next()
+}
+
+// info returns information about the given stream.
+//
+// s.m must be held, for reading or writing.
+func (s *store) info(stream string) gospel.StreamInfo {
+	facts := s.streams[stream]
+
+	info := gospel.StreamInfo{
+		Next: gospel.Address{
+			Stream: stream,
+			Offset: uint64(len(facts)),
+		},
+		FactCount: uint64(len(facts)),
+	}
+
+	if len(facts) > 0 {
+		info.Exists = true
+		info.FirstFactTime = facts[0].Time
+		info.LastFactTime = facts[len(facts)-1].Time
+	}
+
+	return info
+}
+
+// notify wakes any readers waiting on the given stream or the ε-stream.
+func (s *store) notify(stream string) {
+	s.hub.Notify(stream)
+	s.hub.Notify("")
+}
+
+// copyEvent returns a copy of ev that does not share its body or metadata
+// with ev.
+func copyEvent(ev gospel.Event) gospel.Event {
+	if ev.Body != nil {
+		body := make([]byte, len(ev.Body))
+		copy(body, ev.Body)
+		ev.Body = body
+	}
+
+	ev.Metadata = metadata.Copy(ev.Metadata)
+
+	return ev
+}
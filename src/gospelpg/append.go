@@ -0,0 +1,380 @@
+package gospelpg
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/internal/apierror"
+	"github.com/jmalloc/gospel/src/internal/dedupe"
+	"github.com/jmalloc/gospel/src/internal/metadata"
+	"github.com/lib/pq"
+)
+
+// atomicAppend writes events to a stream inside a transaction using the given
+// append strategy.
+func atomicAppend(
+	ctx context.Context,
+	db *sql.DB,
+	storeID uint64,
+	addr *gospel.Address,
+	events []gospel.Event,
+	strategy appendStrategy,
+) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := appendOnce(ctx, tx, storeID, addr, events, strategy); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// atomicAppendMulti writes events to several streams inside a single
+// transaction, using the expectation of each StreamAppend.
+//
+// addrs must have the same length as appends. Each element is set to the
+// address of the next unused offset of the corresponding stream after its
+// append.
+func atomicAppendMulti(
+	ctx context.Context,
+	db *sql.DB,
+	storeID uint64,
+	appends []gospel.StreamAppend,
+	addrs []gospel.Address,
+) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, a := range appends {
+		addrs[i] = gospel.Address{Stream: a.Stream}
+		strategy := appendExpected(a.Expectation)
+
+		if err := appendOnce(ctx, tx, storeID, &addrs[i], a.Events, strategy); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// appendOnce appends events to a stream within tx using the given append
+// strategy, unless the append is a repeat of an earlier append of events with
+// the same IDs, in which case addr.Offset is set to the next unused offset
+// after the original append.
+func appendOnce(
+	ctx context.Context,
+	tx *sql.Tx,
+	storeID uint64,
+	addr *gospel.Address,
+	events []gospel.Event,
+	strategy appendStrategy,
+) error {
+	ids, err := dedupe.IDs(events)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) != 0 {
+		prev, err := queryIDs(ctx, tx, storeID, ids)
+		if err != nil {
+			return err
+		}
+
+		o, orig, ev := dedupe.Compare(addr.Stream, events, prev)
+
+		switch o {
+		case dedupe.Repeat:
+			*addr = orig
+			return nil
+		case dedupe.Conflict:
+			next, err := queryNext(ctx, tx, storeID, addr.Stream)
+			if err != nil {
+				return err
+			}
+
+			return apierror.NewDuplicateConflict(ev, orig, next)
+		}
+	}
+
+	if err := strategy(ctx, tx, storeID, addr, events); err != nil {
+		return err
+	}
+
+	if len(ids) != 0 {
+		return recordIDs(ctx, tx, storeID, *addr, events)
+	}
+
+	return nil
+}
+
+// queryIDs returns the addresses at which events with the given IDs were
+// appended, as read within tx. IDs that have not been appended are omitted.
+func queryIDs(
+	ctx context.Context,
+	tx *sql.Tx,
+	storeID uint64,
+	ids []string,
+) (map[string]gospel.Address, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT uid, stream, "offset" FROM event_uid WHERE store_id = $1 AND uid = ANY($2)`,
+		storeID,
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prev := map[string]gospel.Address{}
+
+	for rows.Next() {
+		var (
+			id   string
+			addr gospel.Address
+		)
+
+		if err := rows.Scan(&id, &addr.Stream, &addr.Offset); err != nil {
+			return nil, err
+		}
+
+		prev[id] = addr
+	}
+
+	return prev, rows.Err()
+}
+
+// recordIDs records the addresses of the events that have IDs, given nx, the
+// address of the next unused offset after they were appended.
+func recordIDs(
+	ctx context.Context,
+	tx *sql.Tx,
+	storeID uint64,
+	nx gospel.Address,
+	events []gospel.Event,
+) error {
+	offset := nx.Offset - uint64(len(events))
+
+	for _, ev := range events {
+		if ev.ID != "" {
+			if _, err := tx.ExecContext(
+				ctx,
+				`INSERT INTO event_uid (store_id, uid, stream, "offset") VALUES ($1, $2, $3, $4)`,
+				storeID,
+				ev.ID,
+				nx.Stream,
+				offset,
+			); err != nil {
+				return err
+			}
+		}
+
+		offset++
+	}
+
+	return nil
+}
+
+// appendStrategy is a function that actually performs the database queries
+// to write events.
+//
+// addr.Offset is updated to refer to the next unused offset after the append.
+type appendStrategy func(
+	ctx context.Context,
+	tx *sql.Tx,
+	storeID uint64,
+	addr *gospel.Address,
+	events []gospel.Event,
+) error
+
+// appendChecked is an append strategy which verifies that addr refers to the
+// next unused offset.
+func appendChecked(
+	ctx context.Context,
+	tx *sql.Tx,
+	storeID uint64,
+	addr *gospel.Address,
+	events []gospel.Event,
+) error {
+	for _, ev := range events {
+		md, err := metadata.Marshal(ev.Metadata)
+		if err != nil {
+			return err
+		}
+
+		row := tx.QueryRowContext(
+			ctx,
+			`SELECT append_checked($1, $2, $3, $4, $5, $6, $7, $8)`,
+			storeID,
+			addr.Stream,
+			addr.Offset,
+			ev.EventType,
+			ev.ContentType,
+			ev.Body,
+			md,
+			eventUID(ev),
+		)
+
+		var ok bool
+		if err := row.Scan(&ok); err != nil {
+			return err
+		}
+
+		if !ok {
+			return conflict(ctx, tx, storeID, *addr, ev)
+		}
+
+		addr.Offset++
+	}
+
+	return nil
+}
+
+// conflict returns a conflict error for an attempt to append ev at addr. The
+// error includes the actual next unused offset of the stream, which is read
+// within tx.
+func conflict(
+	ctx context.Context,
+	tx *sql.Tx,
+	storeID uint64,
+	addr gospel.Address,
+	ev gospel.Event,
+) error {
+	next, err := queryNext(ctx, tx, storeID, addr.Stream)
+	if err != nil {
+		return err
+	}
+
+	return apierror.NewConflict(addr, ev, next)
+}
+
+// queryNext returns the address of the next unused offset of stream, as read
+// within tx.
+func queryNext(
+	ctx context.Context,
+	tx *sql.Tx,
+	storeID uint64,
+	stream string,
+) (gospel.Address, error) {
+	next := gospel.Address{Stream: stream}
+
+	row := tx.QueryRowContext(
+		ctx,
+		`SELECT next FROM stream WHERE store_id = $1 AND name = $2`,
+		storeID,
+		stream,
+	)
+
+	if err := row.Scan(&next.Offset); err != nil && err != sql.ErrNoRows {
+		return next, err
+	}
+
+	return next, nil
+}
+
+// appendExpected returns an append strategy which verifies that the stream
+// satisfies the expectation e before appending.
+func appendExpected(e gospel.Expectation) appendStrategy {
+	return func(
+		ctx context.Context,
+		tx *sql.Tx,
+		storeID uint64,
+		addr *gospel.Address,
+		events []gospel.Event,
+	) error {
+		ev := events[0]
+
+		md, err := metadata.Marshal(ev.Metadata)
+		if err != nil {
+			return err
+		}
+
+		row := tx.QueryRowContext(
+			ctx,
+			`SELECT append_expected($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			storeID,
+			addr.Stream,
+			e.Mode,
+			e.Offset,
+			ev.EventType,
+			ev.ContentType,
+			ev.Body,
+			md,
+			eventUID(ev),
+		)
+
+		var offset sql.NullInt64
+		if err := row.Scan(&offset); err != nil {
+			return err
+		}
+
+		if !offset.Valid {
+			next, err := queryNext(ctx, tx, storeID, addr.Stream)
+			if err != nil {
+				return err
+			}
+
+			return apierror.NewExpectationConflict(addr.Stream, e, ev, next)
+		}
+
+		// The remaining events are appended immediately after the first, which
+		// is now known to be the next unused offset.
+		addr.Offset = uint64(offset.Int64) + 1
+
+		return appendChecked(ctx, tx, storeID, addr, events[1:])
+	}
+}
+
+// appendUnchecked is an append strategy which always appends regardless
+// of the offset in addr.
+func appendUnchecked(
+	ctx context.Context,
+	tx *sql.Tx,
+	storeID uint64,
+	addr *gospel.Address,
+	events []gospel.Event,
+) error {
+	for _, ev := range events {
+		md, err := metadata.Marshal(ev.Metadata)
+		if err != nil {
+			return err
+		}
+
+		row := tx.QueryRowContext(
+			ctx,
+			`SELECT append_unchecked($1, $2, $3, $4, $5, $6, $7)`,
+			storeID,
+			addr.Stream,
+			ev.EventType,
+			ev.ContentType,
+			ev.Body,
+			md,
+			eventUID(ev),
+		)
+
+		if err := row.Scan(&addr.Offset); err != nil {
+			return err
+		}
+	}
+
+	addr.Offset++
+
+	return nil
+}
+
+// eventUID returns the value to store in the uid column for ev, which is NULL
+// if the event does not have an ID.
+func eventUID(ev gospel.Event) sql.NullString {
+	return sql.NullString{
+		String: ev.ID,
+		Valid:  ev.ID != "",
+	}
+}
@@ -0,0 +1,24 @@
+// +build !without_postgres
+
+package gospelpg_test
+
+import (
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/gospeltest"
+)
+
+var (
+	_ = gospeltest.DescribeEventStore(newConformanceStore)
+	_ = gospeltest.DescribeReader(newConformanceStore)
+)
+
+// newConformanceStore is a gospeltest.Factory that returns an EventStore that
+// uses the test DSN.
+func newConformanceStore() (gospel.EventStore, func()) {
+	c, es := getTestStore()
+
+	return es, func() {
+		c.Close()
+		destroyTestSchema()
+	}
+}
@@ -0,0 +1,24 @@
+package gospelpg
+
+import (
+	"github.com/lib/pq"
+)
+
+const (
+	pgSerializationFailure = "40001" // https://www.postgresql.org/docs/current/static/errcodes-appendix.html
+	pgDeadlockDetected     = "40P01"
+	pgUniqueViolation      = "23505"
+)
+
+// isRetryable returns true if err represents a PostgreSQL deadlock or
+// serialization failure, in which case the transaction can be retried.
+//
+// Unique violations are also retryable, as they occur when an event with the
+// same ID is appended by a concurrent transaction. Once retried, the append is
+// identified as a repeat of the concurrent append.
+func isRetryable(err error) bool {
+	e, ok := err.(*pq.Error)
+	return ok && (e.Code == pgSerializationFailure ||
+		e.Code == pgDeadlockDetected ||
+		e.Code == pgUniqueViolation)
+}
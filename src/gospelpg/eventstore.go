@@ -0,0 +1,284 @@
+package gospelpg
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/internal/logging"
+	"github.com/jmalloc/gospel/src/internal/options"
+	"github.com/jmalloc/twelf/src/twelf"
+	"golang.org/x/time/rate"
+)
+
+// EventStore an interface for reading and writing streams of events stored in
+// a PostgreSQL database.
+type EventStore struct {
+	// db is the pool of PostgreSQL connections used by the event stores and the
+	// readers it creates.
+	db *sql.DB
+
+	// id and store are the auto-increment ID and name of the store, respectively.
+	id    uint64
+	store string
+
+	// listener is used to wake readers when new facts are appended.
+	listener *listener
+
+	// rlimit is a rate-limiter that limits the number of polling queries that
+	// can be performed each second. It is shared by all readers, and hence
+	// provides a global cap of the number of read queries per second.
+	rlimit *rate.Limiter
+
+	// logger is the logger to use for activity and debug logging.
+	logger twelf.Logger
+}
+
+// Append atomically writes one or more events to the end of a stream,
+// producing a contiguous block of facts.
+//
+// addr.Offset must refer to the next unused offset within the stream,
+// otherwise the append fails, and IsConflict(err) returns true.
+//
+// nx is the address of the next unused offset after the facts have been
+// appended.
+//
+// Append panics if ev is empty.
+func (es *EventStore) Append(
+	ctx context.Context,
+	addr gospel.Address,
+	ev ...gospel.Event,
+) (gospel.Address, error) {
+	err := es.append(ctx, &addr, ev, appendChecked)
+
+	if err == nil {
+		logging.AppendChecked(
+			es.logger,
+			gospel.Address{
+				Stream: es.store + "::" + addr.Stream,
+				Offset: addr.Offset,
+			},
+			ev,
+		)
+	} else if e, ok := err.(gospel.ConflictError); ok {
+		logging.Conflict(es.logger, e)
+	}
+
+	return addr, err
+}
+
+// AppendUnchecked atomically writes one or more events to the end of a
+// stream, producing a contiguous block of facts.
+//
+// Unlike Append(), the caller is not required to know the next unused
+// offset of the stream, hence the offset is said to be "unchecked".
+//
+// nx is the address of the next unused offset after the facts have been
+// appended.
+//
+// AppendUnchecked panics if ev is empty.
+func (es *EventStore) AppendUnchecked(
+	ctx context.Context,
+	stream string,
+	ev ...gospel.Event,
+) (gospel.Address, error) {
+	addr := gospel.Address{Stream: stream}
+	err := es.append(ctx, &addr, ev, appendUnchecked)
+
+	if err == nil {
+		logging.AppendUnchecked(
+			es.logger,
+			gospel.Address{
+				Stream: es.store + "::" + addr.Stream,
+				Offset: addr.Offset,
+			},
+			ev,
+		)
+	}
+
+	return addr, err
+}
+
+// AppendExpected atomically writes one or more events to the end of a
+// stream, producing a contiguous block of facts.
+//
+// The stream must satisfy the expectation e, otherwise the append fails,
+// and IsConflict(err) returns true.
+//
+// nx is the address of the next unused offset after the facts have been
+// appended.
+//
+// AppendExpected panics if ev is empty.
+func (es *EventStore) AppendExpected(
+	ctx context.Context,
+	stream string,
+	e gospel.Expectation,
+	ev ...gospel.Event,
+) (gospel.Address, error) {
+	addr := gospel.Address{Stream: stream}
+	err := es.append(ctx, &addr, ev, appendExpected(e))
+
+	if err == nil {
+		logging.AppendExpected(
+			es.logger,
+			gospel.Address{
+				Stream: es.store + "::" + addr.Stream,
+				Offset: addr.Offset,
+			},
+			e,
+			ev,
+		)
+	} else if c, ok := err.(gospel.ConflictError); ok {
+		logging.Conflict(es.logger, c)
+	}
+
+	return addr, err
+}
+
+// AppendMulti atomically writes events to one or more streams. Either all
+// of the events are appended, or none of them are.
+//
+// Each stream must satisfy the expectation in its StreamAppend, otherwise the
+// append fails, and IsConflict(err) returns true. The address returned by the
+// error's ConflictDetails() method identifies the stream that conflicted.
+//
+// nx contains the address of the next unused offset of each stream after its
+// facts have been appended, in the same order as a.
+//
+// AppendMulti panics if a is empty, if any element of a has no events, or if
+// any element of a refers to the ε-stream.
+func (es *EventStore) AppendMulti(
+	ctx context.Context,
+	a ...gospel.StreamAppend,
+) ([]gospel.Address, error) {
+	nx, err := es.appendMulti(ctx, a)
+
+	if err == nil {
+		for i, x := range a {
+			logging.AppendExpected(
+				es.logger,
+				gospel.Address{
+					Stream: es.store + "::" + nx[i].Stream,
+					Offset: nx[i].Offset,
+				},
+				x.Expectation,
+				x.Events,
+			)
+		}
+	} else if c, ok := err.(gospel.ConflictError); ok {
+		logging.Conflict(es.logger, c)
+	}
+
+	return nx, err
+}
+
+// Open returns a reader that begins reading facts at addr.
+//
+// ctx applies to the opening of the reader, and not to the reader itself.
+func (es *EventStore) Open(
+	ctx context.Context,
+	addr gospel.Address,
+	opts ...gospel.ReaderOption,
+) (gospel.Reader, error) {
+	return openReader(
+		ctx,
+		es.db,
+		es.id,
+		addr,
+		es.listener,
+		es.rlimit,
+		es.logger,
+		options.NewReaderOptions(opts),
+	)
+}
+
+// StreamInfo returns information about the current state of a stream,
+// including the next unused offset.
+//
+// It does not return an error if the stream does not exist, instead
+// info.Exists is false.
+func (es *EventStore) StreamInfo(
+	ctx context.Context,
+	stream string,
+) (gospel.StreamInfo, error) {
+	return queryStreamInfo(ctx, es.db, es.id, stream)
+}
+
+// append writes events to a stream using the given append strategy.
+//
+// If a deadlock or serialization failure occurs the append is retried. There is no limit on the retries other than
+// the context deadline.
+func (es *EventStore) append(
+	ctx context.Context,
+	addr *gospel.Address,
+	events []gospel.Event,
+	strategy appendStrategy,
+) error {
+	if addr.Stream == "" {
+		panic("can not append to the ε-stream")
+	}
+
+	count := len(events)
+
+	if count == 0 {
+		panic("no events provided")
+	}
+
+	for {
+		err := atomicAppend(
+			ctx,
+			es.db,
+			es.id,
+			addr,
+			events,
+			strategy,
+		)
+
+		if !isRetryable(err) {
+			return err
+		}
+	}
+}
+
+// appendMulti writes events to several streams in a single transaction.
+//
+// Deadlocks and serialization failures are retried in the same way as for
+// append().
+func (es *EventStore) appendMulti(
+	ctx context.Context,
+	appends []gospel.StreamAppend,
+) ([]gospel.Address, error) {
+	if len(appends) == 0 {
+		panic("no appends provided")
+	}
+
+	for _, a := range appends {
+		if a.Stream == "" {
+			panic("can not append to the ε-stream")
+		}
+
+		if len(a.Events) == 0 {
+			panic("no events provided")
+		}
+	}
+
+	addrs := make([]gospel.Address, len(appends))
+
+	for {
+		err := atomicAppendMulti(
+			ctx,
+			es.db,
+			es.id,
+			appends,
+			addrs,
+		)
+
+		if err == nil {
+			return addrs, nil
+		}
+
+		if !isRetryable(err) {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// +build !without_postgres
+
+package gospelpg_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/gospelpg"
+	"github.com/jmalloc/twelf/src/twelf"
+)
+
+// getTestClient returns a Client that uses the test DSN.
+func getTestClient() *gospelpg.Client {
+	c, err := gospelpg.OpenEnv(
+		gospel.Logger(
+			&twelf.StandardLogger{
+				CaptureDebug: true,
+			},
+		),
+	)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// getTestStore returns an EventStore that uses the test DSN.
+func getTestStore() (*gospelpg.Client, *gospelpg.EventStore) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	c := getTestClient()
+	es, err := c.OpenStore(ctx, "test")
+	if err != nil {
+		c.Close()
+		panic(err)
+	}
+
+	return c, es
+}
+
+// getTestDB returns a database pool that uses the test DSN, for use by tests
+// that need to access the database directly.
+func getTestDB() *sql.DB {
+	dsn := os.Getenv("GOSPEL_POSTGRES_DSN")
+	if dsn == "" {
+		dsn = gospelpg.DefaultDSN
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		panic(err)
+	}
+
+	return db
+}
+
+// destroyTestSchema removes all tables and functions from the the database
+// specified by the test DSN.
+func destroyTestSchema() {
+	dsn := os.Getenv("GOSPEL_POSTGRES_DSN")
+	if dsn == "" {
+		dsn = gospelpg.DefaultDSN
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`DROP SCHEMA IF EXISTS public CASCADE; CREATE SCHEMA public`)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,166 @@
+package gospelpg
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmalloc/gospel/src/internal/notify"
+	"github.com/jmalloc/twelf/src/twelf"
+	"github.com/lib/pq"
+)
+
+const (
+	// notifyChannel is the PostgreSQL notification channel on which the
+	// record_fact() function announces new facts.
+	notifyChannel = "gospel_fact"
+
+	// listenerPingInterval is the interval at which the listener's connection
+	// is checked if no notifications have been received.
+	listenerPingInterval = 90 * time.Second
+)
+
+// streamKey is the key used to identify a stream when waiting on a notify.Hub.
+type streamKey struct {
+	storeID uint64
+	stream  string
+}
+
+// listener receives notifications about new facts from PostgreSQL and wakes the
+// readers that are waiting on the affected streams.
+type listener struct {
+	// conn is the dedicated PostgreSQL connection used to LISTEN for
+	// notifications.
+	conn *pq.Listener
+
+	// hub is used to wake readers that are waiting for new facts. Readers wait
+	// on a streamKey.
+	hub notify.Hub
+
+	// connected is non-zero when the listener is connected and listening. While
+	// it is zero, readers can not rely on being notified of new facts.
+	connected int32
+
+	// logger is the target for debug logging.
+	logger twelf.Logger
+
+	// done is a signaling channel that is closed when the listener's worker
+	// goroutine has returned.
+	done chan struct{}
+}
+
+// newListener returns a new listener that connects to the server at dsn.
+func newListener(dsn string, logger twelf.Logger) (*listener, error) {
+	l := &listener{
+		logger: logger,
+		done:   make(chan struct{}),
+	}
+
+	l.conn = pq.NewListener(
+		dsn,
+		100*time.Millisecond,
+		10*time.Second,
+		l.handleEvent,
+	)
+
+	if err := l.conn.Listen(notifyChannel); err != nil {
+		l.conn.Close()
+		return nil, err
+	}
+
+	go l.run()
+
+	return l, nil
+}
+
+// Wait returns a channel that is closed when a fact is appended to the given
+// stream, or when notifications may have been lost. release must be called
+// once the caller stops waiting.
+func (l *listener) Wait(storeID uint64, stream string) (ch <-chan struct{}, release func()) {
+	return l.hub.Wait(streamKey{storeID, stream})
+}
+
+// IsConnected returns true if the listener is currently able to receive
+// notifications.
+func (l *listener) IsConnected() bool {
+	return atomic.LoadInt32(&l.connected) != 0
+}
+
+// Close stops the listener and closes its connection.
+func (l *listener) Close() error {
+	err := l.conn.Close()
+	<-l.done
+	return err
+}
+
+// run dispatches notifications to waiting readers until the listener is
+// closed.
+func (l *listener) run() {
+	defer close(l.done)
+
+	// Wake any remaining readers so that they can observe that the listener
+	// is no longer available.
+	defer l.hub.NotifyAll()
+
+	ping := time.NewTicker(listenerPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case n, ok := <-l.conn.Notify:
+			if !ok {
+				return
+			}
+
+			// A nil notification is sent after the connection is
+			// re-established. Any notifications that were sent while it was
+			// down have been lost, so we wake every reader so that they poll.
+			if n == nil {
+				l.hub.NotifyAll()
+				continue
+			}
+
+			if k, ok := parsePayload(n.Extra); ok {
+				l.hub.Notify(k)
+			}
+
+		case <-ping.C:
+			go l.conn.Ping()
+		}
+	}
+}
+
+// handleEvent is called by the pq.Listener when the state of its connection
+// changes.
+func (l *listener) handleEvent(ev pq.ListenerEventType, err error) {
+	switch ev {
+	case pq.ListenerEventConnected, pq.ListenerEventReconnected:
+		atomic.StoreInt32(&l.connected, 1)
+		l.logger.Debug("listening for notifications on '%s'", notifyChannel)
+
+	case pq.ListenerEventDisconnected, pq.ListenerEventConnectionAttemptFailed:
+		atomic.StoreInt32(&l.connected, 0)
+		l.logger.Debug("not listening for notifications, readers will poll: %s", err)
+
+		// Wake all readers so that they begin polling at the faster rate.
+		l.hub.NotifyAll()
+	}
+}
+
+// parsePayload parses the payload of a notification sent by record_fact().
+//
+// The payload contains the store ID and the stream name, separated by a colon.
+func parsePayload(p string) (streamKey, bool) {
+	parts := strings.SplitN(p, ":", 2)
+	if len(parts) != 2 {
+		return streamKey{}, false
+	}
+
+	id, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return streamKey{}, false
+	}
+
+	return streamKey{id, parts[1]}, true
+}
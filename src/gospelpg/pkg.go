@@ -0,0 +1,6 @@
+// Package gospelpg is an implementation of the gospel public API that uses
+// PostgreSQL for storage.
+//
+// Readers are notified of new facts using PostgreSQL's LISTEN/NOTIFY feature,
+// and only poll the database when notifications may have been lost.
+package gospelpg
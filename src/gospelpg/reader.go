@@ -0,0 +1,728 @@
+package gospelpg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/internal/match"
+	"github.com/jmalloc/gospel/src/internal/metadata"
+	"github.com/jmalloc/gospel/src/internal/options"
+	"github.com/jmalloc/twelf/src/twelf"
+	"github.com/lib/pq"
+	"golang.org/x/time/rate"
+)
+
+// Reader is an interface for reading facts from a stream stored in PostgreSQL.
+type Reader struct {
+	// stmt is a prepared statement used to query for facts.
+	// It accepts the stream offset as a parameter.
+	stmt *sql.Stmt
+
+	// args are the arguments to stmt, excluding the stream offset.
+	args []interface{}
+
+	// storeID is the ID of the store that contains the stream.
+	storeID uint64
+
+	// listener is used to wait for notifications of new facts.
+	listener *listener
+
+	// logger is the target for debug logging. Readers do not perform general
+	// activity logging.
+	logger twelf.Logger
+
+	// facts is a channel on which facts are delivered to the caller of Next().
+	// A worker goroutine polls the database and delivers the facts to this
+	// channel.
+	facts chan gospel.Fact
+
+	// current is the fact returned by Get() until Next() is called again.
+	current *gospel.Fact
+
+	// next is the fact that will become "current" when Next() is called.
+	// If it is nil, no additional facts were available in the buffer on the
+	// previous call to Next().
+	next *gospel.Fact
+
+	// end is a signaling channel that is closed when the database polling
+	// goroutine fetches 0 facts.
+	end chan struct{}
+
+	// done is a signaling channel which is closed when the database polling
+	// goroutine returns. The error that caused the closure, if any, is sent to
+	// the channel before it closed. This means a pending call to Next() will
+	// return the error when it first occurs, but subsequent calls will return
+	// a more generic "reader is closed" error.
+	done chan error
+
+	// ctx is a context that is canceled when Close() is called, or when the
+	// database polling goroutine returns. It is used to abort any in-progress
+	// database queries or rate-limit pauses when the reader is closed.
+	//
+	// Context cancellation errors are not sent to the 'done' channel, so any
+	// pending Next() call will receive a generic "reader is closed" error.
+	ctx    context.Context
+	cancel func()
+
+	// addr is the starting address for the next database poll.
+	addr gospel.Address
+
+	// bounded is true if the reader ends once it reaches the offset in stop,
+	// as per the StopAt() and StopAtHead() options.
+	bounded bool
+	stop    uint64
+
+	// headStmt is a prepared statement used to query for the next unused
+	// offset of the stream. It is only used by forward readers that are
+	// bounded, and is nil otherwise.
+	headStmt *sql.Stmt
+
+	// head is the next unused offset of the stream, as at the most recent
+	// query using headStmt.
+	head uint64
+
+	// globalLimit is a rate-limiter that limits the number of polling queries
+	// that can be performed each second. It is shared by all readers, and hence
+	// provides a global cap of the number of read queries per second.
+	globalLimit *rate.Limiter
+
+	// pollInterval is the interval at which the reader polls for new facts
+	// while notifications are unavailable.
+	pollInterval time.Duration
+
+	// idlePollInterval is the interval at which the reader polls for new facts
+	// while notifications are available.
+	idlePollInterval time.Duration
+
+	// opts is the options specified when opening the reader.
+	opts *options.ReaderOptions
+}
+
+// errReaderClosed is an error returned by Next() when it is called on a closed
+// reader, or when the reader is closed while a call to Next() is pending.
+var errReaderClosed = errors.New("reader is closed")
+
+// openReader returns a new reader that begins at addr.
+func openReader(
+	ctx context.Context,
+	db *sql.DB,
+	storeID uint64,
+	addr gospel.Address,
+	l *listener,
+	limit *rate.Limiter,
+	logger twelf.Logger,
+	opts *options.ReaderOptions,
+) (*Reader, error) {
+	// Note that runCtx is NOT derived from ctx, which is only used for the
+	// opening of the reader itself.
+	runCtx, cancel := context.WithCancel(context.Background())
+
+	r := &Reader{
+		storeID:          storeID,
+		listener:         l,
+		logger:           logger,
+		facts:            make(chan gospel.Fact, getReadBufferSize(opts)),
+		end:              make(chan struct{}),
+		done:             make(chan error, 1),
+		ctx:              runCtx,
+		cancel:           cancel,
+		addr:             addr,
+		globalLimit:      limit,
+		pollInterval:     getPollInterval(opts),
+		idlePollInterval: getIdlePollInterval(opts),
+		opts:             opts,
+	}
+
+	if err := r.prepareBound(ctx, db, storeID, opts); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if opts.FilterByTime && !opts.Reverse {
+		if err := r.seek(ctx, db, opts.FromTime); err != nil {
+			if r.headStmt != nil {
+				r.headStmt.Close()
+			}
+
+			cancel()
+			return nil, err
+		}
+	}
+
+	if err := r.prepareStatement(ctx, db, storeID, opts); err != nil {
+		if r.headStmt != nil {
+			r.headStmt.Close()
+		}
+
+		cancel()
+		return nil, err
+	}
+
+	r.logInitialization()
+
+	go r.run()
+
+	return r, nil
+}
+
+// Next blocks until a fact is available for reading or ctx is canceled.
+//
+// If err is nil, the "current" fact is ready to be returned by Get().
+//
+// nx is the offset within the stream that the reader has reached. It can be
+// used to efficiently resume reading in a future call to EventStore.Open().
+//
+// Note that nx is not always the address immediately following the fact
+// returned by Get() - it may be "further ahead" in the stream, this skipping
+// over any facts that the reader is not interested in.
+func (r *Reader) Next(ctx context.Context) (nx gospel.Address, err error) {
+	nx, _, err = r.tryNext(ctx, nil)
+	return nx, err
+}
+
+// TryNext blocks until the next fact is available for reading, the end of
+// stream is reached, or ctx is canceled.
+//
+// If ok is true, a new fact is available and is ready to be returned by
+// Get(). ok is false if the current fact is the last known fact in the
+// stream.
+//
+// nx is the offset within the stream that the reader has reached. It can be
+// used to efficiently resume reading in a future call to EventStore.Open().
+// nx is invalid if ok is false.
+func (r *Reader) TryNext(ctx context.Context) (nx gospel.Address, ok bool, err error) {
+	return r.tryNext(ctx, r.end)
+}
+
+func (r *Reader) tryNext(ctx context.Context, end <-chan struct{}) (nx gospel.Address, ok bool, err error) {
+	if r.next == nil {
+		select {
+		case f, open := <-r.facts:
+			if !open {
+				err = gospel.ErrEndOfRange
+				return
+			}
+
+			r.current = &f
+			ok = true
+		case <-end:
+			// no fact is available, return with ok == false
+			return
+		case <-ctx.Done():
+			err = ctx.Err()
+			return
+		case err = <-r.done:
+			if err == nil {
+				err = errReaderClosed
+			}
+			return
+		}
+	} else {
+		r.current = r.next
+		r.next = nil
+		ok = true
+	}
+
+	// Perform a non-blocking lookahead to see if we have the next fact already.
+	select {
+	case f, open := <-r.facts:
+		if !open {
+			// the reader has reached the end of its range
+			nx = gospel.Address{Stream: r.current.Addr.Stream}
+			if r.bounded {
+				nx.Offset = r.stop
+			}
+		} else if r.opts.Reverse {
+			r.next = &f
+			nx = r.next.Addr.Next()
+		} else {
+			r.next = &f
+			nx = r.next.Addr
+		}
+	default:
+		if r.opts.Reverse {
+			// assume next is literally the previous fact on the stream
+			nx = r.current.Addr
+		} else {
+			// assume next is literally the next fact on the stream
+			nx = r.current.Addr.Next()
+		}
+	}
+
+	return
+}
+
+// Get returns the "current" fact.
+//
+// It panics if Next() has not been called.
+// Get() returns the same Fact until Next() is called again.
+func (r *Reader) Get() gospel.Fact {
+	if r.current == nil {
+		panic("Next() must be called before calling Get()")
+	}
+
+	return *r.current
+}
+
+// Close closes the reader.
+func (r *Reader) Close() error {
+	select {
+	case err := <-r.done:
+		return err
+	default:
+		r.cancel()
+		return <-r.done
+	}
+}
+
+// prepareBound sets the offset at which the reader ends, if any.
+//
+// Forward readers that are bounded use r.headStmt to determine when all of the
+// facts before the stop offset have been appended, so it is prepared here. If
+// the StopAtHead() option is used the head of the stream is queried
+// immediately.
+func (r *Reader) prepareBound(
+	ctx context.Context,
+	db *sql.DB,
+	storeID uint64,
+	opts *options.ReaderOptions,
+) error {
+	r.bounded = opts.StopAtOffset
+	r.stop = opts.StopOffset
+
+	if opts.Reverse || !(opts.StopAtOffset || opts.StopAtHead) {
+		return nil
+	}
+
+	stmt, err := db.PrepareContext(
+		ctx,
+		`SELECT next FROM stream WHERE store_id = $1 AND name = $2`,
+	)
+	if err != nil {
+		return err
+	}
+
+	r.headStmt = stmt
+
+	if opts.StopAtHead {
+		if err := r.queryHead(ctx); err != nil {
+			stmt.Close()
+			return err
+		}
+
+		if !r.bounded || r.head < r.stop {
+			r.bounded = true
+			r.stop = r.head
+		}
+	}
+
+	return nil
+}
+
+// seek advances r.addr to the lowest offset of any fact on the stream that
+// occurred at or after t, or to the head of the stream if there is no such
+// fact, so that the facts before it are never read.
+//
+// Fact times do not necessarily increase with the offset, so the reader still
+// filters out any facts after that offset that occurred before t.
+func (r *Reader) seek(ctx context.Context, db *sql.DB, t time.Time) error {
+	var offset uint64
+
+	if err := db.QueryRowContext(
+		ctx,
+		`SELECT COALESCE(
+			(
+				SELECT MIN(f."offset")
+				FROM fact AS f
+				WHERE f.store_id = $1
+					AND f.stream = $2
+					AND f.time >= $3
+			),
+			(
+				SELECT s.next
+				FROM stream AS s
+				WHERE s.store_id = $1
+					AND s.name = $2
+			),
+			0
+		)`,
+		r.storeID,
+		r.addr.Stream,
+		t,
+	).Scan(&offset); err != nil {
+		return err
+	}
+
+	if offset > r.addr.Offset {
+		r.addr.Offset = offset
+	}
+
+	return nil
+}
+
+// queryHead updates r.head to the next unused offset of the stream.
+func (r *Reader) queryHead(ctx context.Context) error {
+	row := r.headStmt.QueryRowContext(ctx, r.storeID, r.addr.Stream)
+
+	if err := row.Scan(&r.head); err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	return nil
+}
+
+// prepareStatement creates r.stmt, an SQL prepared statement used to poll
+// for new facts.
+func (r *Reader) prepareStatement(
+	ctx context.Context,
+	db *sql.DB,
+	storeID uint64,
+	opts *options.ReaderOptions,
+) error {
+	r.args = []interface{}{
+		storeID,
+		r.addr.Stream,
+	}
+
+	filter := ""
+	if opts.FilterByEventType {
+		filter = `AND ` + r.typeFilter(
+			`e.event_type`,
+			opts.EventTypes,
+			opts.EventTypePrefixes,
+			opts.EventTypePatterns,
+		)
+	}
+
+	if len(opts.ExcludedEventTypes) != 0 {
+		r.args = append(r.args, pq.Array(opts.ExcludedEventTypes))
+		filter += fmt.Sprintf(` AND NOT (e.event_type = ANY($%d))`, len(r.args))
+	}
+
+	if opts.FilterByContentType {
+		filter += ` AND ` + r.typeFilter(
+			`e.content_type`,
+			opts.ContentTypes,
+			opts.ContentTypePrefixes,
+			nil,
+		)
+	}
+
+	if opts.FilterByTime {
+		r.args = append(r.args, opts.FromTime)
+		filter += fmt.Sprintf(` AND f.time >= $%d`, len(r.args))
+	}
+
+	// Facts on the ε-stream are joined to the same fact on the stream they
+	// were originally appended to, in order to populate the fact's origin and
+	// to apply the stream filter. Facts recorded by the store itself do not
+	// have an originating stream, so they are excluded by the stream filter.
+	origin := `f.stream, f."offset"`
+	join := ""
+
+	if r.addr.Stream == "" {
+		kind, cond := `LEFT`, ``
+		if opts.FilterByStream {
+			kind = `INNER`
+			cond = ` AND ` + r.typeFilter(
+				`o.stream`,
+				opts.Streams,
+				opts.StreamPrefixes,
+				opts.StreamPatterns,
+			)
+		}
+
+		origin = `COALESCE(o.stream, ''), COALESCE(o."offset", f."offset")`
+		join = kind + ` JOIN fact AS o
+			ON o.store_id = f.store_id
+			AND o.event_id = f.event_id
+			AND o.stream != ''` + cond
+	} else if opts.FilterByStream && !match.Stream(opts, r.addr.Stream) {
+		filter += ` AND (1 = 0)`
+	}
+
+	if r.bounded {
+		if opts.Reverse {
+			filter += fmt.Sprintf(` AND f."offset" >= %d`, r.stop)
+		} else {
+			filter += fmt.Sprintf(` AND f."offset" < %d`, r.stop)
+		}
+	}
+
+	bound, order := `>=`, `ASC`
+	if opts.Reverse {
+		bound, order = `<`, `DESC`
+	}
+
+	query := fmt.Sprintf(
+		`SELECT
+			f."offset",
+			f.time,
+			e.event_type,
+			e.content_type,
+			e.body,
+			e.metadata,
+			COALESCE(e.uid, ''),
+			%s
+		FROM fact AS f
+		INNER JOIN event AS e
+		ON e.id = f.event_id
+		%s
+		%s
+		WHERE f.store_id = $1
+			AND f.stream = $2
+			AND f."offset" %s $%d
+		ORDER BY f."offset" %s
+		LIMIT %d`,
+		origin,
+		filter,
+		join,
+		bound,
+		len(r.args)+1,
+		order,
+		cap(r.facts),
+	)
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	r.stmt = stmt
+
+	return nil
+}
+
+// typeFilter returns an SQL condition that matches rows where the value of
+// column is one of the values in exact, begins with one of the given prefixes,
+// or matches one of the given glob patterns. The values are added to r.args.
+func (r *Reader) typeFilter(column string, exact, prefixes, patterns []string) string {
+	var conds []string
+
+	if len(exact) != 0 {
+		r.args = append(r.args, pq.Array(exact))
+		conds = append(conds, fmt.Sprintf(`%s = ANY($%d)`, column, len(r.args)))
+	}
+
+	for _, p := range prefixes {
+		r.args = append(r.args, match.LikePrefix(p))
+		conds = append(conds, fmt.Sprintf(`%s LIKE $%d`, column, len(r.args)))
+	}
+
+	// Patterns are matched against the UTF-8 encoding of the value, as BYTEA,
+	// so that '?' matches a single byte, as it does in the other event store
+	// implementations, rather than a single character.
+	for _, p := range patterns {
+		r.args = append(r.args, []byte(match.Like(p)))
+		conds = append(conds, fmt.Sprintf(`convert_to(%s, 'UTF8') LIKE $%d`, column, len(r.args)))
+	}
+
+	if len(conds) == 0 {
+		return `(1 = 0)`
+	}
+
+	return `(` + strings.Join(conds, ` OR `) + `)`
+}
+
+// run polls the database for facts and sends them to r.facts until r.ctx is
+// canceled or an error occurs.
+func (r *Reader) run() {
+	defer r.cancel()
+	defer close(r.done)
+	defer r.stmt.Close()
+
+	if r.headStmt != nil {
+		defer r.headStmt.Close()
+	}
+
+	var err error
+
+	for err == nil {
+		err = r.tick()
+	}
+
+	if err == gospel.ErrEndOfRange {
+		// Closing r.facts causes Next() to return ErrEndOfRange once the
+		// buffered facts have been consumed. r.done is left open until the
+		// reader is closed.
+		close(r.facts)
+		<-r.ctx.Done()
+		return
+	}
+
+	if err != context.Canceled {
+		r.done <- err
+	}
+}
+
+// tick executes one pass of the worker goroutine.
+func (r *Reader) tick() error {
+	// Begin waiting for notifications before polling, so that any facts
+	// appended during the poll are not missed.
+	wait, release := r.listener.Wait(r.storeID, r.addr.Stream)
+	defer release()
+
+	if err := r.globalLimit.Wait(r.ctx); err != nil {
+		return err
+	}
+
+	count, err := r.poll()
+	if err != nil {
+		return err
+	}
+
+	// If the poll filled the buffer there are likely more facts available
+	// already, so there's no need to wait for a notification.
+	if count == cap(r.facts) {
+		return nil
+	}
+
+	interval := r.idlePollInterval
+	if !r.listener.IsConnected() {
+		interval = r.pollInterval
+	}
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	select {
+	case <-wait:
+		return nil
+	case <-timer.C:
+		return nil
+	case <-r.ctx.Done():
+		return r.ctx.Err()
+	}
+}
+
+// poll queries the database for facts beginning at r.addr.
+func (r *Reader) poll() (int, error) {
+	// The head must be queried before polling for facts, otherwise facts
+	// appended between the two queries could be mistaken for facts that do
+	// not match the reader's filter.
+	if r.headStmt != nil && r.head < r.stop {
+		if err := r.queryHead(r.ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	rows, err := r.stmt.QueryContext(
+		r.ctx,
+		append(r.args, r.addr.Offset)...,
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	f := gospel.Fact{
+		Addr: r.addr,
+	}
+
+	var md []byte
+	count := 0
+
+	for rows.Next() {
+		if err := rows.Scan(
+			&f.Addr.Offset,
+			&f.Time,
+			&f.Event.EventType,
+			&f.Event.ContentType,
+			&f.Event.Body,
+			&md,
+			&f.Event.ID,
+			&f.Origin.Stream,
+			&f.Origin.Offset,
+		); err != nil {
+			return count, err
+		}
+
+		f.Event.Metadata, err = metadata.Unmarshal(md)
+		if err != nil {
+			return count, err
+		}
+
+		select {
+		case r.facts <- f:
+		case <-r.ctx.Done():
+			return count, r.ctx.Err()
+		}
+
+		if r.opts.Reverse {
+			r.addr = f.Addr
+		} else {
+			r.addr = f.Addr.Next()
+		}
+		count++
+	}
+
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+
+	if count < cap(r.facts) && r.exhausted() {
+		return count, gospel.ErrEndOfRange
+	}
+
+	if count == 0 {
+		select {
+		case r.end <- struct{}{}:
+		default:
+		}
+	}
+
+	r.logPoll(count)
+
+	return count, nil
+}
+
+// exhausted returns true if a poll that did not fill the buffer indicates
+// that the reader has read all of the facts in its range.
+func (r *Reader) exhausted() bool {
+	// A reverse reader never waits for new facts, so the beginning of the
+	// stream (or the stop offset) has been reached.
+	if r.opts.Reverse {
+		return true
+	}
+
+	return r.bounded && (r.head >= r.stop || r.addr.Offset >= r.stop)
+}
+
+// logInitialization logs a debug message describing the reader settings.
+func (r *Reader) logInitialization() {
+	if !r.logger.IsDebug() {
+		return
+	}
+
+	r.logger.Debug(
+		"[reader %p] %s | global poll limit: %.02f/s | poll interval: %s | idle poll interval: %s | read-buffer: %d | filter: %s",
+		r,
+		r.addr,
+		r.globalLimit.Limit(),
+		r.pollInterval,
+		r.idlePollInterval,
+		cap(r.facts),
+		match.Format(r.opts),
+	)
+}
+
+// logPoll logs a debug message containing metrics for the previous poll.
+func (r *Reader) logPoll(count int) {
+	if !r.logger.IsDebug() || count == 0 {
+		return
+	}
+
+	r.logger.Debug(
+		"[reader %p] %s | fetch: %3d | queue: %3d/%3d",
+		r,
+		r.addr,
+		count,
+		len(r.facts),
+		cap(r.facts),
+	)
+}
@@ -0,0 +1,109 @@
+// +build !without_postgres
+
+package gospelpg_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/jmalloc/gospel/src/gospelpg"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reader", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+
+		client *Client
+		store  *EventStore
+		reader gospel.Reader
+	)
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 3*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+
+		client, store = getTestStore()
+
+		_, err := store.AppendUnchecked(
+			ctx,
+			"test-stream",
+			gospel.Event{EventType: "event-type-1", Body: []byte("event-1")},
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		reader, err = store.Open(
+			ctx,
+			gospel.Address{Stream: "test-stream"},
+			IdlePollInterval(time.Hour), // ensure the reader relies on notifications
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		cancel()
+		reader.Close()
+		client.Close()
+		destroyTestSchema()
+	})
+
+	Describe("Next", func() {
+		It("is woken by a notification when a fact is appended", func() {
+			_, err := reader.Next(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			go func() {
+				defer GinkgoRecover()
+
+				time.Sleep(50 * time.Millisecond)
+
+				_, err := store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{EventType: "event-type-2", Body: []byte("event-2")},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+			}()
+
+			nextCtx, cancel := context.WithTimeout(ctx, 1*time.Second)
+			defer cancel()
+
+			_, err = reader.Next(nextCtx)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(reader.Get().Event.Body).To(Equal([]byte("event-2")))
+		})
+
+		It("is woken by a notification when a fact is appended by another client", func() {
+			_, err := reader.Next(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			other, otherStore := getTestStore()
+			defer other.Close()
+
+			_, err = otherStore.AppendUnchecked(
+				ctx,
+				"test-stream",
+				gospel.Event{EventType: "event-type-2", Body: []byte("event-2")},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			nextCtx, cancel := context.WithTimeout(ctx, 1*time.Second)
+			defer cancel()
+
+			_, err = reader.Next(nextCtx)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(reader.Get().Event.Body).To(Equal([]byte("event-2")))
+		})
+
+		It("returns an error if the reader is closed", func() {
+			reader.Close()
+
+			_, err := reader.Next(ctx)
+			Expect(err).To(MatchError("reader is closed"))
+		})
+	})
+})
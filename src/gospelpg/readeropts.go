@@ -0,0 +1,114 @@
+package gospelpg
+
+import (
+	"time"
+
+	"github.com/jmalloc/gospel/src/internal/options"
+)
+
+const (
+	// DefaultReadBufferSize is the default read-buffer size for each reader.
+	// It is used if no specific size is set via ReadBufferSize().
+	DefaultReadBufferSize = 100
+
+	// DefaultPollInterval is the default interval at which readers poll for new
+	// facts while notifications are unavailable. It is used if no specific
+	// value is set via PollInterval().
+	DefaultPollInterval = 200 * time.Millisecond
+
+	// DefaultIdlePollInterval is the default interval at which readers poll for
+	// new facts while notifications are available. It is used if no specific
+	// value is set via IdlePollInterval().
+	DefaultIdlePollInterval = 10 * time.Second
+)
+
+// readerOptionKey is a custom type used to ensure that PostgreSQL-specific
+// keys can not clash with custom options from other systems.
+type readerOptionKey int
+
+const (
+	readBufferKey readerOptionKey = iota
+	pollIntervalKey
+	idlePollIntervalKey
+)
+
+// ReadBufferSize is a reader option that sets the number of facts to buffer
+// in memory before a call to Next().
+//
+// The minimum read-buffer size is 2.
+func ReadBufferSize(n uint) options.ReaderOption {
+	if n < 2 {
+		n = 2
+	}
+
+	return func(o *options.ReaderOptions) {
+		o.Set(readBufferKey, n)
+	}
+}
+
+// getReadBufferSize returns the read-buffer size to use for the given reader
+// options, falling back to the default if necessary.
+func getReadBufferSize(o *options.ReaderOptions) uint {
+	if v, ok := o.Get(readBufferKey); ok {
+		return v.(uint)
+	}
+
+	return DefaultReadBufferSize
+}
+
+// PollInterval is a reader option that sets the interval at which the reader
+// polls for new facts while the client is unable to receive notifications,
+// such as when the listener connection has been lost.
+func PollInterval(d time.Duration) options.ReaderOption {
+	if d < 0 {
+		d = 0
+	}
+
+	return func(o *options.ReaderOptions) {
+		o.Set(pollIntervalKey, d)
+	}
+}
+
+// getPollInterval returns the poll interval to use for the given reader
+// options, falling back to the default if necessary.
+func getPollInterval(o *options.ReaderOptions) time.Duration {
+	if v, ok := o.Get(pollIntervalKey); ok {
+		return v.(time.Duration)
+	}
+
+	return DefaultPollInterval
+}
+
+// IdlePollInterval is a reader option that sets the interval at which the
+// reader polls for new facts while the client is receiving notifications.
+//
+// Polling is not strictly necessary while notifications are available, but it
+// guards against notifications that are lost without the client noticing.
+//
+// The setting is ignored if d is less than the poll interval.
+func IdlePollInterval(d time.Duration) options.ReaderOption {
+	if d < 0 {
+		d = 0
+	}
+
+	return func(o *options.ReaderOptions) {
+		o.Set(idlePollIntervalKey, d)
+	}
+}
+
+// getIdlePollInterval returns the idle poll interval to use for the given
+// reader options, falling back to the default if necessary.
+func getIdlePollInterval(o *options.ReaderOptions) time.Duration {
+	poll := getPollInterval(o)
+	idle := DefaultIdlePollInterval
+
+	if v, ok := o.Get(idlePollIntervalKey); ok {
+		idle = v.(time.Duration)
+	}
+
+	if idle < poll {
+		return poll
+	}
+
+	return idle
+}
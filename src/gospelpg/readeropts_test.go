@@ -0,0 +1,110 @@
+package gospelpg
+
+import (
+	"time"
+
+	"github.com/jmalloc/gospel/src/internal/options"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("read-buffer size option", func() {
+	Describe("ReadBufferSize", func() {
+		It("sets the buffer size", func() {
+			opts := &options.ReaderOptions{}
+
+			ReadBufferSize(10)(opts)
+
+			Expect(getReadBufferSize(opts)).To(
+				BeNumerically("==", 10),
+			)
+		})
+
+		It("caps the minimum size at 2", func() {
+			opts := &options.ReaderOptions{}
+			ReadBufferSize(1)(opts)
+
+			Expect(getReadBufferSize(opts)).To(
+				BeNumerically("==", 2),
+			)
+		})
+	})
+
+	Describe("getReadBufferSize", func() {
+		It("returns the default buffer size if none is set", func() {
+			opts := &options.ReaderOptions{}
+
+			Expect(getReadBufferSize(opts)).To(
+				BeNumerically("==", DefaultReadBufferSize),
+			)
+		})
+	})
+})
+
+var _ = Describe("poll interval option", func() {
+	Describe("PollInterval", func() {
+		It("sets the poll interval", func() {
+			opts := &options.ReaderOptions{}
+
+			PollInterval(10 * time.Second)(opts)
+
+			Expect(getPollInterval(opts)).To(
+				Equal(10 * time.Second),
+			)
+		})
+
+		It("caps the minimum at zero", func() {
+			opts := &options.ReaderOptions{}
+
+			PollInterval(-time.Second)(opts)
+
+			Expect(getPollInterval(opts)).To(
+				Equal(0 * time.Second),
+			)
+		})
+	})
+
+	Describe("getPollInterval", func() {
+		It("returns the default interval if none is set", func() {
+			opts := &options.ReaderOptions{}
+
+			Expect(getPollInterval(opts)).To(
+				Equal(DefaultPollInterval),
+			)
+		})
+	})
+})
+
+var _ = Describe("idle poll interval option", func() {
+	Describe("IdlePollInterval", func() {
+		It("sets the idle poll interval", func() {
+			opts := &options.ReaderOptions{}
+
+			IdlePollInterval(time.Minute)(opts)
+
+			Expect(getIdlePollInterval(opts)).To(
+				Equal(time.Minute),
+			)
+		})
+	})
+
+	Describe("getIdlePollInterval", func() {
+		It("returns the default interval if none is set", func() {
+			opts := &options.ReaderOptions{}
+
+			Expect(getIdlePollInterval(opts)).To(
+				Equal(DefaultIdlePollInterval),
+			)
+		})
+
+		It("returns the poll interval if it is greater than the idle poll interval", func() {
+			opts := &options.ReaderOptions{}
+			PollInterval(time.Minute)(opts)
+			IdlePollInterval(time.Second)(opts)
+
+			Expect(getIdlePollInterval(opts)).To(
+				Equal(time.Minute),
+			)
+		})
+	})
+})
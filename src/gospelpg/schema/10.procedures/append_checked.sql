@@ -0,0 +1,78 @@
+--
+-- append_checked records a new fact to a named stream and to the ε-stream.
+--
+-- It returns TRUE if the event is appended, or FALSE if there is a conflict.
+-- A conflict occurs when p_offset is not the next unused offset of p_stream.
+--
+CREATE OR REPLACE FUNCTION append_checked
+(
+    p_store_id     BIGINT,
+    p_stream       VARCHAR(255),
+    p_offset       BIGINT,
+    p_event_type   VARCHAR(255),
+    p_content_type VARCHAR(255),
+    p_body         BYTEA,
+    p_metadata     BYTEA,
+    p_uid          VARCHAR(255)
+)
+RETURNS BOOLEAN
+LANGUAGE plpgsql
+VOLATILE
+SECURITY DEFINER
+AS $$
+DECLARE
+    v_now      TIMESTAMPTZ := clock_timestamp();
+    v_event_id BIGINT;
+BEGIN
+    IF p_stream = '' THEN
+        RAISE EXCEPTION 'cannot append directly to the ε-stream';
+    END IF;
+
+    -- If p_offset = 0, we're attempting to create the stream.
+    IF p_offset = 0 THEN
+
+        INSERT INTO stream (store_id, name, next)
+        VALUES (p_store_id, p_stream, 1)
+        ON CONFLICT DO NOTHING;
+
+        IF NOT FOUND THEN
+            RETURN FALSE;
+        END IF;
+
+        PERFORM record_stream_created(v_now, p_store_id, p_stream);
+
+    -- Otherwise the stream must already exist at p_offset.
+    ELSE
+
+        UPDATE stream SET
+            next = p_offset + 1
+        WHERE store_id = p_store_id
+            AND name = p_stream
+            AND next = p_offset;
+
+        IF NOT FOUND THEN
+            RETURN FALSE;
+        END IF;
+
+    END IF;
+
+    -- Once we know our write will not conflict, we can store the event.
+    v_event_id := store_event(
+        v_now,
+        p_store_id,
+        p_event_type,
+        p_content_type,
+        p_body,
+        p_metadata,
+        p_uid
+    );
+
+    -- Record a fact on the ε-stream.
+    PERFORM record_epsilon(v_now, p_store_id, v_event_id);
+
+    -- Record a fact on the named stream.
+    PERFORM record_fact(v_now, p_store_id, p_stream, p_offset, v_event_id);
+
+    RETURN TRUE;
+END;
+$$;
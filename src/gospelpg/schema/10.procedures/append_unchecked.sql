@@ -0,0 +1,60 @@
+--
+-- append_unchecked records a new fact to a named stream and to the ε-stream.
+--
+CREATE OR REPLACE FUNCTION append_unchecked
+(
+    p_store_id     BIGINT,
+    p_stream       VARCHAR(255),
+    p_event_type   VARCHAR(255),
+    p_content_type VARCHAR(255),
+    p_body         BYTEA,
+    p_metadata     BYTEA,
+    p_uid          VARCHAR(255)
+)
+RETURNS BIGINT
+LANGUAGE plpgsql
+VOLATILE
+SECURITY DEFINER
+AS $$
+DECLARE
+    v_now      TIMESTAMPTZ := clock_timestamp();
+    v_event_id BIGINT;
+    v_offset   BIGINT;
+BEGIN
+    IF p_stream = '' THEN
+        RAISE EXCEPTION 'cannot append directly to the ε-stream';
+    END IF;
+
+    -- Record the event first, defering the locking of the stream table as late
+    -- as possible.
+    v_event_id := store_event(
+        v_now,
+        p_store_id,
+        p_event_type,
+        p_content_type,
+        p_body,
+        p_metadata,
+        p_uid
+    );
+
+    -- We don't care what the offset is now, we just want to find it so we can
+    -- add our fact. Our offset is whatever we set the next offset to minus 1.
+    INSERT INTO stream AS s (store_id, name, next)
+    VALUES (p_store_id, p_stream, 1)
+    ON CONFLICT (store_id, name) DO UPDATE SET
+        next = s.next + 1
+    RETURNING s.next - 1 INTO v_offset;
+
+    IF v_offset = 0 THEN
+        PERFORM record_stream_created(v_now, p_store_id, p_stream);
+    END IF;
+
+    -- Record our fact on the ε-stream.
+    PERFORM record_epsilon(v_now, p_store_id, v_event_id);
+
+    -- Record our fact on the named stream.
+    PERFORM record_fact(v_now, p_store_id, p_stream, v_offset, v_event_id);
+
+    RETURN v_offset;
+END;
+$$;
@@ -0,0 +1,40 @@
+--
+-- open_store returns the ID of the store named p_store, creating it if it does
+-- not already exist.
+--
+CREATE OR REPLACE FUNCTION open_store
+(
+    p_store VARCHAR(255)
+)
+RETURNS BIGINT
+LANGUAGE plpgsql
+VOLATILE
+SECURITY DEFINER
+AS $$
+DECLARE
+    v_now      TIMESTAMPTZ := clock_timestamp();
+    v_store_id BIGINT;
+BEGIN
+    INSERT INTO store (name)
+    VALUES (p_store)
+    ON CONFLICT DO NOTHING
+    RETURNING id INTO v_store_id;
+
+    -- Create the ε-stream and record an event about store creation.
+    IF v_store_id IS NOT NULL THEN
+        INSERT INTO stream (store_id, name, next)
+        VALUES (v_store_id, '', 0);
+
+        PERFORM record_store_created(v_now, v_store_id, p_store);
+
+        RETURN v_store_id;
+    END IF;
+
+    SELECT id
+        INTO v_store_id
+        FROM store
+    WHERE name = p_store;
+
+    RETURN v_store_id;
+END;
+$$;
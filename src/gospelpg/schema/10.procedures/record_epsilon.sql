@@ -0,0 +1,38 @@
+--
+-- record_epsilon unconditionally records a fact to the ε-stream.
+--
+-- This function is an implementation detail and should not be called by clients.
+--
+CREATE OR REPLACE FUNCTION record_epsilon
+(
+    p_now      TIMESTAMPTZ,
+    p_store_id BIGINT,
+    p_event_id BIGINT
+)
+RETURNS VOID
+LANGUAGE plpgsql
+VOLATILE
+SECURITY DEFINER
+AS $$
+DECLARE
+    v_offset BIGINT;
+BEGIN
+    SELECT next
+        INTO v_offset
+        FROM stream
+    WHERE store_id = p_store_id
+        AND name = ''
+        FOR UPDATE;
+
+    IF v_offset IS NULL THEN
+        RAISE EXCEPTION 'ε-stream does not exist';
+    END IF;
+
+    PERFORM record_fact(p_now, p_store_id, '', v_offset, p_event_id);
+
+    UPDATE stream SET
+        next = v_offset + 1
+    WHERE store_id = p_store_id
+        AND name = '';
+END;
+$$;
@@ -0,0 +1,30 @@
+--
+-- record_fact unconditionally inserts a fact and notifies any listening
+-- readers that it is available.
+--
+-- Notifications are sent on the 'gospel_fact' channel. The payload is the
+-- store ID and the stream name, separated by a colon. Notifications are only
+-- delivered once the transaction that appended the fact is committed.
+--
+-- This function is an implementation detail and should not be called by clients.
+--
+CREATE OR REPLACE FUNCTION record_fact
+(
+    p_now      TIMESTAMPTZ,
+    p_store_id BIGINT,
+    p_stream   VARCHAR(255),
+    p_offset   BIGINT,
+    p_event_id BIGINT
+)
+RETURNS VOID
+LANGUAGE plpgsql
+VOLATILE
+SECURITY DEFINER
+AS $$
+BEGIN
+    INSERT INTO fact (store_id, stream, "offset", event_id, time)
+    VALUES (p_store_id, p_stream, p_offset, p_event_id, p_now);
+
+    PERFORM pg_notify('gospel_fact', p_store_id || ':' || p_stream);
+END;
+$$;
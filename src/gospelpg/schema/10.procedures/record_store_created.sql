@@ -0,0 +1,33 @@
+--
+-- record_store_created records a fact to the ε-stream about a new store being
+-- created.
+--
+-- This function is an implementation detail and should not be called by clients.
+--
+CREATE OR REPLACE FUNCTION record_store_created
+(
+    p_now      TIMESTAMPTZ,
+    p_store_id BIGINT,
+    p_store    VARCHAR(255)
+)
+RETURNS VOID
+LANGUAGE plpgsql
+VOLATILE
+SECURITY DEFINER
+AS $$
+BEGIN
+    PERFORM record_epsilon(
+        p_now,
+        p_store_id,
+        store_event(
+            p_now,
+            p_store_id,
+            '$store.created',
+            'application/vnd.gospel.store.created.v1',
+            convert_to(p_store, 'UTF8'),
+            NULL,
+            NULL
+        )
+    );
+END;
+$$;
@@ -0,0 +1,33 @@
+--
+-- record_stream_created records a fact to the ε-stream about a new named stream
+-- being created.
+--
+-- This function is an implementation detail and should not be called by clients.
+--
+CREATE OR REPLACE FUNCTION record_stream_created
+(
+    p_now      TIMESTAMPTZ,
+    p_store_id BIGINT,
+    p_stream   VARCHAR(255)
+)
+RETURNS VOID
+LANGUAGE plpgsql
+VOLATILE
+SECURITY DEFINER
+AS $$
+BEGIN
+    PERFORM record_epsilon(
+        p_now,
+        p_store_id,
+        store_event(
+            p_now,
+            p_store_id,
+            '$stream.created',
+            'application/vnd.gospel.stream.created.v1',
+            convert_to(p_stream, 'UTF8'),
+            NULL,
+            NULL
+        )
+    );
+END;
+$$;
@@ -0,0 +1,30 @@
+--
+-- store_event inserts an event and returns its ID.
+--
+-- This function is an implementation detail and should not be called by clients.
+--
+CREATE OR REPLACE FUNCTION store_event
+(
+    p_now          TIMESTAMPTZ,
+    p_store_id     BIGINT,
+    p_event_type   VARCHAR(255),
+    p_content_type VARCHAR(255),
+    p_body         BYTEA,
+    p_metadata     BYTEA,
+    p_uid          VARCHAR(255)
+)
+RETURNS BIGINT
+LANGUAGE plpgsql
+VOLATILE
+SECURITY DEFINER
+AS $$
+DECLARE
+    v_event_id BIGINT;
+BEGIN
+    INSERT INTO event (time, store_id, event_type, content_type, body, metadata, uid)
+    VALUES (p_now, p_store_id, p_event_type, p_content_type, p_body, p_metadata, p_uid)
+    RETURNING id INTO v_event_id;
+
+    RETURN v_event_id;
+END;
+$$;
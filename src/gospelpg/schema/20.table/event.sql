@@ -0,0 +1,15 @@
+--
+-- event contains application-defined event data.
+--
+CREATE TABLE IF NOT EXISTS event
+(
+    id           BIGSERIAL PRIMARY KEY,
+    time         TIMESTAMPTZ NOT NULL,
+
+    store_id     BIGINT NOT NULL,
+    event_type   VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    body         BYTEA,
+    metadata     BYTEA, -- JSON object, NULL if the event has no metadata
+    uid          VARCHAR(255) -- application-defined ID, NULL if the event has no ID
+);
@@ -0,0 +1,23 @@
+--
+-- fact contains mappings of stream and offset to events.
+--
+-- Every event appended by the client appears on both the named stream it was
+-- originally appended to, as well as the ε-stream.
+--
+CREATE TABLE IF NOT EXISTS fact
+(
+    store_id BIGINT NOT NULL,
+    stream   VARCHAR(255) NOT NULL,
+    "offset" BIGINT NOT NULL,
+
+    event_id BIGINT NOT NULL,
+    time     TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (store_id, stream, "offset")
+);
+
+CREATE INDEX IF NOT EXISTS fact_event_id ON fact (event_id);
+
+-- Used to find the first fact at or after a given time, as per FromTime().
+-- The offset is included so that the lookup reads only the index.
+CREATE INDEX IF NOT EXISTS fact_time ON fact (store_id, stream, time, "offset");
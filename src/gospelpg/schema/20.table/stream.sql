@@ -0,0 +1,16 @@
+--
+-- stream is the authoratative source for the next offset of each stream. Its
+-- rows are locked to ensure consistency of concurrent append operations.
+--
+-- Note that stream names and offsets are de-normalized, they appear in the
+-- 'fact' table, rather than having a relation to this table. This mirrors the
+-- MariaDB schema, where 'fact' and 'event' are partitioned.
+--
+CREATE TABLE IF NOT EXISTS stream
+(
+    store_id BIGINT NOT NULL,
+    name     VARCHAR(255) NOT NULL,
+    next     BIGINT NOT NULL,
+
+    PRIMARY KEY (store_id, name)
+);
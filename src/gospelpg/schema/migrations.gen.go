@@ -0,0 +1,36 @@
+package schema
+
+var migrations = []string{
+	`--
+-- 01.event_metadata adds event metadata to schemas created before event
+-- metadata was supported.
+--
+-- CREATE OR REPLACE FUNCTION creates an overload when the argument list
+-- changes, so the functions that store events are dropped by their previous
+-- signatures. They are re-created with their current signatures when the rest
+-- of the schema is created, once all migrations have been applied.
+--
+ALTER TABLE event ADD COLUMN IF NOT EXISTS metadata BYTEA;
+
+DROP FUNCTION IF EXISTS append_checked(BIGINT, VARCHAR, BIGINT, VARCHAR, VARCHAR, BYTEA);
+DROP FUNCTION IF EXISTS append_expected(BIGINT, VARCHAR, INTEGER, BIGINT, VARCHAR, VARCHAR, BYTEA);
+DROP FUNCTION IF EXISTS append_unchecked(BIGINT, VARCHAR, VARCHAR, VARCHAR, BYTEA);
+DROP FUNCTION IF EXISTS store_event(TIMESTAMPTZ, BIGINT, VARCHAR, VARCHAR, BYTEA);
+`,
+	`--
+-- 02.event_uid adds application-defined event IDs to schemas created before
+-- event IDs were supported.
+--
+-- The 'event_uid' table did not previously exist, so it is created along with
+-- the rest of the schema once all migrations have been applied. The functions
+-- that store events were dropped by 01.event_metadata.
+--
+ALTER TABLE event ADD COLUMN IF NOT EXISTS uid VARCHAR(255);
+`,
+	`--
+-- 03.fact_time adds the index used to find the first fact at or after a given
+-- time to schemas created before readers could seek by time.
+--
+CREATE INDEX IF NOT EXISTS fact_time ON fact (store_id, stream, time, "offset");
+`,
+}
@@ -0,0 +1,15 @@
+--
+-- 01.event_metadata adds event metadata to schemas created before event
+-- metadata was supported.
+--
+-- CREATE OR REPLACE FUNCTION creates an overload when the argument list
+-- changes, so the functions that store events are dropped by their previous
+-- signatures. They are re-created with their current signatures when the rest
+-- of the schema is created, once all migrations have been applied.
+--
+ALTER TABLE event ADD COLUMN IF NOT EXISTS metadata BYTEA;
+
+DROP FUNCTION IF EXISTS append_checked(BIGINT, VARCHAR, BIGINT, VARCHAR, VARCHAR, BYTEA);
+DROP FUNCTION IF EXISTS append_expected(BIGINT, VARCHAR, INTEGER, BIGINT, VARCHAR, VARCHAR, BYTEA);
+DROP FUNCTION IF EXISTS append_unchecked(BIGINT, VARCHAR, VARCHAR, VARCHAR, BYTEA);
+DROP FUNCTION IF EXISTS store_event(TIMESTAMPTZ, BIGINT, VARCHAR, VARCHAR, BYTEA);
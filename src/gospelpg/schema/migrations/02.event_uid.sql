@@ -0,0 +1,9 @@
+--
+-- 02.event_uid adds application-defined event IDs to schemas created before
+-- event IDs were supported.
+--
+-- The 'event_uid' table did not previously exist, so it is created along with
+-- the rest of the schema once all migrations have been applied. The functions
+-- that store events were dropped by 01.event_metadata.
+--
+ALTER TABLE event ADD COLUMN IF NOT EXISTS uid VARCHAR(255);
@@ -0,0 +1,516 @@
+package schema
+
+var statements = `
+--
+-- append_checked records a new fact to a named stream and to the ε-stream.
+--
+-- It returns TRUE if the event is appended, or FALSE if there is a conflict.
+-- A conflict occurs when p_offset is not the next unused offset of p_stream.
+--
+CREATE OR REPLACE FUNCTION append_checked
+(
+    p_store_id     BIGINT,
+    p_stream       VARCHAR(255),
+    p_offset       BIGINT,
+    p_event_type   VARCHAR(255),
+    p_content_type VARCHAR(255),
+    p_body         BYTEA,
+    p_metadata     BYTEA,
+    p_uid          VARCHAR(255)
+)
+RETURNS BOOLEAN
+LANGUAGE plpgsql
+VOLATILE
+SECURITY DEFINER
+AS $$
+DECLARE
+    v_now      TIMESTAMPTZ := clock_timestamp();
+    v_event_id BIGINT;
+BEGIN
+    IF p_stream = '' THEN
+        RAISE EXCEPTION 'cannot append directly to the ε-stream';
+    END IF;
+
+    -- If p_offset = 0, we're attempting to create the stream.
+    IF p_offset = 0 THEN
+
+        INSERT INTO stream (store_id, name, next)
+        VALUES (p_store_id, p_stream, 1)
+        ON CONFLICT DO NOTHING;
+
+        IF NOT FOUND THEN
+            RETURN FALSE;
+        END IF;
+
+        PERFORM record_stream_created(v_now, p_store_id, p_stream);
+
+    -- Otherwise the stream must already exist at p_offset.
+    ELSE
+
+        UPDATE stream SET
+            next = p_offset + 1
+        WHERE store_id = p_store_id
+            AND name = p_stream
+            AND next = p_offset;
+
+        IF NOT FOUND THEN
+            RETURN FALSE;
+        END IF;
+
+    END IF;
+
+    -- Once we know our write will not conflict, we can store the event.
+    v_event_id := store_event(
+        v_now,
+        p_store_id,
+        p_event_type,
+        p_content_type,
+        p_body,
+        p_metadata,
+        p_uid
+    );
+
+    -- Record a fact on the ε-stream.
+    PERFORM record_epsilon(v_now, p_store_id, v_event_id);
+
+    -- Record a fact on the named stream.
+    PERFORM record_fact(v_now, p_store_id, p_stream, p_offset, v_event_id);
+
+    RETURN TRUE;
+END;
+$$;
+--
+-- append_expected records a new fact to a named stream and to the ε-stream,
+-- provided that the stream satisfies an expectation.
+--
+-- p_mode is the numeric value of a gospel.ExpectationMode:
+--
+--   0 = any offset
+--   1 = next unused offset is exactly p_offset
+--   2 = stream does not exist
+--   3 = stream exists
+--   4 = next unused offset is at most p_offset
+--
+-- It returns the offset at which the event is appended, or NULL if there is a
+-- conflict.
+--
+CREATE OR REPLACE FUNCTION append_expected
+(
+    p_store_id     BIGINT,
+    p_stream       VARCHAR(255),
+    p_mode         INTEGER,
+    p_offset       BIGINT,
+    p_event_type   VARCHAR(255),
+    p_content_type VARCHAR(255),
+    p_body         BYTEA,
+    p_metadata     BYTEA,
+    p_uid          VARCHAR(255)
+)
+RETURNS BIGINT
+LANGUAGE plpgsql
+VOLATILE
+SECURITY DEFINER
+AS $$
+DECLARE
+    v_next BIGINT;
+BEGIN
+    -- Lock the stream row so that the offset can not change between checking
+    -- the expectation and appending.
+    SELECT next
+        INTO v_next
+        FROM stream
+    WHERE store_id = p_store_id
+        AND name   = p_stream
+    FOR UPDATE;
+
+    IF NOT FOUND THEN
+        v_next := 0;
+    END IF;
+
+    IF (p_mode = 1 AND v_next != p_offset)
+        OR (p_mode = 2 AND v_next != 0)
+        OR (p_mode = 3 AND v_next = 0)
+        OR (p_mode = 4 AND v_next > p_offset) THEN
+        RETURN NULL;
+    END IF;
+
+    -- append_checked still guards against a concurrent creation of the stream,
+    -- which is not prevented by the lock above.
+    IF NOT append_checked(
+        p_store_id,
+        p_stream,
+        v_next,
+        p_event_type,
+        p_content_type,
+        p_body,
+        p_metadata,
+        p_uid
+    ) THEN
+        RETURN NULL;
+    END IF;
+
+    RETURN v_next;
+END;
+$$;
+--
+-- append_unchecked records a new fact to a named stream and to the ε-stream.
+--
+CREATE OR REPLACE FUNCTION append_unchecked
+(
+    p_store_id     BIGINT,
+    p_stream       VARCHAR(255),
+    p_event_type   VARCHAR(255),
+    p_content_type VARCHAR(255),
+    p_body         BYTEA,
+    p_metadata     BYTEA,
+    p_uid          VARCHAR(255)
+)
+RETURNS BIGINT
+LANGUAGE plpgsql
+VOLATILE
+SECURITY DEFINER
+AS $$
+DECLARE
+    v_now      TIMESTAMPTZ := clock_timestamp();
+    v_event_id BIGINT;
+    v_offset   BIGINT;
+BEGIN
+    IF p_stream = '' THEN
+        RAISE EXCEPTION 'cannot append directly to the ε-stream';
+    END IF;
+
+    -- Record the event first, defering the locking of the stream table as late
+    -- as possible.
+    v_event_id := store_event(
+        v_now,
+        p_store_id,
+        p_event_type,
+        p_content_type,
+        p_body,
+        p_metadata,
+        p_uid
+    );
+
+    -- We don't care what the offset is now, we just want to find it so we can
+    -- add our fact. Our offset is whatever we set the next offset to minus 1.
+    INSERT INTO stream AS s (store_id, name, next)
+    VALUES (p_store_id, p_stream, 1)
+    ON CONFLICT (store_id, name) DO UPDATE SET
+        next = s.next + 1
+    RETURNING s.next - 1 INTO v_offset;
+
+    IF v_offset = 0 THEN
+        PERFORM record_stream_created(v_now, p_store_id, p_stream);
+    END IF;
+
+    -- Record our fact on the ε-stream.
+    PERFORM record_epsilon(v_now, p_store_id, v_event_id);
+
+    -- Record our fact on the named stream.
+    PERFORM record_fact(v_now, p_store_id, p_stream, v_offset, v_event_id);
+
+    RETURN v_offset;
+END;
+$$;
+--
+-- open_store returns the ID of the store named p_store, creating it if it does
+-- not already exist.
+--
+CREATE OR REPLACE FUNCTION open_store
+(
+    p_store VARCHAR(255)
+)
+RETURNS BIGINT
+LANGUAGE plpgsql
+VOLATILE
+SECURITY DEFINER
+AS $$
+DECLARE
+    v_now      TIMESTAMPTZ := clock_timestamp();
+    v_store_id BIGINT;
+BEGIN
+    INSERT INTO store (name)
+    VALUES (p_store)
+    ON CONFLICT DO NOTHING
+    RETURNING id INTO v_store_id;
+
+    -- Create the ε-stream and record an event about store creation.
+    IF v_store_id IS NOT NULL THEN
+        INSERT INTO stream (store_id, name, next)
+        VALUES (v_store_id, '', 0);
+
+        PERFORM record_store_created(v_now, v_store_id, p_store);
+
+        RETURN v_store_id;
+    END IF;
+
+    SELECT id
+        INTO v_store_id
+        FROM store
+    WHERE name = p_store;
+
+    RETURN v_store_id;
+END;
+$$;
+--
+-- record_epsilon unconditionally records a fact to the ε-stream.
+--
+-- This function is an implementation detail and should not be called by clients.
+--
+CREATE OR REPLACE FUNCTION record_epsilon
+(
+    p_now      TIMESTAMPTZ,
+    p_store_id BIGINT,
+    p_event_id BIGINT
+)
+RETURNS VOID
+LANGUAGE plpgsql
+VOLATILE
+SECURITY DEFINER
+AS $$
+DECLARE
+    v_offset BIGINT;
+BEGIN
+    SELECT next
+        INTO v_offset
+        FROM stream
+    WHERE store_id = p_store_id
+        AND name = ''
+        FOR UPDATE;
+
+    IF v_offset IS NULL THEN
+        RAISE EXCEPTION 'ε-stream does not exist';
+    END IF;
+
+    PERFORM record_fact(p_now, p_store_id, '', v_offset, p_event_id);
+
+    UPDATE stream SET
+        next = v_offset + 1
+    WHERE store_id = p_store_id
+        AND name = '';
+END;
+$$;
+--
+-- record_fact unconditionally inserts a fact and notifies any listening
+-- readers that it is available.
+--
+-- Notifications are sent on the 'gospel_fact' channel. The payload is the
+-- store ID and the stream name, separated by a colon. Notifications are only
+-- delivered once the transaction that appended the fact is committed.
+--
+-- This function is an implementation detail and should not be called by clients.
+--
+CREATE OR REPLACE FUNCTION record_fact
+(
+    p_now      TIMESTAMPTZ,
+    p_store_id BIGINT,
+    p_stream   VARCHAR(255),
+    p_offset   BIGINT,
+    p_event_id BIGINT
+)
+RETURNS VOID
+LANGUAGE plpgsql
+VOLATILE
+SECURITY DEFINER
+AS $$
+BEGIN
+    INSERT INTO fact (store_id, stream, "offset", event_id, time)
+    VALUES (p_store_id, p_stream, p_offset, p_event_id, p_now);
+
+    PERFORM pg_notify('gospel_fact', p_store_id || ':' || p_stream);
+END;
+$$;
+--
+-- record_store_created records a fact to the ε-stream about a new store being
+-- created.
+--
+-- This function is an implementation detail and should not be called by clients.
+--
+CREATE OR REPLACE FUNCTION record_store_created
+(
+    p_now      TIMESTAMPTZ,
+    p_store_id BIGINT,
+    p_store    VARCHAR(255)
+)
+RETURNS VOID
+LANGUAGE plpgsql
+VOLATILE
+SECURITY DEFINER
+AS $$
+BEGIN
+    PERFORM record_epsilon(
+        p_now,
+        p_store_id,
+        store_event(
+            p_now,
+            p_store_id,
+            '$store.created',
+            'application/vnd.gospel.store.created.v1',
+            convert_to(p_store, 'UTF8'),
+            NULL,
+            NULL
+        )
+    );
+END;
+$$;
+--
+-- record_stream_created records a fact to the ε-stream about a new named stream
+-- being created.
+--
+-- This function is an implementation detail and should not be called by clients.
+--
+CREATE OR REPLACE FUNCTION record_stream_created
+(
+    p_now      TIMESTAMPTZ,
+    p_store_id BIGINT,
+    p_stream   VARCHAR(255)
+)
+RETURNS VOID
+LANGUAGE plpgsql
+VOLATILE
+SECURITY DEFINER
+AS $$
+BEGIN
+    PERFORM record_epsilon(
+        p_now,
+        p_store_id,
+        store_event(
+            p_now,
+            p_store_id,
+            '$stream.created',
+            'application/vnd.gospel.stream.created.v1',
+            convert_to(p_stream, 'UTF8'),
+            NULL,
+            NULL
+        )
+    );
+END;
+$$;
+--
+-- store_event inserts an event and returns its ID.
+--
+-- This function is an implementation detail and should not be called by clients.
+--
+CREATE OR REPLACE FUNCTION store_event
+(
+    p_now          TIMESTAMPTZ,
+    p_store_id     BIGINT,
+    p_event_type   VARCHAR(255),
+    p_content_type VARCHAR(255),
+    p_body         BYTEA,
+    p_metadata     BYTEA,
+    p_uid          VARCHAR(255)
+)
+RETURNS BIGINT
+LANGUAGE plpgsql
+VOLATILE
+SECURITY DEFINER
+AS $$
+DECLARE
+    v_event_id BIGINT;
+BEGIN
+    INSERT INTO event (time, store_id, event_type, content_type, body, metadata, uid)
+    VALUES (p_now, p_store_id, p_event_type, p_content_type, p_body, p_metadata, p_uid)
+    RETURNING id INTO v_event_id;
+
+    RETURN v_event_id;
+END;
+$$;
+--
+-- event contains application-defined event data.
+--
+CREATE TABLE IF NOT EXISTS event
+(
+    id           BIGSERIAL PRIMARY KEY,
+    time         TIMESTAMPTZ NOT NULL,
+
+    store_id     BIGINT NOT NULL,
+    event_type   VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    body         BYTEA,
+    metadata     BYTEA, -- JSON object, NULL if the event has no metadata
+    uid          VARCHAR(255) -- application-defined ID, NULL if the event has no ID
+);
+--
+-- event_uid maps application-defined event IDs to the address at which the
+-- event was appended. It is used to detect appends that are repeated, such as
+-- when a client retries after a network failure.
+--
+CREATE TABLE IF NOT EXISTS event_uid
+(
+    store_id BIGINT NOT NULL,
+    uid      VARCHAR(255) NOT NULL,
+    stream   VARCHAR(255) NOT NULL,
+    "offset" BIGINT NOT NULL,
+
+    PRIMARY KEY (store_id, uid)
+);
+--
+-- fact contains mappings of stream and offset to events.
+--
+-- Every event appended by the client appears on both the named stream it was
+-- originally appended to, as well as the ε-stream.
+--
+CREATE TABLE IF NOT EXISTS fact
+(
+    store_id BIGINT NOT NULL,
+    stream   VARCHAR(255) NOT NULL,
+    "offset" BIGINT NOT NULL,
+
+    event_id BIGINT NOT NULL,
+    time     TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (store_id, stream, "offset")
+);
+
+CREATE INDEX IF NOT EXISTS fact_event_id ON fact (event_id);
+
+-- Used to find the first fact at or after a given time, as per FromTime().
+-- The offset is included so that the lookup reads only the index.
+CREATE INDEX IF NOT EXISTS fact_time ON fact (store_id, stream, time, "offset");
+--
+-- store is a mapping of name to event store ID.
+--
+CREATE TABLE IF NOT EXISTS store
+(
+    id   BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE
+);
+--
+-- stream is the authoratative source for the next offset of each stream. Its
+-- rows are locked to ensure consistency of concurrent append operations.
+--
+-- Note that stream names and offsets are de-normalized, they appear in the
+-- 'fact' table, rather than having a relation to this table. This mirrors the
+-- MariaDB schema, where 'fact' and 'event' are partitioned.
+--
+CREATE TABLE IF NOT EXISTS stream
+(
+    store_id BIGINT NOT NULL,
+    name     VARCHAR(255) NOT NULL,
+    next     BIGINT NOT NULL,
+
+    PRIMARY KEY (store_id, name)
+);
+--
+-- human_view is a human-readable, de-duplicated, chronological report of facts,
+-- excluding those on the ε-stream.
+--
+CREATE OR REPLACE VIEW human_view AS
+    SELECT
+        o.name AS store,
+        f.time,
+        f.stream,
+        f."offset",
+        e.event_type,
+        e.content_type,
+        e.body,
+        e.metadata
+    FROM store AS o
+    INNER JOIN fact AS f
+        ON f.store_id = o.id
+    INNER JOIN event AS e
+        ON e.id = f.event_id
+    WHERE f.stream != ''
+    ORDER BY o.name, e.time, f.stream, f."offset";
+`
@@ -0,0 +1,97 @@
+package schema
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// lockID is the key of the PostgreSQL advisory lock that is held while the
+// schema is created. It prevents concurrent clients from attempting to create
+// or replace the same objects at the same time.
+const lockID = 0x676f7370656c // "gospel"
+
+// Create creates the gospel schema on the given database pool, or migrates an
+// existing schema to the current version.
+//
+// The schema version is the number of migrations that have been applied. New
+// databases are created at the current version without applying any
+// migrations. Databases that contain a schema but no version were created
+// before versioning was introduced, and are treated as version zero.
+func Create(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, lockID); err != nil {
+		return err
+	}
+
+	v, err := version(tx)
+	if err != nil {
+		return err
+	}
+
+	if v > len(migrations) {
+		return fmt.Errorf(
+			"schema version %d is newer than the latest supported version (%d)",
+			v,
+			len(migrations),
+		)
+	}
+
+	for _, m := range migrations[v:] {
+		if _, err := tx.Exec(m); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(statements); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM schema_version`); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO schema_version (version) VALUES ($1)`,
+		len(migrations),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// version returns the current schema version.
+func version(tx *sql.Tx) (int, error) {
+	if _, err := tx.Exec(
+		`CREATE TABLE IF NOT EXISTS schema_version
+		(
+			version INTEGER NOT NULL
+		)`,
+	); err != nil {
+		return 0, err
+	}
+
+	var v int
+	err := tx.QueryRow(`SELECT version FROM schema_version`).Scan(&v)
+	if err != sql.ErrNoRows {
+		return v, err
+	}
+
+	var exists bool
+	if err := tx.QueryRow(
+		`SELECT to_regclass('store') IS NOT NULL`,
+	).Scan(&exists); err != nil {
+		return 0, err
+	}
+
+	if !exists {
+		return len(migrations), nil
+	}
+
+	return 0, nil
+}
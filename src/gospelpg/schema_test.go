@@ -0,0 +1,143 @@
+// +build !without_postgres
+
+package gospelpg_test
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/gospelpg"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("schema", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+		db     *sql.DB
+	)
+
+	// exec executes each of the given statements directly on the database.
+	exec := func(statements ...string) {
+		for _, s := range statements {
+			_, err := db.Exec(s)
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+	}
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 3*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+
+		db = getTestDB()
+
+		c := getTestClient()
+		c.Close()
+	})
+
+	AfterEach(func() {
+		cancel()
+		db.Close()
+		destroyTestSchema()
+	})
+
+	It("does not change the schema version when opened repeatedly", func() {
+		var before, after int
+
+		err := db.QueryRow(`SELECT version FROM schema_version`).Scan(&before)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		c := getTestClient()
+		c.Close()
+
+		err = db.QueryRow(`SELECT version FROM schema_version`).Scan(&after)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(after).To(Equal(before))
+	})
+
+	It("returns an error if the schema is newer than the client", func() {
+		exec(`UPDATE schema_version SET version = version + 1`)
+
+		_, err := gospelpg.OpenEnv()
+		Expect(err).To(MatchError(ContainSubstring("newer than the latest supported version")))
+	})
+
+	Context("when the database was created before schema versioning", func() {
+		BeforeEach(func() {
+			// Revert the schema to the state it was in before event metadata,
+			// IDs and the fact_time index were added. An overload of append_unchecked() with its
+			// original signature is created, which must not survive the
+			// migration. Dropping the metadata column also drops the view.
+			exec(
+				`DROP TABLE schema_version`,
+				`ALTER TABLE event DROP COLUMN metadata CASCADE`,
+				`ALTER TABLE event DROP COLUMN uid`,
+				`DROP TABLE event_uid`,
+				`DROP INDEX fact_time`,
+				`CREATE FUNCTION append_unchecked
+				(
+					p_store_id     BIGINT,
+					p_stream       VARCHAR(255),
+					p_event_type   VARCHAR(255),
+					p_content_type VARCHAR(255),
+					p_body         BYTEA
+				)
+				RETURNS BIGINT
+				LANGUAGE sql
+				AS 'SELECT NULL::BIGINT'`,
+			)
+		})
+
+		It("migrates the schema such that events with metadata and IDs can be appended", func() {
+			c, es := getTestStore()
+			defer c.Close()
+
+			_, err := es.AppendUnchecked(
+				ctx,
+				"test-stream",
+				gospel.Event{
+					ID:        "id-1",
+					EventType: "event-type-1",
+					Metadata:  map[string]string{"key": "value"},
+				},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			r, err := es.Open(ctx, gospel.Address{Stream: "test-stream"})
+			Expect(err).ShouldNot(HaveOccurred())
+			defer r.Close()
+
+			_, err = r.Next(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(r.Get().Event.Metadata).To(Equal(map[string]string{"key": "value"}))
+			Expect(r.Get().Event.ID).To(Equal("id-1"))
+
+			_, err = db.Exec(`SELECT metadata FROM human_view`)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			var n int
+			err = db.QueryRow(
+				`SELECT COUNT(*) FROM pg_proc WHERE proname = 'append_unchecked'`,
+			).Scan(&n)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(n).To(Equal(1))
+		})
+
+		It("adds the index used to find facts by time", func() {
+			c := getTestClient()
+			c.Close()
+
+			var exists bool
+			err := db.QueryRow(
+				`SELECT to_regclass('fact_time') IS NOT NULL`,
+			).Scan(&exists)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,71 @@
+package gospelpg
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/lib/pq"
+)
+
+// queryStreamInfo returns information about a stream.
+//
+// The next offset and fact count are read from the 'stream' table. The times
+// of the first and last facts are read from the 'fact' table by looking up the
+// facts at offset zero and the offset before next, using the primary key.
+func queryStreamInfo(
+	ctx context.Context,
+	db *sql.DB,
+	storeID uint64,
+	stream string,
+) (gospel.StreamInfo, error) {
+	info := gospel.StreamInfo{
+		Next: gospel.Address{Stream: stream},
+	}
+
+	row := db.QueryRowContext(
+		ctx,
+		`SELECT
+			s.next,
+			(
+				SELECT f.time
+				FROM fact AS f
+				WHERE f.store_id = s.store_id
+					AND f.stream = s.name
+					AND f."offset" = 0
+			),
+			(
+				SELECT f.time
+				FROM fact AS f
+				WHERE f.store_id = s.store_id
+					AND f.stream = s.name
+					AND f."offset" = s.next - 1
+			)
+		FROM stream AS s
+		WHERE s.store_id = $1
+			AND s.name = $2`,
+		storeID,
+		stream,
+	)
+
+	var first, last pq.NullTime
+
+	err := row.Scan(
+		&info.Next.Offset,
+		&first,
+		&last,
+	)
+
+	if err == sql.ErrNoRows {
+		return info, nil
+	} else if err != nil {
+		return info, err
+	}
+
+	info.Exists = info.Next.Offset > 0
+	info.FactCount = info.Next.Offset
+	info.FirstFactTime = first.Time
+	info.LastFactTime = last.Time
+
+	return info, nil
+}
@@ -0,0 +1,600 @@
+package gospelsqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/internal/apierror"
+	"github.com/jmalloc/gospel/src/internal/dedupe"
+	"github.com/jmalloc/gospel/src/internal/metadata"
+)
+
+// atomicAppend writes events to a stream inside a transaction using the given
+// append strategy.
+func atomicAppend(
+	ctx context.Context,
+	db *sql.DB,
+	storeID uint64,
+	addr *gospel.Address,
+	events []gospel.Event,
+	strategy appendStrategy,
+) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := appendOnce(ctx, tx, storeID, addr, events, strategy); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// atomicAppendMulti writes events to several streams inside a single
+// transaction, using the expectation of each StreamAppend.
+//
+// addrs must have the same length as appends. Each element is set to the
+// address of the next unused offset of the corresponding stream after its
+// append.
+func atomicAppendMulti(
+	ctx context.Context,
+	db *sql.DB,
+	storeID uint64,
+	appends []gospel.StreamAppend,
+	addrs []gospel.Address,
+) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, a := range appends {
+		addrs[i] = gospel.Address{Stream: a.Stream}
+		strategy := appendExpected(a.Expectation)
+
+		if err := appendOnce(ctx, tx, storeID, &addrs[i], a.Events, strategy); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// appendOnce appends events to a stream within tx using the given append
+// strategy, unless the append is a repeat of an earlier append of events with
+// the same IDs, in which case addr.Offset is set to the next unused offset
+// after the original append.
+func appendOnce(
+	ctx context.Context,
+	tx *sql.Tx,
+	storeID uint64,
+	addr *gospel.Address,
+	events []gospel.Event,
+	strategy appendStrategy,
+) error {
+	ids, err := dedupe.IDs(events)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) != 0 {
+		prev, err := queryIDs(ctx, tx, storeID, ids)
+		if err != nil {
+			return err
+		}
+
+		o, orig, ev := dedupe.Compare(addr.Stream, events, prev)
+
+		switch o {
+		case dedupe.Repeat:
+			*addr = orig
+			return nil
+		case dedupe.Conflict:
+			next, err := queryNext(ctx, tx, storeID, addr.Stream)
+			if err != nil {
+				return err
+			}
+
+			return apierror.NewDuplicateConflict(ev, orig, next)
+		}
+	}
+
+	if err := strategy(ctx, tx, storeID, addr, events); err != nil {
+		return err
+	}
+
+	if len(ids) != 0 {
+		return recordIDs(ctx, tx, storeID, *addr, events)
+	}
+
+	return nil
+}
+
+// queryIDs returns the addresses at which events with the given IDs were
+// appended, as read within tx. IDs that have not been appended are omitted.
+func queryIDs(
+	ctx context.Context,
+	tx *sql.Tx,
+	storeID uint64,
+	ids []string,
+) (map[string]gospel.Address, error) {
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, storeID)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT uid, stream, "offset" FROM event_uid WHERE store_id = ? AND uid IN (?`+strings.Repeat(`, ?`, len(ids)-1)+`)`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prev := map[string]gospel.Address{}
+
+	for rows.Next() {
+		var (
+			id   string
+			addr gospel.Address
+		)
+
+		if err := rows.Scan(&id, &addr.Stream, &addr.Offset); err != nil {
+			return nil, err
+		}
+
+		prev[id] = addr
+	}
+
+	return prev, rows.Err()
+}
+
+// recordIDs records the addresses of the events that have IDs, given nx, the
+// address of the next unused offset after they were appended.
+func recordIDs(
+	ctx context.Context,
+	tx *sql.Tx,
+	storeID uint64,
+	nx gospel.Address,
+	events []gospel.Event,
+) error {
+	offset := nx.Offset - uint64(len(events))
+
+	for _, ev := range events {
+		if ev.ID != "" {
+			if _, err := tx.ExecContext(
+				ctx,
+				`INSERT INTO event_uid (store_id, uid, stream, "offset") VALUES (?, ?, ?, ?)`,
+				storeID,
+				ev.ID,
+				nx.Stream,
+				offset,
+			); err != nil {
+				return err
+			}
+		}
+
+		offset++
+	}
+
+	return nil
+}
+
+// appendStrategy is a function that actually performs the database queries
+// to write events.
+//
+// addr.Offset is updated to refer to the next unused offset after the append.
+type appendStrategy func(
+	ctx context.Context,
+	tx *sql.Tx,
+	storeID uint64,
+	addr *gospel.Address,
+	events []gospel.Event,
+) error
+
+// appendChecked is an append strategy which verifies that addr refers to the
+// next unused offset.
+func appendChecked(
+	ctx context.Context,
+	tx *sql.Tx,
+	storeID uint64,
+	addr *gospel.Address,
+	events []gospel.Event,
+) error {
+	now := time.Now()
+
+	for _, ev := range events {
+		var (
+			res sql.Result
+			err error
+		)
+
+		// If addr.Offset = 0, we're attempting to create the stream, otherwise
+		// the stream must already exist at addr.Offset.
+		if addr.Offset == 0 {
+			res, err = tx.ExecContext(
+				ctx,
+				`INSERT OR IGNORE INTO stream (store_id, name, next) VALUES (?, ?, 1)`,
+				storeID,
+				addr.Stream,
+			)
+		} else {
+			res, err = tx.ExecContext(
+				ctx,
+				`UPDATE stream SET next = next + 1 WHERE store_id = ? AND name = ? AND next = ?`,
+				storeID,
+				addr.Stream,
+				addr.Offset,
+			)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if n == 0 {
+			return conflict(ctx, tx, storeID, *addr, ev)
+		}
+
+		if addr.Offset == 0 {
+			if err := recordStreamCreated(ctx, tx, now, storeID, addr.Stream); err != nil {
+				return err
+			}
+		}
+
+		if err := record(ctx, tx, now, storeID, *addr, ev); err != nil {
+			return err
+		}
+
+		addr.Offset++
+	}
+
+	return nil
+}
+
+// conflict returns a conflict error for an attempt to append ev at addr. The
+// error includes the actual next unused offset of the stream, which is read
+// within tx.
+func conflict(
+	ctx context.Context,
+	tx *sql.Tx,
+	storeID uint64,
+	addr gospel.Address,
+	ev gospel.Event,
+) error {
+	next, err := queryNext(ctx, tx, storeID, addr.Stream)
+	if err != nil {
+		return err
+	}
+
+	return apierror.NewConflict(addr, ev, next)
+}
+
+// queryNext returns the address of the next unused offset of stream, as read
+// within tx.
+func queryNext(
+	ctx context.Context,
+	tx *sql.Tx,
+	storeID uint64,
+	stream string,
+) (gospel.Address, error) {
+	next := gospel.Address{Stream: stream}
+
+	row := tx.QueryRowContext(
+		ctx,
+		`SELECT next FROM stream WHERE store_id = ? AND name = ?`,
+		storeID,
+		stream,
+	)
+
+	if err := row.Scan(&next.Offset); err != nil && err != sql.ErrNoRows {
+		return next, err
+	}
+
+	return next, nil
+}
+
+// appendExpected returns an append strategy which verifies that the stream
+// satisfies the expectation e before appending.
+//
+// Transactions are started with an immediate lock, so the stream can not be
+// modified between checking the expectation and appending the events.
+func appendExpected(e gospel.Expectation) appendStrategy {
+	return func(
+		ctx context.Context,
+		tx *sql.Tx,
+		storeID uint64,
+		addr *gospel.Address,
+		events []gospel.Event,
+	) error {
+		next, err := queryNext(ctx, tx, storeID, addr.Stream)
+		if err != nil {
+			return err
+		}
+
+		if !e.IsSatisfiedBy(next.Offset) {
+			return apierror.NewExpectationConflict(addr.Stream, e, events[0], next)
+		}
+
+		addr.Offset = next.Offset
+
+		return appendChecked(ctx, tx, storeID, addr, events)
+	}
+}
+
+// appendUnchecked is an append strategy which always appends regardless
+// of the offset in addr.
+func appendUnchecked(
+	ctx context.Context,
+	tx *sql.Tx,
+	storeID uint64,
+	addr *gospel.Address,
+	events []gospel.Event,
+) error {
+	now := time.Now()
+
+	row := tx.QueryRowContext(
+		ctx,
+		`SELECT next FROM stream WHERE store_id = ? AND name = ?`,
+		storeID,
+		addr.Stream,
+	)
+
+	err := row.Scan(&addr.Offset)
+
+	if err == sql.ErrNoRows {
+		addr.Offset = 0
+
+		if err := recordStreamCreated(ctx, tx, now, storeID, addr.Stream); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	for _, ev := range events {
+		if err := record(ctx, tx, now, storeID, *addr, ev); err != nil {
+			return err
+		}
+
+		addr.Offset++
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT OR REPLACE INTO stream (store_id, name, next) VALUES (?, ?, ?)`,
+		storeID,
+		addr.Stream,
+		addr.Offset,
+	)
+
+	return err
+}
+
+// openStore returns the ID of the store with the given name, creating it if
+// it does not already exist.
+func openStore(
+	ctx context.Context,
+	tx *sql.Tx,
+	name string,
+) (uint64, error) {
+	var id uint64
+
+	row := tx.QueryRowContext(
+		ctx,
+		`SELECT id FROM store WHERE name = ?`,
+		name,
+	)
+
+	err := row.Scan(&id)
+	if err != sql.ErrNoRows {
+		return id, err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO store (name) VALUES (?)`,
+		name,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	id = uint64(n)
+
+	// Create the ε-stream and record an event about store creation.
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO stream (store_id, name, next) VALUES (?, '', 0)`,
+		id,
+	); err != nil {
+		return 0, err
+	}
+
+	return id, recordEpsilon(
+		ctx,
+		tx,
+		time.Now(),
+		id,
+		gospel.Event{
+			EventType:   "$store.created",
+			ContentType: "application/vnd.gospel.store.created.v1",
+			Body:        []byte(name),
+		},
+	)
+}
+
+// recordStreamCreated records a fact to the ε-stream about a new named stream
+// being created.
+func recordStreamCreated(
+	ctx context.Context,
+	tx *sql.Tx,
+	now time.Time,
+	storeID uint64,
+	stream string,
+) error {
+	return recordEpsilon(
+		ctx,
+		tx,
+		now,
+		storeID,
+		gospel.Event{
+			EventType:   "$stream.created",
+			ContentType: "application/vnd.gospel.stream.created.v1",
+			Body:        []byte(stream),
+		},
+	)
+}
+
+// record stores ev and records facts for it at addr and on the ε-stream.
+//
+// It does not update the next offset of addr.Stream.
+func record(
+	ctx context.Context,
+	tx *sql.Tx,
+	now time.Time,
+	storeID uint64,
+	addr gospel.Address,
+	ev gospel.Event,
+) error {
+	eventID, err := storeEvent(ctx, tx, now, storeID, ev)
+	if err != nil {
+		return err
+	}
+
+	if err := recordEpsilonFact(ctx, tx, now, storeID, eventID); err != nil {
+		return err
+	}
+
+	return recordFact(ctx, tx, now, storeID, addr, eventID)
+}
+
+// recordEpsilon stores ev and records a fact for it on the ε-stream only.
+func recordEpsilon(
+	ctx context.Context,
+	tx *sql.Tx,
+	now time.Time,
+	storeID uint64,
+	ev gospel.Event,
+) error {
+	eventID, err := storeEvent(ctx, tx, now, storeID, ev)
+	if err != nil {
+		return err
+	}
+
+	return recordEpsilonFact(ctx, tx, now, storeID, eventID)
+}
+
+// recordEpsilonFact records a fact for an existing event on the ε-stream.
+func recordEpsilonFact(
+	ctx context.Context,
+	tx *sql.Tx,
+	now time.Time,
+	storeID uint64,
+	eventID int64,
+) error {
+	addr := gospel.Address{}
+
+	row := tx.QueryRowContext(
+		ctx,
+		`SELECT next FROM stream WHERE store_id = ? AND name = ''`,
+		storeID,
+	)
+
+	if err := row.Scan(&addr.Offset); err != nil {
+		return err
+	}
+
+	if err := recordFact(ctx, tx, now, storeID, addr, eventID); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE stream SET next = next + 1 WHERE store_id = ? AND name = ''`,
+		storeID,
+	)
+
+	return err
+}
+
+// recordFact unconditionally inserts a fact.
+func recordFact(
+	ctx context.Context,
+	tx *sql.Tx,
+	now time.Time,
+	storeID uint64,
+	addr gospel.Address,
+	eventID int64,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO fact (store_id, stream, "offset", event_id, time) VALUES (?, ?, ?, ?, ?)`,
+		storeID,
+		addr.Stream,
+		addr.Offset,
+		eventID,
+		now.UnixNano(),
+	)
+
+	return err
+}
+
+// storeEvent inserts an event and returns its ID.
+func storeEvent(
+	ctx context.Context,
+	tx *sql.Tx,
+	now time.Time,
+	storeID uint64,
+	ev gospel.Event,
+) (int64, error) {
+	md, err := metadata.Marshal(ev.Metadata)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO event (time, store_id, event_type, content_type, body, metadata, uid) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		now.UnixNano(),
+		storeID,
+		ev.EventType,
+		ev.ContentType,
+		ev.Body,
+		md,
+		eventUID(ev),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+// eventUID returns the value to store in the uid column for ev, which is NULL
+// if the event does not have an ID.
+func eventUID(ev gospel.Event) sql.NullString {
+	return sql.NullString{
+		String: ev.ID,
+		Valid:  ev.ID != "",
+	}
+}
@@ -0,0 +1,143 @@
+package gospelsqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/gospelsqlite/schema"
+	"github.com/jmalloc/gospel/src/internal/match"
+	"github.com/jmalloc/gospel/src/internal/notify"
+	"github.com/jmalloc/gospel/src/internal/options"
+	"github.com/jmalloc/twelf/src/twelf"
+	"github.com/mattn/go-sqlite3"
+	"go.uber.org/multierr"
+)
+
+// driverName is the name of the database/sql driver used by every client. It
+// is the go-sqlite3 driver, with the addition of the SQL functions that gospel
+// requires.
+const driverName = "gospel_sqlite3"
+
+func init() {
+	sql.Register(
+		driverName,
+		&sqlite3.SQLiteDriver{
+			ConnectHook: func(c *sqlite3.SQLiteConn) error {
+				// gospel_glob(p, s) returns true if s matches the glob pattern
+				// p. It is used instead of SQLite's GLOB operator, which
+				// matches characters rather than bytes, and supports
+				// character classes.
+				return c.RegisterFunc("gospel_glob", match.Glob, true)
+			},
+		},
+	)
+}
+
+// dsnParameters are the connection parameters that are added to the DSN of
+// every client.
+//
+// WAL mode allows readers to proceed while an append is in progress, and
+// immediate transactions acquire the write lock up-front, avoiding deadlocks
+// between concurrent appends that would otherwise both attempt to upgrade a
+// read lock.
+const dsnParameters = "_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate"
+
+// Client is a connection to an SQLite database file.
+//
+// Each database supports an arbitrary number of named event stores.
+type Client struct {
+	// db is the pool of SQLite connections used by the event stores accessed
+	// through this client.
+	db *sql.DB
+
+	// hub is used to wake readers when new facts are appended via this client.
+	// Readers wait on a streamKey.
+	hub *notify.Hub
+
+	// logger is the logger to use for activity and debug logging. It is
+	// inherited by all event stores and their readers.
+	logger twelf.Logger
+}
+
+// Open returns a new Client instance for the SQLite database file at path,
+// creating it if it does not already exist.
+//
+// path may include additional connection parameters, as accepted by
+// github.com/mattn/go-sqlite3.
+func Open(path string, opts ...gospel.Option) (*Client, error) {
+	o := options.NewClientOptions(opts)
+
+	dsn := path
+	if strings.Contains(dsn, "?") {
+		dsn += "&" + dsnParameters
+	} else {
+		dsn += "?" + dsnParameters
+	}
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	err = schema.Create(db)
+	if err != nil {
+		return nil, multierr.Append(
+			err,
+			db.Close(),
+		)
+	}
+
+	o.Logger.Log(
+		"opened SQLite event store at %s",
+		path,
+	)
+
+	return &Client{
+		db,
+		&notify.Hub{},
+		o.Logger,
+	}, nil
+}
+
+// OpenStore returns an event store by name, creating it if it does not already
+// exist.
+//
+// ctx applies to the opening of the store, and not to the store itself.
+func (c *Client) OpenStore(ctx context.Context, name string) (*EventStore, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	id, err := openStore(ctx, tx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	c.logger.Debug("opened '%s' event store", name)
+
+	return &EventStore{
+		c.db,
+		id,
+		name,
+		c.hub,
+		c.logger,
+	}, nil
+}
+
+// Close closes the database connections.
+//
+// Any readers that are waiting for new facts are woken so that they observe
+// the closure.
+func (c *Client) Close() error {
+	err := c.db.Close()
+	c.hub.NotifyAll()
+	return err
+}
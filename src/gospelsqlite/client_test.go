@@ -0,0 +1,65 @@
+package gospelsqlite_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/jmalloc/gospel/src/gospelsqlite"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+		path   string
+		client *Client
+	)
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 3*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+
+		path = getTestPath()
+		client = getTestClient(path)
+	})
+
+	AfterEach(func() {
+		cancel()
+		client.Close()
+		destroyTestDatabase(path)
+	})
+
+	Describe("OpenStore", func() {
+		It("returns a gospel.EventStore", func() {
+			var es gospel.EventStore // static interface check
+			es, err := client.OpenStore(ctx, "test")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(es).NotTo(BeNil())
+		})
+
+		It("returns the same store when reopened by another client", func() {
+			es, err := client.OpenStore(ctx, "test")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = es.AppendUnchecked(ctx, "test-stream", gospel.Event{EventType: "event-type-1"})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			other, otherStore := getTestStore(path)
+			defer other.Close()
+
+			nx, err := otherStore.AppendUnchecked(ctx, "test-stream", gospel.Event{EventType: "event-type-2"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(nx.Offset).To(BeEquivalentTo(2))
+		})
+
+		It("returns an error if the client is closed", func() {
+			client.Close()
+			_, err := client.OpenStore(ctx, "test")
+			Expect(err).Should(MatchError("sql: database is closed"))
+		})
+	})
+})
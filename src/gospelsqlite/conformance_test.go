@@ -0,0 +1,23 @@
+package gospelsqlite_test
+
+import (
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/gospeltest"
+)
+
+var (
+	_ = gospeltest.DescribeEventStore(newConformanceStore)
+	_ = gospeltest.DescribeReader(newConformanceStore)
+)
+
+// newConformanceStore is a gospeltest.Factory that returns an EventStore that
+// uses a new temporary database file.
+func newConformanceStore() (gospel.EventStore, func()) {
+	path := getTestPath()
+	c, es := getTestStore(path)
+
+	return es, func() {
+		c.Close()
+		destroyTestDatabase(path)
+	}
+}
@@ -0,0 +1,65 @@
+package gospelsqlite_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/gospelsqlite"
+	"github.com/jmalloc/twelf/src/twelf"
+)
+
+// getTestPath returns the path to a database file in a new temporary
+// directory.
+func getTestPath() string {
+	dir, err := ioutil.TempDir("", "gospelsqlite")
+	if err != nil {
+		panic(err)
+	}
+
+	return filepath.Join(dir, "gospel.db")
+}
+
+// getTestClient returns a Client that uses the database file at path.
+func getTestClient(path string) *gospelsqlite.Client {
+	c, err := gospelsqlite.Open(
+		path,
+		gospel.Logger(
+			&twelf.StandardLogger{
+				CaptureDebug: true,
+			},
+		),
+	)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// getTestStore returns an EventStore that uses the database file at path.
+func getTestStore(path string) (*gospelsqlite.Client, *gospelsqlite.EventStore) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	c := getTestClient(path)
+	es, err := c.OpenStore(ctx, "test")
+	if err != nil {
+		c.Close()
+		panic(err)
+	}
+
+	return c, es
+}
+
+// destroyTestDatabase removes the temporary directory containing the database
+// file at path.
+func destroyTestDatabase(path string) {
+	if err := os.RemoveAll(filepath.Dir(path)); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,8 @@
+// Package gospelsqlite is an implementation of the gospel public API that uses
+// an SQLite database file for storage.
+//
+// It is intended for single-node deployments and command-line tools that can
+// not depend on a database server. Readers are woken immediately when facts are
+// appended via the same Client, and poll the database to discover facts that
+// are appended by other processes.
+package gospelsqlite
@@ -0,0 +1,123 @@
+package gospelsqlite_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/jmalloc/gospel/src/gospelsqlite"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reader", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+
+		path   string
+		client *Client
+		store  *EventStore
+	)
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 3*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+
+		path = getTestPath()
+		client, store = getTestStore(path)
+
+		_, err := store.AppendUnchecked(
+			ctx,
+			"test-stream",
+			gospel.Event{EventType: "event-type-1", Body: []byte("event-1")},
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		cancel()
+		client.Close()
+		destroyTestDatabase(path)
+	})
+
+	Describe("Next", func() {
+		It("is woken immediately when a fact is appended via the same client", func() {
+			r, err := store.Open(
+				ctx,
+				gospel.Address{Stream: "test-stream"},
+				PollInterval(time.Hour),
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+			defer r.Close()
+
+			_, err = r.Next(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			go func() {
+				defer GinkgoRecover()
+
+				time.Sleep(50 * time.Millisecond)
+
+				_, err := store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{EventType: "event-type-2", Body: []byte("event-2")},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+			}()
+
+			nextCtx, cancel := context.WithTimeout(ctx, 1*time.Second)
+			defer cancel()
+
+			_, err = r.Next(nextCtx)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(r.Get().Event.Body).To(Equal([]byte("event-2")))
+		})
+
+		It("polls for facts that are appended by another client", func() {
+			r, err := store.Open(
+				ctx,
+				gospel.Address{Stream: "test-stream"},
+				PollInterval(10*time.Millisecond),
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+			defer r.Close()
+
+			_, err = r.Next(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			other, otherStore := getTestStore(path)
+			defer other.Close()
+
+			_, err = otherStore.AppendUnchecked(
+				ctx,
+				"test-stream",
+				gospel.Event{EventType: "event-type-2", Body: []byte("event-2")},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = r.Next(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(r.Get().Event.Body).To(Equal([]byte("event-2")))
+		})
+
+		It("returns an error if the client is closed", func() {
+			r, err := store.Open(
+				ctx,
+				gospel.Address{Stream: "test-stream"},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+			defer r.Close()
+
+			_, err = r.Next(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			client.Close()
+
+			_, err = r.Next(ctx)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
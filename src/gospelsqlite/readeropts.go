@@ -0,0 +1,77 @@
+package gospelsqlite
+
+import (
+	"time"
+
+	"github.com/jmalloc/gospel/src/internal/options"
+)
+
+const (
+	// DefaultReadBufferSize is the default read-buffer size for each reader.
+	// It is used if no specific size is set via ReadBufferSize().
+	DefaultReadBufferSize = 100
+
+	// DefaultPollInterval is the default interval at which readers poll for new
+	// facts that are appended by other processes. It is used if no specific
+	// value is set via PollInterval().
+	DefaultPollInterval = 1 * time.Second
+)
+
+// readerOptionKey is a custom type used to ensure that SQLite-specific keys
+// can not clash with custom options from other systems.
+type readerOptionKey int
+
+const (
+	readBufferKey readerOptionKey = iota
+	pollIntervalKey
+)
+
+// ReadBufferSize is a reader option that sets the number of facts to buffer
+// in memory before a call to Next().
+//
+// The minimum read-buffer size is 2.
+func ReadBufferSize(n uint) options.ReaderOption {
+	if n < 2 {
+		n = 2
+	}
+
+	return func(o *options.ReaderOptions) {
+		o.Set(readBufferKey, n)
+	}
+}
+
+// getReadBufferSize returns the read-buffer size to use for the given reader
+// options, falling back to the default if necessary.
+func getReadBufferSize(o *options.ReaderOptions) uint {
+	if v, ok := o.Get(readBufferKey); ok {
+		return v.(uint)
+	}
+
+	return DefaultReadBufferSize
+}
+
+// PollInterval is a reader option that sets the interval at which the reader
+// polls for new facts.
+//
+// Readers are woken immediately when facts are appended via the same client,
+// polling is only necessary to discover facts that are appended by other
+// processes that share the same database file.
+func PollInterval(d time.Duration) options.ReaderOption {
+	if d < 0 {
+		d = 0
+	}
+
+	return func(o *options.ReaderOptions) {
+		o.Set(pollIntervalKey, d)
+	}
+}
+
+// getPollInterval returns the poll interval to use for the given reader
+// options, falling back to the default if necessary.
+func getPollInterval(o *options.ReaderOptions) time.Duration {
+	if v, ok := o.Get(pollIntervalKey); ok {
+		return v.(time.Duration)
+	}
+
+	return DefaultPollInterval
+}
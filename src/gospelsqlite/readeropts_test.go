@@ -0,0 +1,76 @@
+package gospelsqlite
+
+import (
+	"time"
+
+	"github.com/jmalloc/gospel/src/internal/options"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("read-buffer size option", func() {
+	Describe("ReadBufferSize", func() {
+		It("sets the buffer size", func() {
+			opts := &options.ReaderOptions{}
+
+			ReadBufferSize(10)(opts)
+
+			Expect(getReadBufferSize(opts)).To(
+				BeNumerically("==", 10),
+			)
+		})
+
+		It("caps the minimum size at 2", func() {
+			opts := &options.ReaderOptions{}
+			ReadBufferSize(1)(opts)
+
+			Expect(getReadBufferSize(opts)).To(
+				BeNumerically("==", 2),
+			)
+		})
+	})
+
+	Describe("getReadBufferSize", func() {
+		It("returns the default buffer size if none is set", func() {
+			opts := &options.ReaderOptions{}
+
+			Expect(getReadBufferSize(opts)).To(
+				BeNumerically("==", DefaultReadBufferSize),
+			)
+		})
+	})
+})
+
+var _ = Describe("poll interval option", func() {
+	Describe("PollInterval", func() {
+		It("sets the poll interval", func() {
+			opts := &options.ReaderOptions{}
+
+			PollInterval(10 * time.Second)(opts)
+
+			Expect(getPollInterval(opts)).To(
+				Equal(10 * time.Second),
+			)
+		})
+
+		It("caps the minimum at zero", func() {
+			opts := &options.ReaderOptions{}
+
+			PollInterval(-time.Second)(opts)
+
+			Expect(getPollInterval(opts)).To(
+				Equal(0 * time.Second),
+			)
+		})
+	})
+
+	Describe("getPollInterval", func() {
+		It("returns the default interval if none is set", func() {
+			opts := &options.ReaderOptions{}
+
+			Expect(getPollInterval(opts)).To(
+				Equal(DefaultPollInterval),
+			)
+		})
+	})
+})
@@ -0,0 +1,17 @@
+--
+-- event contains application-defined event data.
+--
+-- Times are stored as the number of nanoseconds since the Unix epoch.
+--
+CREATE TABLE IF NOT EXISTS event
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    time         INTEGER NOT NULL,
+
+    store_id     INTEGER NOT NULL,
+    event_type   TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    body         BLOB,
+    metadata     BLOB, -- JSON object, NULL if the event has no metadata
+    uid          TEXT -- application-defined ID, NULL if the event has no ID
+);
@@ -0,0 +1,14 @@
+--
+-- event_uid maps application-defined event IDs to the address at which the
+-- event was appended. It is used to detect appends that are repeated, such as
+-- when a client retries after a network failure.
+--
+CREATE TABLE IF NOT EXISTS event_uid
+(
+    store_id INTEGER NOT NULL,
+    uid      TEXT NOT NULL,
+    stream   TEXT NOT NULL,
+    "offset" INTEGER NOT NULL,
+
+    PRIMARY KEY (store_id, uid)
+) WITHOUT ROWID;
@@ -0,0 +1,23 @@
+--
+-- fact contains mappings of stream and offset to events.
+--
+-- Every event appended by the client appears on both the named stream it was
+-- originally appended to, as well as the ε-stream.
+--
+CREATE TABLE IF NOT EXISTS fact
+(
+    store_id INTEGER NOT NULL,
+    stream   TEXT NOT NULL,
+    "offset" INTEGER NOT NULL,
+
+    event_id INTEGER NOT NULL,
+    time     INTEGER NOT NULL,
+
+    PRIMARY KEY (store_id, stream, "offset")
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS fact_event_id ON fact (event_id);
+
+-- Used to find the first fact at or after a given time, as per FromTime().
+-- The primary key is implicitly included, so the lookup reads only the index.
+CREATE INDEX IF NOT EXISTS fact_time ON fact (store_id, stream, time);
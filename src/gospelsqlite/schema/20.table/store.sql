@@ -0,0 +1,8 @@
+--
+-- store is a mapping of name to event store ID.
+--
+CREATE TABLE IF NOT EXISTS store
+(
+    id   INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE
+);
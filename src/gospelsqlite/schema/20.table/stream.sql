@@ -0,0 +1,15 @@
+--
+-- stream is the authoratative source for the next offset of each stream.
+--
+-- SQLite only allows a single writer at a time, so unlike the MariaDB schema
+-- there is no need to lock individual rows to ensure the consistency of
+-- concurrent append operations.
+--
+CREATE TABLE IF NOT EXISTS stream
+(
+    store_id INTEGER NOT NULL,
+    name     TEXT NOT NULL,
+    next     INTEGER NOT NULL,
+
+    PRIMARY KEY (store_id, name)
+) WITHOUT ROWID;
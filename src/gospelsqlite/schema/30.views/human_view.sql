@@ -0,0 +1,21 @@
+--
+-- human_view is a human-readable, de-duplicated, chronological report of facts,
+-- excluding those on the ε-stream.
+--
+CREATE VIEW IF NOT EXISTS human_view AS
+    SELECT
+        o.name AS store,
+        strftime('%Y-%m-%d %H:%M:%f', f.time / 1000000000.0, 'unixepoch') AS time,
+        f.stream,
+        f."offset",
+        e.event_type,
+        e.content_type,
+        e.body,
+        e.metadata
+    FROM store AS o
+    INNER JOIN fact AS f
+        ON f.store_id = o.id
+    INNER JOIN event AS e
+        ON e.id = f.event_id
+    WHERE f.stream != ''
+    ORDER BY o.name, e.time, f.stream, f."offset";
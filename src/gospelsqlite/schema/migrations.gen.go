@@ -0,0 +1,30 @@
+package schema
+
+var migrations = []string{
+	`--
+-- 01.event_metadata adds event metadata to schemas created before event
+-- metadata was supported.
+--
+-- The view is dropped so that it is re-created with the new column when the
+-- rest of the schema is created, once all migrations have been applied.
+--
+ALTER TABLE event ADD COLUMN metadata BLOB;
+
+DROP VIEW IF EXISTS human_view;
+`,
+	`--
+-- 02.event_uid adds application-defined event IDs to schemas created before
+-- event IDs were supported.
+--
+-- The 'event_uid' table did not previously exist, so it is created along with
+-- the rest of the schema once all migrations have been applied.
+--
+ALTER TABLE event ADD COLUMN uid TEXT;
+`,
+	`--
+-- 03.fact_time adds the index used to find the first fact at or after a given
+-- time to schemas created before readers could seek by time.
+--
+CREATE INDEX IF NOT EXISTS fact_time ON fact (store_id, stream, time);
+`,
+}
@@ -0,0 +1,10 @@
+--
+-- 01.event_metadata adds event metadata to schemas created before event
+-- metadata was supported.
+--
+-- The view is dropped so that it is re-created with the new column when the
+-- rest of the schema is created, once all migrations have been applied.
+--
+ALTER TABLE event ADD COLUMN metadata BLOB;
+
+DROP VIEW IF EXISTS human_view;
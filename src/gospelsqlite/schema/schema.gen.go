@@ -0,0 +1,102 @@
+package schema
+
+var statements = `
+--
+-- event contains application-defined event data.
+--
+-- Times are stored as the number of nanoseconds since the Unix epoch.
+--
+CREATE TABLE IF NOT EXISTS event
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    time         INTEGER NOT NULL,
+
+    store_id     INTEGER NOT NULL,
+    event_type   TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    body         BLOB,
+    metadata     BLOB, -- JSON object, NULL if the event has no metadata
+    uid          TEXT -- application-defined ID, NULL if the event has no ID
+);
+--
+-- event_uid maps application-defined event IDs to the address at which the
+-- event was appended. It is used to detect appends that are repeated, such as
+-- when a client retries after a network failure.
+--
+CREATE TABLE IF NOT EXISTS event_uid
+(
+    store_id INTEGER NOT NULL,
+    uid      TEXT NOT NULL,
+    stream   TEXT NOT NULL,
+    "offset" INTEGER NOT NULL,
+
+    PRIMARY KEY (store_id, uid)
+) WITHOUT ROWID;
+--
+-- fact contains mappings of stream and offset to events.
+--
+-- Every event appended by the client appears on both the named stream it was
+-- originally appended to, as well as the ε-stream.
+--
+CREATE TABLE IF NOT EXISTS fact
+(
+    store_id INTEGER NOT NULL,
+    stream   TEXT NOT NULL,
+    "offset" INTEGER NOT NULL,
+
+    event_id INTEGER NOT NULL,
+    time     INTEGER NOT NULL,
+
+    PRIMARY KEY (store_id, stream, "offset")
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS fact_event_id ON fact (event_id);
+
+-- Used to find the first fact at or after a given time, as per FromTime().
+-- The primary key is implicitly included, so the lookup reads only the index.
+CREATE INDEX IF NOT EXISTS fact_time ON fact (store_id, stream, time);
+--
+-- store is a mapping of name to event store ID.
+--
+CREATE TABLE IF NOT EXISTS store
+(
+    id   INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE
+);
+--
+-- stream is the authoratative source for the next offset of each stream.
+--
+-- SQLite only allows a single writer at a time, so unlike the MariaDB schema
+-- there is no need to lock individual rows to ensure the consistency of
+-- concurrent append operations.
+--
+CREATE TABLE IF NOT EXISTS stream
+(
+    store_id INTEGER NOT NULL,
+    name     TEXT NOT NULL,
+    next     INTEGER NOT NULL,
+
+    PRIMARY KEY (store_id, name)
+) WITHOUT ROWID;
+--
+-- human_view is a human-readable, de-duplicated, chronological report of facts,
+-- excluding those on the ε-stream.
+--
+CREATE VIEW IF NOT EXISTS human_view AS
+    SELECT
+        o.name AS store,
+        strftime('%Y-%m-%d %H:%M:%f', f.time / 1000000000.0, 'unixepoch') AS time,
+        f.stream,
+        f."offset",
+        e.event_type,
+        e.content_type,
+        e.body,
+        e.metadata
+    FROM store AS o
+    INNER JOIN fact AS f
+        ON f.store_id = o.id
+    INNER JOIN event AS e
+        ON e.id = f.event_id
+    WHERE f.stream != ''
+    ORDER BY o.name, e.time, f.stream, f."offset";
+`
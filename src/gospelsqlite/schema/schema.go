@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Create creates the gospel schema on the given database, or migrates an
+// existing schema to the current version.
+//
+// The schema version is the number of migrations that have been applied. New
+// databases are created at the current version without applying any
+// migrations. Databases that contain a schema but no version were created
+// before versioning was introduced, and are treated as version zero.
+func Create(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	v, err := version(tx)
+	if err != nil {
+		return err
+	}
+
+	if v > len(migrations) {
+		return fmt.Errorf(
+			"schema version %d is newer than the latest supported version (%d)",
+			v,
+			len(migrations),
+		)
+	}
+
+	for _, m := range migrations[v:] {
+		if _, err := tx.Exec(m); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(statements); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM schema_version`); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO schema_version (version) VALUES (?)`,
+		len(migrations),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// version returns the current schema version.
+func version(tx *sql.Tx) (int, error) {
+	if _, err := tx.Exec(
+		`CREATE TABLE IF NOT EXISTS schema_version
+		(
+			version INTEGER NOT NULL
+		)`,
+	); err != nil {
+		return 0, err
+	}
+
+	var v int
+	err := tx.QueryRow(`SELECT version FROM schema_version`).Scan(&v)
+	if err != sql.ErrNoRows {
+		return v, err
+	}
+
+	var n int
+	if err := tx.QueryRow(
+		`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'store'`,
+	).Scan(&n); err != nil {
+		return 0, err
+	}
+
+	if n == 0 {
+		return len(migrations), nil
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,181 @@
+package gospelsqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/gospelsqlite"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// unversionedSchema is the schema as it was created before schema versioning
+// was introduced.
+const unversionedSchema = `
+CREATE TABLE event
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    time         INTEGER NOT NULL,
+
+    store_id     INTEGER NOT NULL,
+    event_type   TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    body         BLOB
+);
+
+CREATE TABLE fact
+(
+    store_id INTEGER NOT NULL,
+    stream   TEXT NOT NULL,
+    "offset" INTEGER NOT NULL,
+
+    event_id INTEGER NOT NULL,
+    time     INTEGER NOT NULL,
+
+    PRIMARY KEY (store_id, stream, "offset")
+) WITHOUT ROWID;
+
+CREATE INDEX fact_event_id ON fact (event_id);
+
+CREATE TABLE store
+(
+    id   INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE stream
+(
+    store_id INTEGER NOT NULL,
+    name     TEXT NOT NULL,
+    next     INTEGER NOT NULL,
+
+    PRIMARY KEY (store_id, name)
+) WITHOUT ROWID;
+
+CREATE VIEW human_view AS
+    SELECT
+        o.name AS store,
+        f.stream,
+        f."offset",
+        e.event_type,
+        e.content_type,
+        e.body
+    FROM store AS o
+    INNER JOIN fact AS f
+        ON f.store_id = o.id
+    INNER JOIN event AS e
+        ON e.id = f.event_id
+    WHERE f.stream != '';
+`
+
+var _ = Describe("schema", func() {
+	var (
+		path string
+		db   *sql.DB
+	)
+
+	BeforeEach(func() {
+		path = getTestPath()
+
+		var err error
+		db, err = sql.Open("sqlite3", path)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		db.Close()
+		destroyTestDatabase(path)
+	})
+
+	// schemaVersion returns the version recorded in the database.
+	schemaVersion := func() int {
+		var v int
+		err := db.QueryRow(`SELECT version FROM schema_version`).Scan(&v)
+		Expect(err).ShouldNot(HaveOccurred())
+		return v
+	}
+
+	It("records the schema version of a new database", func() {
+		c := getTestClient(path)
+		c.Close()
+
+		Expect(schemaVersion()).To(BeNumerically(">", 0))
+	})
+
+	It("can be opened repeatedly", func() {
+		c := getTestClient(path)
+		c.Close()
+
+		v := schemaVersion()
+
+		c = getTestClient(path)
+		c.Close()
+
+		Expect(schemaVersion()).To(Equal(v))
+	})
+
+	Context("when the database was created before schema versioning", func() {
+		BeforeEach(func() {
+			_, err := db.Exec(unversionedSchema)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("adds the metadata column to the event table and view", func() {
+			c := getTestClient(path)
+			c.Close()
+
+			_, err := db.Exec(`SELECT metadata FROM event`)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = db.Exec(`SELECT metadata FROM human_view`)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("adds the index used to find facts by time", func() {
+			c := getTestClient(path)
+			c.Close()
+
+			var n int
+			err := db.QueryRow(
+				`SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = 'fact_time'`,
+			).Scan(&n)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(n).To(Equal(1))
+		})
+
+		It("supports appending events with IDs", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+
+			c, es := getTestStore(path)
+			defer c.Close()
+
+			ev := gospel.Event{EventType: "event-type-1", ID: "id-1"}
+
+			nx, err := es.AppendUnchecked(ctx, "test-stream", ev)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			rx, err := es.AppendUnchecked(ctx, "test-stream", ev)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(rx).To(Equal(nx))
+
+			var n int
+			err = db.QueryRow(`SELECT COUNT(*) FROM event_uid`).Scan(&n)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(n).To(Equal(1))
+		})
+	})
+
+	It("returns an error if the schema is newer than the client", func() {
+		c := getTestClient(path)
+		c.Close()
+
+		_, err := db.Exec(`UPDATE schema_version SET version = version + 1`)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = gospelsqlite.Open(path)
+		Expect(err).To(MatchError(ContainSubstring("newer than the latest supported version")))
+	})
+})
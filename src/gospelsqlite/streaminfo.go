@@ -0,0 +1,77 @@
+package gospelsqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+)
+
+// queryStreamInfo returns information about a stream.
+//
+// The next offset and fact count are read from the 'stream' table. The times
+// of the first and last facts are read from the 'fact' table by looking up the
+// facts at offset zero and the offset before next, using the primary key.
+func queryStreamInfo(
+	ctx context.Context,
+	db *sql.DB,
+	storeID uint64,
+	stream string,
+) (gospel.StreamInfo, error) {
+	info := gospel.StreamInfo{
+		Next: gospel.Address{Stream: stream},
+	}
+
+	row := db.QueryRowContext(
+		ctx,
+		`SELECT
+			s.next,
+			(
+				SELECT f.time
+				FROM fact AS f
+				WHERE f.store_id = s.store_id
+					AND f.stream = s.name
+					AND f."offset" = 0
+			),
+			(
+				SELECT f.time
+				FROM fact AS f
+				WHERE f.store_id = s.store_id
+					AND f.stream = s.name
+					AND f."offset" = s.next - 1
+			)
+		FROM stream AS s
+		WHERE s.store_id = ?
+			AND s.name = ?`,
+		storeID,
+		stream,
+	)
+
+	var first, last sql.NullInt64
+
+	err := row.Scan(
+		&info.Next.Offset,
+		&first,
+		&last,
+	)
+
+	if err == sql.ErrNoRows {
+		return info, nil
+	} else if err != nil {
+		return info, err
+	}
+
+	info.Exists = info.Next.Offset > 0
+	info.FactCount = info.Next.Offset
+
+	if first.Valid {
+		info.FirstFactTime = time.Unix(0, first.Int64)
+	}
+
+	if last.Valid {
+		info.LastFactTime = time.Unix(0, last.Int64)
+	}
+
+	return info, nil
+}
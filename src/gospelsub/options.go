@@ -0,0 +1,60 @@
+package gospelsub
+
+import (
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/internal/options"
+)
+
+// Option is a function that applies a subscription option to a
+// SubscriptionOptions struct.
+type Option = options.SubscriptionOption
+
+// CommitEvery is a subscription option that commits the subscription's
+// checkpoint after every n facts are handled.
+//
+// It can be combined with CommitInterval, in which case the checkpoint is
+// committed whenever either condition is met. If neither option is used, the
+// checkpoint is committed after every fact.
+//
+// It panics if n is less than one.
+func CommitEvery(n int) Option {
+	if n < 1 {
+		panic("commit count must be at least one")
+	}
+
+	return func(o *options.SubscriptionOptions) {
+		o.CommitEvery = n
+	}
+}
+
+// CommitInterval is a subscription option that commits the subscription's
+// checkpoint at a regular interval, if any facts have been handled since the
+// last commit.
+//
+// It can be combined with CommitEvery, in which case the checkpoint is
+// committed whenever either condition is met. A d of zero is treated as though
+// the option were not used.
+//
+// It panics if d is negative.
+func CommitInterval(d time.Duration) Option {
+	if d < 0 {
+		panic("commit interval must not be negative")
+	}
+
+	return func(o *options.SubscriptionOptions) {
+		o.CommitInterval = d
+	}
+}
+
+// ReaderOptions is a subscription option that applies reader options to the
+// reader used by the subscription.
+//
+// Multiple ReaderOptions options can be combined, the reader options are
+// applied in the order given.
+func ReaderOptions(opts ...gospel.ReaderOption) Option {
+	return func(o *options.SubscriptionOptions) {
+		o.ReaderOptions = append(o.ReaderOptions, opts...)
+	}
+}
@@ -0,0 +1,37 @@
+package gospelsub_test
+
+import (
+	"time"
+
+	. "github.com/jmalloc/gospel/src/gospelsub"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CommitEvery", func() {
+	It("panics if n is zero", func() {
+		Expect(func() {
+			CommitEvery(0)
+		}).To(Panic())
+	})
+
+	It("panics if n is negative", func() {
+		Expect(func() {
+			CommitEvery(-1)
+		}).To(Panic())
+	})
+})
+
+var _ = Describe("CommitInterval", func() {
+	It("panics if d is negative", func() {
+		Expect(func() {
+			CommitInterval(-time.Second)
+		}).To(Panic())
+	})
+
+	It("does not panic if d is zero", func() {
+		Expect(func() {
+			CommitInterval(0)
+		}).NotTo(Panic())
+	})
+})
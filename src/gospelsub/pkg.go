@@ -0,0 +1,6 @@
+// Package gospelsub provides durable subscriptions to gospel event streams.
+//
+// A subscription reads facts from a stream and passes them to a handler,
+// periodically saving the address it has reached to a checkpoint store, so
+// that it can resume reading from the same place after a restart.
+package gospelsub
@@ -0,0 +1,186 @@
+package gospelsub
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/internal/options"
+	"go.uber.org/multierr"
+)
+
+// Handler is a function that handles a fact delivered by a subscription.
+//
+// If it returns an error, the subscription stops.
+type Handler func(ctx context.Context, f gospel.Fact) error
+
+// Subscription reads facts from a stream and passes them to a Handler,
+// persisting its progress to a checkpoint store.
+//
+// Facts are delivered at least once. Any facts that are handled after the most
+// recent commit are delivered again when the subscription is restarted.
+type Subscription struct {
+	// store is the event store that facts are read from.
+	store gospel.EventStore
+
+	// checkpoints is the store used to persist the subscription's progress.
+	checkpoints gospel.CheckpointStore
+
+	// name is the name under which the subscription's checkpoint is saved.
+	name string
+
+	// stream is the stream to read when no checkpoint has been saved.
+	stream string
+
+	// opts contains the subscription options.
+	opts *options.SubscriptionOptions
+}
+
+// flushTimeout is the time allowed to commit the subscription's checkpoint
+// when it stops because the context passed to Run() is canceled.
+const flushTimeout = 5 * time.Second
+
+// New returns a subscription that reads facts from a stream of es, saving its
+// checkpoint to cs under the given name.
+//
+// Reading begins at the start of the stream if no checkpoint has been saved,
+// otherwise it resumes from the checkpoint.
+func New(
+	es gospel.EventStore,
+	cs gospel.CheckpointStore,
+	name, stream string,
+	opts ...Option,
+) *Subscription {
+	return &Subscription{
+		es,
+		cs,
+		name,
+		stream,
+		options.NewSubscriptionOptions(opts),
+	}
+}
+
+// Run reads facts and passes them to h, committing the checkpoint according
+// to the subscription's commit policy.
+//
+// It blocks until the reader reaches the end of the range it was opened to
+// read, ctx is canceled, or an error occurs. Progress that has not yet been
+// committed is committed before it returns, even if ctx is canceled.
+//
+// It returns nil if the reader reaches the end of its range, otherwise it
+// returns the error that caused it to stop.
+func (s *Subscription) Run(ctx context.Context, h Handler) error {
+	addr, ok, err := s.checkpoints.LoadCheckpoint(ctx, s.name)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		addr = gospel.Address{Stream: s.stream}
+	}
+
+	r, err := s.store.Open(ctx, addr, s.opts.ReaderOptions...)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var p progress
+
+	readCtx, cancel := context.WithCancel(ctx)
+	items, result := gospel.Channel(readCtx, r, 0)
+
+	err = s.consume(ctx, h, items, &p)
+
+	// Stop the reader and wait for it to finish, in case consume() returned
+	// before the items channel was closed.
+	cancel()
+	if e := <-result; err == nil {
+		err = e
+	}
+
+	return multierr.Append(err, s.flush(ctx, &p))
+}
+
+// progress tracks the facts that have been handled since the last commit.
+type progress struct {
+	// next is the checkpoint to commit.
+	next gospel.Address
+
+	// pending is the number of facts handled since the last commit.
+	pending int
+}
+
+// consume passes facts from items to h until the items channel is closed, or
+// an error occurs.
+func (s *Subscription) consume(
+	ctx context.Context,
+	h Handler,
+	items <-chan gospel.Item,
+	p *progress,
+) error {
+	var tick <-chan time.Time
+
+	if s.opts.CommitInterval > 0 {
+		t := time.NewTicker(s.opts.CommitInterval)
+		defer t.Stop()
+		tick = t.C
+	}
+
+	for {
+		select {
+		case i, ok := <-items:
+			if !ok {
+				return nil
+			}
+
+			if err := h(ctx, i.Fact); err != nil {
+				return err
+			}
+
+			p.next = i.Next
+			p.pending++
+
+			if s.opts.CommitEvery > 0 && p.pending >= s.opts.CommitEvery {
+				if err := s.commit(ctx, p); err != nil {
+					return err
+				}
+			}
+
+		case <-tick:
+			if err := s.commit(ctx, p); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// flush commits any pending progress after the subscription has stopped.
+//
+// If ctx has already been canceled, a new context is used so that the
+// progress is not lost.
+func (s *Subscription) flush(ctx context.Context, p *progress) error {
+	if ctx.Err() != nil {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(context.Background(), flushTimeout)
+		defer cancel()
+	}
+
+	return s.commit(ctx, p)
+}
+
+// commit saves the checkpoint, if any facts have been handled since the last
+// commit.
+func (s *Subscription) commit(ctx context.Context, p *progress) error {
+	if p.pending == 0 {
+		return nil
+	}
+
+	if err := s.checkpoints.SaveCheckpoint(ctx, s.name, p.next); err != nil {
+		return err
+	}
+
+	p.pending = 0
+
+	return nil
+}
@@ -0,0 +1,256 @@
+package gospelsub_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/gospelmem"
+	. "github.com/jmalloc/gospel/src/gospelsub"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// countingCheckpointStore is a CheckpointStore that records each saved
+// checkpoint.
+type countingCheckpointStore struct {
+	gospel.CheckpointStore
+
+	m     sync.Mutex
+	saved []gospel.Address
+}
+
+func (cs *countingCheckpointStore) SaveCheckpoint(
+	ctx context.Context,
+	name string,
+	addr gospel.Address,
+) error {
+	cs.m.Lock()
+	cs.saved = append(cs.saved, addr)
+	cs.m.Unlock()
+
+	return cs.CheckpointStore.SaveCheckpoint(ctx, name, addr)
+}
+
+func (cs *countingCheckpointStore) Saved() []gospel.Address {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	return append([]gospel.Address(nil), cs.saved...)
+}
+
+var _ = Describe("Subscription", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+
+		client      *gospelmem.Client
+		store       *gospelmem.EventStore
+		checkpoints *countingCheckpointStore
+		bodies      []string
+	)
+
+	// handle is a Handler that records the body of each fact.
+	handle := func(ctx context.Context, f gospel.Fact) error {
+		bodies = append(bodies, string(f.Event.Body))
+		return nil
+	}
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 5*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+
+		client = gospelmem.NewClient()
+
+		var err error
+		store, err = client.OpenStore(ctx, "test")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		checkpoints = &countingCheckpointStore{CheckpointStore: store}
+		bodies = nil
+
+		_, err = store.AppendUnchecked(
+			ctx,
+			"test-stream",
+			gospel.Event{EventType: "event-type-1", Body: []byte("event-1")},
+			gospel.Event{EventType: "event-type-2", Body: []byte("event-2")},
+			gospel.Event{EventType: "event-type-3", Body: []byte("event-3")},
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		cancel()
+		client.Close()
+	})
+
+	Describe("Run", func() {
+		It("reads from the start of the stream if there is no checkpoint", func() {
+			sub := New(
+				store,
+				checkpoints,
+				"test-consumer",
+				"test-stream",
+				ReaderOptions(gospel.StopAtHead()),
+			)
+
+			err := sub.Run(ctx, handle)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(bodies).To(Equal([]string{"event-1", "event-2", "event-3"}))
+		})
+
+		It("resumes reading from the checkpoint", func() {
+			err := store.SaveCheckpoint(
+				ctx,
+				"test-consumer",
+				gospel.Address{Stream: "test-stream", Offset: 2},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			sub := New(
+				store,
+				checkpoints,
+				"test-consumer",
+				"test-stream",
+				ReaderOptions(gospel.StopAtHead()),
+			)
+
+			err = sub.Run(ctx, handle)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(bodies).To(Equal([]string{"event-3"}))
+		})
+
+		It("commits after every fact by default", func() {
+			sub := New(
+				store,
+				checkpoints,
+				"test-consumer",
+				"test-stream",
+				ReaderOptions(gospel.StopAtHead()),
+			)
+
+			err := sub.Run(ctx, handle)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(checkpoints.Saved()).To(Equal([]gospel.Address{
+				{Stream: "test-stream", Offset: 1},
+				{Stream: "test-stream", Offset: 2},
+				{Stream: "test-stream", Offset: 3},
+			}))
+		})
+
+		It("commits after the given number of facts", func() {
+			sub := New(
+				store,
+				checkpoints,
+				"test-consumer",
+				"test-stream",
+				CommitEvery(2),
+				ReaderOptions(gospel.StopAtHead()),
+			)
+
+			err := sub.Run(ctx, handle)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(checkpoints.Saved()).To(Equal([]gospel.Address{
+				{Stream: "test-stream", Offset: 2},
+				{Stream: "test-stream", Offset: 3}, // committed when Run() returns
+			}))
+		})
+
+		It("commits at the given interval", func() {
+			sub := New(
+				store,
+				checkpoints,
+				"test-consumer",
+				"test-stream",
+				CommitInterval(10*time.Millisecond),
+			)
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			result := make(chan error, 1)
+			go func() {
+				result <- sub.Run(ctx, func(context.Context, gospel.Fact) error {
+					return nil
+				})
+			}()
+
+			Eventually(checkpoints.Saved).Should(Equal([]gospel.Address{
+				{Stream: "test-stream", Offset: 3},
+			}))
+
+			cancel()
+			Expect(<-result).To(Equal(context.Canceled))
+		})
+
+		It("commits the progress made before the handler fails", func() {
+			e := errors.New("<error>")
+
+			sub := New(
+				store,
+				checkpoints,
+				"test-consumer",
+				"test-stream",
+				CommitEvery(10),
+			)
+
+			err := sub.Run(ctx, func(ctx context.Context, f gospel.Fact) error {
+				if f.Addr.Offset == 2 {
+					return e
+				}
+
+				return nil
+			})
+
+			Expect(err).To(Equal(e))
+
+			addr, ok, err := store.LoadCheckpoint(ctx, "test-consumer")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(addr).To(Equal(gospel.Address{Stream: "test-stream", Offset: 2}))
+		})
+
+		It("commits the progress made before the context is canceled", func() {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			sub := New(
+				store,
+				checkpoints,
+				"test-consumer",
+				"test-stream",
+				CommitEvery(10),
+			)
+
+			err := sub.Run(ctx, func(ctx context.Context, f gospel.Fact) error {
+				if f.Addr.Offset == 1 {
+					cancel()
+				}
+
+				return nil
+			})
+
+			Expect(err).To(Equal(context.Canceled))
+
+			addr, _, err := store.LoadCheckpoint(context.Background(), "test-consumer")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(addr.Offset).To(BeNumerically(">=", 2))
+		})
+
+		It("returns an error if the checkpoint can not be loaded", func() {
+			client.Close()
+
+			sub := New(store, checkpoints, "test-consumer", "test-stream")
+			err := sub.Run(ctx, handle)
+
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,125 @@
+package gospeltest
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// DescribeCheckpointStore declares a suite of tests that verify the behavior of
+// the checkpoint stores produced by factory against the gospel.CheckpointStore
+// contract.
+func DescribeCheckpointStore(factory CheckpointFactory) bool {
+	return Describe("CheckpointStore conformance", func() {
+		var (
+			ctx    context.Context
+			cancel func()
+
+			store gospel.CheckpointStore
+			done  func()
+		)
+
+		BeforeEach(func() {
+			var fn func()
+			ctx, fn = context.WithTimeout(context.Background(), 5*time.Second)
+			cancel = fn // defeat go vet warning about unused cancel func
+
+			store, done = factory()
+		})
+
+		AfterEach(func() {
+			cancel()
+			done()
+		})
+
+		Describe("LoadCheckpoint", func() {
+			It("returns ok == false if no checkpoint has been saved", func() {
+				_, ok, err := store.LoadCheckpoint(ctx, "test-consumer")
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(ok).To(BeFalse())
+			})
+
+			It("returns the saved checkpoint", func() {
+				addr := gospel.Address{
+					Stream: "test-stream",
+					Offset: 123,
+				}
+
+				err := store.SaveCheckpoint(ctx, "test-consumer", addr)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				cp, ok, err := store.LoadCheckpoint(ctx, "test-consumer")
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(ok).To(BeTrue())
+				Expect(cp).To(Equal(addr))
+			})
+
+			It("returns checkpoints on the ε-stream", func() {
+				addr := gospel.Address{
+					Stream: "",
+					Offset: 123,
+				}
+
+				err := store.SaveCheckpoint(ctx, "test-consumer", addr)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				cp, ok, err := store.LoadCheckpoint(ctx, "test-consumer")
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(ok).To(BeTrue())
+				Expect(cp).To(Equal(addr))
+			})
+		})
+
+		Describe("SaveCheckpoint", func() {
+			It("replaces the existing checkpoint", func() {
+				err := store.SaveCheckpoint(
+					ctx,
+					"test-consumer",
+					gospel.Address{Stream: "test-stream", Offset: 1},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				addr := gospel.Address{
+					Stream: "test-stream",
+					Offset: 2,
+				}
+
+				err = store.SaveCheckpoint(ctx, "test-consumer", addr)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				cp, _, err := store.LoadCheckpoint(ctx, "test-consumer")
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(cp).To(Equal(addr))
+			})
+
+			It("does not affect the checkpoints of other consumers", func() {
+				addr := gospel.Address{
+					Stream: "test-stream",
+					Offset: 1,
+				}
+
+				err := store.SaveCheckpoint(ctx, "test-consumer", addr)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				err = store.SaveCheckpoint(
+					ctx,
+					"other-consumer",
+					gospel.Address{Stream: "other-stream", Offset: 2},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				cp, _, err := store.LoadCheckpoint(ctx, "test-consumer")
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(cp).To(Equal(addr))
+			})
+		})
+	})
+}
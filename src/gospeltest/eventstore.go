@@ -0,0 +1,1151 @@
+package gospeltest
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// DescribeEventStore declares a suite of tests that verify the behavior of the
+// event stores produced by factory against the gospel.EventStore contract.
+func DescribeEventStore(factory Factory) bool {
+	return Describe("EventStore conformance", func() {
+		var (
+			ctx    context.Context
+			cancel func()
+
+			store gospel.EventStore
+			done  func()
+		)
+
+		BeforeEach(func() {
+			var fn func()
+			ctx, fn = context.WithTimeout(context.Background(), 5*time.Second)
+			cancel = fn // defeat go vet warning about unused cancel func
+
+			store, done = factory()
+		})
+
+		AfterEach(func() {
+			cancel()
+			done()
+		})
+
+		Describe("Append", func() {
+			Context("when the stream is empty", func() {
+				next := gospel.Address{
+					Stream: "test-stream",
+					Offset: 0,
+				}
+
+				It("returns the next address", func() {
+					nx, err := store.Append(
+						ctx,
+						next,
+						gospel.Event{},
+					)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(nx).To(Equal(
+						next.Next(),
+					))
+				})
+
+				It("returns the next address when appending multiple events", func() {
+					nx, err := store.Append(
+						ctx,
+						next,
+						gospel.Event{},
+						gospel.Event{},
+					)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(nx).To(Equal(
+						next.Next().Next(),
+					))
+				})
+
+				It("returns a conflict error when the offset is too high", func() {
+					_, err := store.Append(
+						ctx,
+						next.Next(),
+						gospel.Event{},
+					)
+
+					Expect(err).Should(HaveOccurred())
+					Expect(gospel.IsConflict(err)).To(BeTrue())
+				})
+
+				It("reports that the stream does not exist when there is a conflict", func() {
+					_, err := store.Append(
+						ctx,
+						next.Next(),
+						gospel.Event{},
+					)
+
+					e, ok := err.(gospel.ConflictError)
+					Expect(ok).To(BeTrue())
+
+					nx, exists := e.ConflictHead()
+					Expect(nx).To(Equal(next))
+					Expect(exists).To(BeFalse())
+				})
+			})
+
+			Context("when the stream is not empty", func() {
+				var next gospel.Address
+
+				BeforeEach(func() {
+					nx, err := store.Append(
+						ctx,
+						gospel.Address{
+							Stream: "test-stream",
+							Offset: 0,
+						},
+						gospel.Event{},
+						gospel.Event{},
+					)
+					Expect(err).ShouldNot(HaveOccurred())
+					next = nx
+				})
+
+				It("returns the next address", func() {
+					nx, err := store.Append(
+						ctx,
+						next,
+						gospel.Event{},
+					)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(nx).To(Equal(
+						next.Next(),
+					))
+				})
+
+				It("returns the next address when appending multiple events", func() {
+					nx, err := store.Append(
+						ctx,
+						next,
+						gospel.Event{},
+						gospel.Event{},
+					)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(nx).To(Equal(
+						next.Next().Next(),
+					))
+				})
+
+				It("returns a conflict error when the offset is too high", func() {
+					_, err := store.Append(
+						ctx,
+						next.Next(),
+						gospel.Event{},
+					)
+
+					Expect(err).Should(HaveOccurred())
+					Expect(gospel.IsConflict(err)).To(BeTrue())
+				})
+
+				It("returns a conflict error when the offset is too low", func() {
+					addr := gospel.Address{
+						Stream: "test-stream",
+						Offset: 0,
+					}
+
+					_, err := store.Append(
+						ctx,
+						addr,
+						gospel.Event{},
+					)
+
+					Expect(err).Should(HaveOccurred())
+					Expect(gospel.IsConflict(err)).To(BeTrue())
+				})
+
+				It("reports the actual next offset when there is a conflict", func() {
+					_, err := store.Append(
+						ctx,
+						next.Next(),
+						gospel.Event{},
+					)
+
+					e, ok := err.(gospel.ConflictError)
+					Expect(ok).To(BeTrue())
+
+					nx, exists := e.ConflictHead()
+					Expect(nx).To(Equal(next))
+					Expect(exists).To(BeTrue())
+				})
+			})
+
+			It("panics if called with no events", func() {
+				Expect(func() {
+					store.Append(
+						ctx,
+						gospel.Address{Stream: "test-stream"},
+					)
+				}).To(Panic())
+			})
+
+			It("panics if called with an ε-stream address", func() {
+				Expect(func() {
+					store.Append(
+						ctx,
+						gospel.Address{Stream: ""},
+						gospel.Event{},
+					)
+				}).To(Panic())
+			})
+
+			It("does not produce any facts when there is a conflict", func() {
+				// append event at +0
+				_, err := store.Append(
+					ctx,
+					gospel.Address{
+						Stream: "test-stream",
+						Offset: 0,
+					},
+					gospel.Event{EventType: "event-type-1"},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				// append a second event at +0, expected to conflict
+				_, err = store.Append(
+					ctx,
+					gospel.Address{
+						Stream: "test-stream",
+						Offset: 0,
+					},
+					gospel.Event{EventType: "event-type-2"},
+				)
+				Expect(gospel.IsConflict(err)).To(BeTrue())
+
+				// append a second event at +1, expected to still be unused
+				_, err = store.Append(
+					ctx,
+					gospel.Address{
+						Stream: "test-stream",
+						Offset: 1,
+					},
+					gospel.Event{EventType: "event-type-3"},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				Expect(
+					readEventTypes(ctx, store, gospel.Address{Stream: "test-stream"}, 2),
+				).To(Equal([]string{
+					"event-type-1",
+					"event-type-3",
+				}))
+			})
+
+			It("does not produce any facts on the ε-stream when there is a conflict", func() {
+				_, err := store.Append(
+					ctx,
+					gospel.Address{
+						Stream: "test-stream",
+						Offset: 1,
+					},
+					gospel.Event{EventType: "event-type-1"},
+				)
+				Expect(gospel.IsConflict(err)).To(BeTrue())
+
+				_, err = store.Append(
+					ctx,
+					gospel.Address{
+						Stream: "test-stream",
+						Offset: 0,
+					},
+					gospel.Event{EventType: "event-type-2"},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				Expect(
+					readEventTypes(
+						ctx,
+						store,
+						gospel.Address{},
+						2,
+						gospel.FilterByEventType(
+							"$stream.created",
+							"event-type-1",
+							"event-type-2",
+						),
+					),
+				).To(Equal([]string{
+					"$stream.created",
+					"event-type-2",
+				}))
+			})
+		})
+
+		Describe("AppendUnchecked", func() {
+			Context("when the stream is empty", func() {
+				next := gospel.Address{
+					Stream: "test-stream",
+					Offset: 0,
+				}
+
+				It("returns the next address", func() {
+					nx, err := store.AppendUnchecked(
+						ctx,
+						"test-stream",
+						gospel.Event{},
+					)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(nx).To(Equal(
+						next.Next(),
+					))
+				})
+
+				It("returns the next address when appending multiple events", func() {
+					nx, err := store.AppendUnchecked(
+						ctx,
+						"test-stream",
+						gospel.Event{},
+						gospel.Event{},
+					)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(nx).To(Equal(
+						next.Next().Next(),
+					))
+				})
+			})
+
+			Context("when the stream is not empty", func() {
+				var next gospel.Address
+
+				BeforeEach(func() {
+					nx, err := store.Append(
+						ctx,
+						gospel.Address{
+							Stream: "test-stream",
+							Offset: 0,
+						},
+						gospel.Event{},
+						gospel.Event{},
+					)
+					Expect(err).ShouldNot(HaveOccurred())
+					next = nx
+				})
+
+				It("returns the next address", func() {
+					nx, err := store.AppendUnchecked(
+						ctx,
+						"test-stream",
+						gospel.Event{},
+					)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(nx).To(Equal(
+						next.Next(),
+					))
+				})
+
+				It("returns the next address when appending multiple events", func() {
+					nx, err := store.AppendUnchecked(
+						ctx,
+						"test-stream",
+						gospel.Event{},
+						gospel.Event{},
+					)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(nx).To(Equal(
+						next.Next().Next(),
+					))
+				})
+			})
+
+			It("panics if called with no events", func() {
+				Expect(func() {
+					store.AppendUnchecked(
+						ctx,
+						"test-stream",
+					)
+				}).To(Panic())
+			})
+
+			It("panics if called with the ε-stream", func() {
+				Expect(func() {
+					store.AppendUnchecked(
+						ctx,
+						"",
+						gospel.Event{},
+					)
+				}).To(Panic())
+			})
+		})
+
+		Describe("AppendExpected", func() {
+			Context("when the stream is empty", func() {
+				next := gospel.Address{
+					Stream: "test-stream",
+					Offset: 0,
+				}
+
+				It("appends when any offset is expected", func() {
+					nx, err := store.AppendExpected(
+						ctx,
+						"test-stream",
+						gospel.ExpectAnyOffset(),
+						gospel.Event{},
+					)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(nx).To(Equal(next.Next()))
+				})
+
+				It("appends when the stream is expected not to exist", func() {
+					nx, err := store.AppendExpected(
+						ctx,
+						"test-stream",
+						gospel.ExpectNoStream(),
+						gospel.Event{},
+						gospel.Event{},
+					)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(nx).To(Equal(next.Next().Next()))
+				})
+
+				It("appends when offset 0 is expected", func() {
+					nx, err := store.AppendExpected(
+						ctx,
+						"test-stream",
+						gospel.ExpectOffset(0),
+						gospel.Event{},
+					)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(nx).To(Equal(next.Next()))
+				})
+
+				It("appends when the offset is expected to be at most some value", func() {
+					nx, err := store.AppendExpected(
+						ctx,
+						"test-stream",
+						gospel.ExpectOffsetAtMost(5),
+						gospel.Event{},
+					)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(nx).To(Equal(next.Next()))
+				})
+
+				It("returns a conflict error when the stream is expected to exist", func() {
+					_, err := store.AppendExpected(
+						ctx,
+						"test-stream",
+						gospel.ExpectStreamExists(),
+						gospel.Event{},
+					)
+
+					Expect(gospel.IsConflict(err)).To(BeTrue())
+
+					e := err.(gospel.ConflictError)
+					nx, exists := e.ConflictHead()
+					Expect(nx).To(Equal(next))
+					Expect(exists).To(BeFalse())
+				})
+
+				It("returns a conflict error when a non-zero offset is expected", func() {
+					_, err := store.AppendExpected(
+						ctx,
+						"test-stream",
+						gospel.ExpectOffset(1),
+						gospel.Event{},
+					)
+
+					Expect(gospel.IsConflict(err)).To(BeTrue())
+				})
+			})
+
+			Context("when the stream is not empty", func() {
+				var next gospel.Address
+
+				BeforeEach(func() {
+					nx, err := store.Append(
+						ctx,
+						gospel.Address{
+							Stream: "test-stream",
+							Offset: 0,
+						},
+						gospel.Event{},
+						gospel.Event{},
+					)
+					Expect(err).ShouldNot(HaveOccurred())
+					next = nx
+				})
+
+				It("appends when any offset is expected", func() {
+					nx, err := store.AppendExpected(
+						ctx,
+						"test-stream",
+						gospel.ExpectAnyOffset(),
+						gospel.Event{},
+					)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(nx).To(Equal(next.Next()))
+				})
+
+				It("appends when the stream is expected to exist", func() {
+					nx, err := store.AppendExpected(
+						ctx,
+						"test-stream",
+						gospel.ExpectStreamExists(),
+						gospel.Event{},
+						gospel.Event{},
+					)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(nx).To(Equal(next.Next().Next()))
+				})
+
+				It("appends when the next unused offset is expected", func() {
+					nx, err := store.AppendExpected(
+						ctx,
+						"test-stream",
+						gospel.ExpectOffset(next.Offset),
+						gospel.Event{},
+					)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(nx).To(Equal(next.Next()))
+				})
+
+				It("appends when the offset is expected to be at most the next unused offset", func() {
+					nx, err := store.AppendExpected(
+						ctx,
+						"test-stream",
+						gospel.ExpectOffsetAtMost(next.Offset),
+						gospel.Event{},
+					)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(nx).To(Equal(next.Next()))
+				})
+
+				It("returns a conflict error when the stream is expected not to exist", func() {
+					_, err := store.AppendExpected(
+						ctx,
+						"test-stream",
+						gospel.ExpectNoStream(),
+						gospel.Event{},
+					)
+
+					Expect(gospel.IsConflict(err)).To(BeTrue())
+
+					e := err.(gospel.ConflictError)
+					nx, exists := e.ConflictHead()
+					Expect(nx).To(Equal(next))
+					Expect(exists).To(BeTrue())
+				})
+
+				It("returns a conflict error when the offset is expected to be lower", func() {
+					_, err := store.AppendExpected(
+						ctx,
+						"test-stream",
+						gospel.ExpectOffsetAtMost(next.Offset-1),
+						gospel.Event{},
+					)
+
+					Expect(gospel.IsConflict(err)).To(BeTrue())
+
+					e := err.(gospel.ConflictError)
+					addr, _ := e.ConflictDetails()
+					Expect(addr).To(Equal(gospel.Address{
+						Stream: "test-stream",
+						Offset: next.Offset - 1,
+					}))
+				})
+
+				It("returns a conflict error when a different offset is expected", func() {
+					_, err := store.AppendExpected(
+						ctx,
+						"test-stream",
+						gospel.ExpectOffset(next.Offset+1),
+						gospel.Event{},
+					)
+
+					Expect(gospel.IsConflict(err)).To(BeTrue())
+				})
+
+				It("does not produce any facts when there is a conflict", func() {
+					_, err := store.AppendExpected(
+						ctx,
+						"test-stream",
+						gospel.ExpectNoStream(),
+						gospel.Event{},
+					)
+					Expect(gospel.IsConflict(err)).To(BeTrue())
+
+					info, err := store.StreamInfo(ctx, "test-stream")
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(info.Next).To(Equal(next))
+				})
+			})
+
+			It("panics if called with no events", func() {
+				Expect(func() {
+					store.AppendExpected(
+						ctx,
+						"test-stream",
+						gospel.ExpectAnyOffset(),
+					)
+				}).To(Panic())
+			})
+
+			It("panics if called with the ε-stream", func() {
+				Expect(func() {
+					store.AppendExpected(
+						ctx,
+						"",
+						gospel.ExpectAnyOffset(),
+						gospel.Event{},
+					)
+				}).To(Panic())
+			})
+		})
+
+		Describe("AppendMulti", func() {
+			It("appends to each stream and returns the next address of each", func() {
+				nx, err := store.AppendMulti(
+					ctx,
+					gospel.StreamAppend{
+						Stream:      "test-stream-1",
+						Expectation: gospel.ExpectNoStream(),
+						Events: []gospel.Event{
+							{EventType: "event-type-1"},
+							{EventType: "event-type-2"},
+						},
+					},
+					gospel.StreamAppend{
+						Stream:      "test-stream-2",
+						Expectation: gospel.ExpectAnyOffset(),
+						Events: []gospel.Event{
+							{EventType: "event-type-3"},
+						},
+					},
+				)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(nx).To(Equal([]gospel.Address{
+					{Stream: "test-stream-1", Offset: 2},
+					{Stream: "test-stream-2", Offset: 1},
+				}))
+
+				Expect(
+					readEventTypes(
+						ctx,
+						store,
+						gospel.Address{Stream: "test-stream-1"},
+						2,
+					),
+				).To(Equal([]string{
+					"event-type-1",
+					"event-type-2",
+				}))
+
+				Expect(
+					readEventTypes(
+						ctx,
+						store,
+						gospel.Address{Stream: "test-stream-2"},
+						1,
+					),
+				).To(Equal([]string{
+					"event-type-3",
+				}))
+			})
+
+			It("checks each expectation against the preceding appends to the same stream", func() {
+				nx, err := store.AppendMulti(
+					ctx,
+					gospel.StreamAppend{
+						Stream:      "test-stream",
+						Expectation: gospel.ExpectNoStream(),
+						Events:      []gospel.Event{{}},
+					},
+					gospel.StreamAppend{
+						Stream:      "test-stream",
+						Expectation: gospel.ExpectOffset(1),
+						Events:      []gospel.Event{{}},
+					},
+				)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(nx).To(Equal([]gospel.Address{
+					{Stream: "test-stream", Offset: 1},
+					{Stream: "test-stream", Offset: 2},
+				}))
+			})
+
+			It("returns a conflict error identifying the stream that conflicted", func() {
+				_, err := store.AppendUnchecked(
+					ctx,
+					"test-stream-2",
+					gospel.Event{},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				_, err = store.AppendMulti(
+					ctx,
+					gospel.StreamAppend{
+						Stream:      "test-stream-1",
+						Expectation: gospel.ExpectNoStream(),
+						Events:      []gospel.Event{{}},
+					},
+					gospel.StreamAppend{
+						Stream:      "test-stream-2",
+						Expectation: gospel.ExpectNoStream(),
+						Events:      []gospel.Event{{}},
+					},
+				)
+
+				Expect(gospel.IsConflict(err)).To(BeTrue())
+
+				e := err.(gospel.ConflictError)
+				addr, _ := e.ConflictDetails()
+				Expect(addr.Stream).To(Equal("test-stream-2"))
+			})
+
+			It("does not produce any facts when there is a conflict", func() {
+				before, err := store.StreamInfo(ctx, "")
+				Expect(err).ShouldNot(HaveOccurred())
+
+				_, err = store.AppendMulti(
+					ctx,
+					gospel.StreamAppend{
+						Stream:      "test-stream-1",
+						Expectation: gospel.ExpectNoStream(),
+						Events:      []gospel.Event{{}},
+					},
+					gospel.StreamAppend{
+						Stream:      "test-stream-2",
+						Expectation: gospel.ExpectStreamExists(),
+						Events:      []gospel.Event{{}},
+					},
+				)
+				Expect(gospel.IsConflict(err)).To(BeTrue())
+
+				info, err := store.StreamInfo(ctx, "test-stream-1")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(info.Exists).To(BeFalse())
+
+				after, err := store.StreamInfo(ctx, "")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(after.Next).To(Equal(before.Next))
+			})
+
+			It("panics if called with no appends", func() {
+				Expect(func() {
+					store.AppendMulti(ctx)
+				}).To(Panic())
+			})
+
+			It("panics if called with no events for a stream", func() {
+				Expect(func() {
+					store.AppendMulti(
+						ctx,
+						gospel.StreamAppend{Stream: "test-stream"},
+					)
+				}).To(Panic())
+			})
+
+			It("panics if called with the ε-stream", func() {
+				Expect(func() {
+					store.AppendMulti(
+						ctx,
+						gospel.StreamAppend{
+							Stream: "",
+							Events: []gospel.Event{{}},
+						},
+					)
+				}).To(Panic())
+			})
+		})
+
+		Describe("event IDs", func() {
+			It("does not produce any facts when an append is repeated", func() {
+				nx, err := store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{ID: "id-1"},
+					gospel.Event{ID: "id-2"},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				rx, err := store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{ID: "id-1"},
+					gospel.Event{ID: "id-2"},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(rx).To(Equal(nx))
+
+				info, err := store.StreamInfo(ctx, "test-stream")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(info.Next).To(Equal(nx))
+			})
+
+			It("returns the original address when a checked append is repeated", func() {
+				nx, err := store.Append(
+					ctx,
+					gospel.Address{Stream: "test-stream"},
+					gospel.Event{ID: "id-1"},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				_, err = store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				rx, err := store.Append(
+					ctx,
+					gospel.Address{Stream: "test-stream"},
+					gospel.Event{ID: "id-1"},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(rx).To(Equal(nx))
+			})
+
+			It("returns the original addresses when a multi-stream append is repeated", func() {
+				appends := []gospel.StreamAppend{
+					{
+						Stream:      "test-stream-1",
+						Expectation: gospel.ExpectNoStream(),
+						Events:      []gospel.Event{{ID: "id-1"}},
+					},
+					{
+						Stream:      "test-stream-2",
+						Expectation: gospel.ExpectNoStream(),
+						Events:      []gospel.Event{{ID: "id-2"}},
+					},
+				}
+
+				nx, err := store.AppendMulti(ctx, appends...)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				rx, err := store.AppendMulti(ctx, appends...)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(rx).To(Equal(nx))
+			})
+
+			It("returns a conflict error if only some of the events have been appended", func() {
+				_, err := store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{ID: "id-1"},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				_, err = store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{ID: "id-1"},
+					gospel.Event{ID: "id-2"},
+				)
+				Expect(gospel.IsConflict(err)).To(BeTrue())
+
+				e := err.(gospel.ConflictError)
+				_, ev := e.ConflictDetails()
+				Expect(ev.ID).To(Equal("id-1"))
+			})
+
+			It("returns a conflict error if the events were appended to a different stream", func() {
+				_, err := store.AppendUnchecked(
+					ctx,
+					"test-stream-1",
+					gospel.Event{ID: "id-1"},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				_, err = store.AppendUnchecked(
+					ctx,
+					"test-stream-2",
+					gospel.Event{ID: "id-1"},
+				)
+				Expect(gospel.IsConflict(err)).To(BeTrue())
+
+				info, err := store.StreamInfo(ctx, "test-stream-2")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(info.Exists).To(BeFalse())
+			})
+
+			It("returns the event ID when the fact is read", func() {
+				_, err := store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{ID: "id-1"},
+					gospel.Event{},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				r, err := store.Open(ctx, gospel.Address{Stream: "test-stream"})
+				Expect(err).ShouldNot(HaveOccurred())
+				defer r.Close()
+
+				_, err = r.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(r.Get().Event.ID).To(Equal("id-1"))
+
+				_, err = r.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(r.Get().Event.ID).To(Equal(""))
+			})
+
+			It("returns a non-conflict error if two events in the same append have the same ID", func() {
+				_, err := store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{ID: "id-1"},
+					gospel.Event{ID: "id-1"},
+				)
+				Expect(err).Should(HaveOccurred())
+				Expect(gospel.IsConflict(err)).To(BeFalse())
+
+				info, err := store.StreamInfo(ctx, "test-stream")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(info.Exists).To(BeFalse())
+			})
+
+			It("does not append to any stream if two events in the same multi-stream append have the same ID", func() {
+				_, err := store.AppendMulti(
+					ctx,
+					gospel.StreamAppend{
+						Stream: "test-stream-1",
+						Events: []gospel.Event{{ID: "id-1"}},
+					},
+					gospel.StreamAppend{
+						Stream: "test-stream-2",
+						Events: []gospel.Event{{ID: "id-2"}, {ID: "id-2"}},
+					},
+				)
+				Expect(err).Should(HaveOccurred())
+				Expect(gospel.IsConflict(err)).To(BeFalse())
+
+				info, err := store.StreamInfo(ctx, "test-stream-1")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(info.Exists).To(BeFalse())
+			})
+		})
+
+		Describe("StreamInfo", func() {
+			It("reports that a stream does not exist if nothing has been appended to it", func() {
+				info, err := store.StreamInfo(ctx, "test-stream")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(info).To(Equal(gospel.StreamInfo{
+					Next: gospel.Address{Stream: "test-stream"},
+				}))
+			})
+
+			It("reports the next offset and fact count of an existing stream", func() {
+				_, err := store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{EventType: "event-type-1"},
+					gospel.Event{EventType: "event-type-2"},
+					gospel.Event{EventType: "event-type-3"},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				info, err := store.StreamInfo(ctx, "test-stream")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(info.Exists).To(BeTrue())
+				Expect(info.Next).To(Equal(gospel.Address{Stream: "test-stream", Offset: 3}))
+				Expect(info.FactCount).To(BeEquivalentTo(3))
+			})
+
+			It("reports the times of the first and last facts", func() {
+				_, err := store.AppendUnchecked(ctx, "test-stream", gospel.Event{EventType: "event-type-1"})
+				Expect(err).ShouldNot(HaveOccurred())
+
+				_, err = store.AppendUnchecked(ctx, "test-stream", gospel.Event{EventType: "event-type-2"})
+				Expect(err).ShouldNot(HaveOccurred())
+
+				r, err := store.Open(ctx, gospel.Address{Stream: "test-stream"})
+				Expect(err).ShouldNot(HaveOccurred())
+				defer r.Close()
+
+				_, err = r.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				first := r.Get().Time
+
+				_, err = r.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				last := r.Get().Time
+
+				info, err := store.StreamInfo(ctx, "test-stream")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(info.FirstFactTime).To(BeTemporally("==", first))
+				Expect(info.LastFactTime).To(BeTemporally("==", last))
+			})
+
+			It("returns an address that can be used to append to the stream", func() {
+				_, err := store.AppendUnchecked(ctx, "test-stream", gospel.Event{EventType: "event-type-1"})
+				Expect(err).ShouldNot(HaveOccurred())
+
+				info, err := store.StreamInfo(ctx, "test-stream")
+				Expect(err).ShouldNot(HaveOccurred())
+
+				_, err = store.Append(ctx, info.Next, gospel.Event{EventType: "event-type-2"})
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("reports that the ε-stream exists", func() {
+				info, err := store.StreamInfo(ctx, "")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(info.Exists).To(BeTrue())
+				Expect(info.Next.Offset).To(BeNumerically(">=", 1))
+				Expect(info.FactCount).To(Equal(info.Next.Offset))
+			})
+		})
+
+		Describe("ε-stream", func() {
+			It("contains a fact describing the creation of the store", func() {
+				r, err := store.Open(
+					ctx,
+					gospel.Address{},
+					gospel.FilterByEventType("$store.created"),
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+				defer r.Close()
+
+				_, err = r.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				f := r.Get()
+				Expect(f.Addr.Stream).To(Equal(""))
+				Expect(f.Event.ContentType).To(Equal("application/vnd.gospel.store.created.v1"))
+			})
+
+			It("contains facts for events appended to named streams, in order", func() {
+				_, err := store.Append(
+					ctx,
+					gospel.Address{Stream: "test-stream-1"},
+					gospel.Event{EventType: "event-type-1"},
+					gospel.Event{EventType: "event-type-2"},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				_, err = store.AppendUnchecked(
+					ctx,
+					"test-stream-2",
+					gospel.Event{EventType: "event-type-3"},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				Expect(
+					readEventTypes(
+						ctx,
+						store,
+						gospel.Address{},
+						3,
+						gospel.FilterByEventType(
+							"event-type-1",
+							"event-type-2",
+							"event-type-3",
+						),
+					),
+				).To(Equal([]string{
+					"event-type-1",
+					"event-type-2",
+					"event-type-3",
+				}))
+			})
+
+			It("contains a fact describing the creation of each stream, before the stream's first event", func() {
+				_, err := store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{EventType: "event-type-1"},
+					gospel.Event{EventType: "event-type-2"},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				r, err := store.Open(
+					ctx,
+					gospel.Address{},
+					gospel.FilterByEventType(
+						"$stream.created",
+						"event-type-1",
+					),
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+				defer r.Close()
+
+				_, err = r.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				f := r.Get()
+				Expect(f.Event.EventType).To(Equal("$stream.created"))
+				Expect(f.Event.ContentType).To(Equal("application/vnd.gospel.stream.created.v1"))
+				Expect(f.Event.Body).To(Equal([]byte("test-stream")))
+
+				_, err = r.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(r.Get().Event.EventType).To(Equal("event-type-1"))
+			})
+
+			It("contains the same event data as the named stream", func() {
+				ev := gospel.Event{
+					EventType:   "event-type-1",
+					ContentType: "text/plain",
+					Body:        []byte("Hello, world!"),
+				}
+
+				_, err := store.AppendUnchecked(ctx, "test-stream", ev)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				r, err := store.Open(
+					ctx,
+					gospel.Address{},
+					gospel.FilterByEventType("event-type-1"),
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+				defer r.Close()
+
+				_, err = r.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(r.Get().Event).To(Equal(ev))
+			})
+		})
+	})
+}
+
+// readEventTypes opens a reader at addr and returns the event types of the
+// first n facts.
+func readEventTypes(
+	ctx context.Context,
+	es gospel.EventStore,
+	addr gospel.Address,
+	n int,
+	opts ...gospel.ReaderOption,
+) []string {
+	r, err := es.Open(ctx, addr, opts...)
+	Expect(err).ShouldNot(HaveOccurred())
+	defer r.Close()
+
+	var types []string
+
+	for len(types) < n {
+		_, err := r.Next(ctx)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		types = append(types, r.Get().Event.EventType)
+	}
+
+	return types
+}
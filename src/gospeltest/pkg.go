@@ -0,0 +1,32 @@
+// Package gospeltest contains a reusable test suite that verifies the behavior
+// of gospel.EventStore, gospel.Reader and gospel.CheckpointStore
+// implementations.
+//
+// The suite is written using Ginkgo and Gomega. Implementations declare the
+// suite from within their own Ginkgo test suite, providing a Factory that
+// produces event stores to test against:
+//
+//     var _ = gospeltest.DescribeEventStore(newTestStore)
+//     var _ = gospeltest.DescribeReader(newTestStore)
+//
+// Checkpoint stores are tested in the same way, using a CheckpointFactory:
+//
+//     var _ = gospeltest.DescribeCheckpointStore(newTestCheckpointStore)
+package gospeltest
+
+import "github.com/jmalloc/gospel/src/gospel"
+
+// Factory is a function that returns a new, empty event store for use within
+// a single test.
+//
+// done is called when the test is complete, and should release any resources
+// used by the store. Factory should panic if the store can not be created.
+type Factory func() (es gospel.EventStore, done func())
+
+// CheckpointFactory is a function that returns a new, empty checkpoint store
+// for use within a single test.
+//
+// done is called when the test is complete, and should release any resources
+// used by the store. CheckpointFactory should panic if the store can not be
+// created.
+type CheckpointFactory func() (cs gospel.CheckpointStore, done func())
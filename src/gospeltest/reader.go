@@ -0,0 +1,899 @@
+package gospeltest
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// DescribeReader declares a suite of tests that verify the behavior of the
+// readers opened on the event stores produced by factory against the
+// gospel.Reader contract.
+func DescribeReader(factory Factory) bool {
+	return Describe("Reader conformance", func() {
+		var (
+			ctx    context.Context
+			cancel func()
+
+			store  gospel.EventStore
+			done   func()
+			reader gospel.Reader
+
+			addr gospel.Address
+			opts []gospel.ReaderOption
+		)
+
+		BeforeEach(func() {
+			var fn func()
+			ctx, fn = context.WithTimeout(context.Background(), 5*time.Second)
+			cancel = fn // defeat go vet warning about unused cancel func
+
+			store, done = factory()
+
+			_, err := store.AppendUnchecked(
+				ctx,
+				"test-stream",
+				gospel.Event{EventType: "event-type-1", Body: []byte("event-1")},
+				gospel.Event{EventType: "event-type-2", Body: []byte("event-2")},
+				gospel.Event{EventType: "event-type-3", Body: []byte("event-3")},
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			addr = gospel.Address{
+				Stream: "test-stream",
+				Offset: 0,
+			}
+			opts = nil
+		})
+
+		JustBeforeEach(func() {
+			var err error
+			reader, err = store.Open(ctx, addr, opts...)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			cancel()
+			reader.Close()
+			done()
+		})
+
+		Describe("Next", func() {
+			It("returns the address of the next fact", func() {
+				nx, err := reader.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(nx).To(Equal(addr.Next()))
+			})
+
+			It("blocks until the deadline if there are no more facts to read", func() {
+				for i := 0; i < 3; i++ {
+					_, err := reader.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+				}
+
+				nextCtx, cancel := context.WithTimeout(ctx, 250*time.Millisecond)
+				defer cancel()
+
+				_, err := reader.Next(nextCtx)
+				Expect(err).To(Equal(context.DeadlineExceeded))
+			})
+
+			It("returns facts that are appended after the reader is opened", func() {
+				for i := 0; i < 3; i++ {
+					_, err := reader.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+				}
+
+				_, err := store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{EventType: "event-type-4", Body: []byte("event-4")},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				_, err = reader.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(reader.Get().Event.Body).To(Equal([]byte("event-4")))
+			})
+
+			It("returns an error if the reader is closed", func() {
+				reader.Close()
+
+				_, err := reader.Next(ctx)
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		Describe("TryNext", func() {
+			It("returns the address of the next fact", func() {
+				nx, ok, err := reader.TryNext(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(ok).To(BeTrue())
+				Expect(nx).To(Equal(addr.Next()))
+			})
+
+			It("returns ok == false when the end of the stream is reached", func() {
+				for i := 0; i < 3; i++ {
+					_, ok, err := reader.TryNext(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(ok).To(BeTrue())
+				}
+
+				_, ok, err := reader.TryNext(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(ok).To(BeFalse())
+			})
+
+			It("returns an error if the reader is closed", func() {
+				reader.Close()
+
+				_, _, err := reader.TryNext(ctx)
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		Describe("Get", func() {
+			It("panics if Next() has not been called", func() {
+				Expect(func() {
+					reader.Get()
+				}).To(Panic())
+			})
+
+			It("returns the current fact", func() {
+				_, err := reader.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				f := reader.Get()
+
+				Expect(f).To(Equal(gospel.Fact{
+					Addr:   addr,
+					Origin: addr,
+					Event: gospel.Event{
+						EventType: "event-type-1",
+						Body:      []byte("event-1"),
+					},
+					Time: f.Time, // perform fuzzy check for time below
+				}))
+
+				// Use a loose comparison for time, as the store may be using a
+				// separate clock, such as a database server running in a VM.
+				Expect(f.Time).To(
+					BeTemporally(
+						"~",
+						time.Now(),
+						1*time.Minute,
+					),
+				)
+			})
+
+			It("returns the expected facts", func() {
+				var bodies [][]byte
+
+				for len(bodies) < 3 {
+					_, err := reader.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					bodies = append(
+						bodies,
+						reader.Get().Event.Body,
+					)
+				}
+
+				Expect(bodies).To(Equal([][]byte{
+					[]byte("event-1"),
+					[]byte("event-2"),
+					[]byte("event-3"),
+				}))
+			})
+
+			It("returns the event metadata", func() {
+				_, err := store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{
+						EventType: "event-type-4",
+						Metadata: map[string]string{
+							"correlation-id": "<correlation>",
+							"causation-id":   "<causation>",
+						},
+					},
+					gospel.Event{
+						EventType: "event-type-5",
+						Metadata:  map[string]string{},
+					},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				var events []gospel.Event
+
+				for len(events) < 5 {
+					_, err := reader.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					events = append(events, reader.Get().Event)
+				}
+
+				Expect(events[3].Metadata).To(Equal(map[string]string{
+					"correlation-id": "<correlation>",
+					"causation-id":   "<causation>",
+				}))
+				Expect(events[4].Metadata).To(BeNil())
+			})
+
+			It("returns the same fact until next is called", func() {
+				_, err := reader.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				f1 := reader.Get()
+				f2 := reader.Get()
+				Expect(f2).To(Equal(f1))
+
+				_, err = reader.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				f3 := reader.Get()
+				Expect(f3).NotTo(Equal(f1))
+			})
+		})
+
+		Context("when opened part way through the stream", func() {
+			BeforeEach(func() {
+				addr.Offset = 1
+			})
+
+			It("begins reading at the given offset", func() {
+				nx, err := reader.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(nx).To(Equal(addr.Next()))
+				Expect(reader.Get().Addr).To(Equal(addr))
+				Expect(reader.Get().Event.Body).To(Equal([]byte("event-2")))
+			})
+		})
+
+		Context("when using an event-type filter", func() {
+			BeforeEach(func() {
+				opts = append(opts, gospel.FilterByEventType(
+					"event-type-1",
+					"event-type-3",
+				))
+			})
+
+			Describe("Next", func() {
+				It("returns the address of the next unfiltered fact", func() {
+					nx, err := reader.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					Expect(nx).To(SatisfyAny(
+						Equal(addr.Next().Next()), // second fact is filtered out
+						Equal(addr.Next()),        // implementation did not look ahead to the next fact
+					))
+				})
+
+				It("skips over filtered facts", func() {
+					var bodies [][]byte
+
+					for len(bodies) < 2 {
+						_, err := reader.Next(ctx)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						bodies = append(
+							bodies,
+							reader.Get().Event.Body,
+						)
+					}
+
+					Expect(bodies).To(Equal([][]byte{
+						[]byte("event-1"),
+						[]byte("event-3"),
+					}))
+				})
+
+				It("never returns an address before a filtered fact that has already been skipped", func() {
+					_, err := reader.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					nx, err := reader.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(reader.Get().Addr.Offset).To(BeNumerically("==", 2))
+					Expect(nx).To(Equal(addr.Next().Next().Next()))
+				})
+			})
+
+			Describe("TryNext", func() {
+				It("returns ok == false when the only remaining facts are filtered", func() {
+					for i := 0; i < 2; i++ {
+						_, ok, err := reader.TryNext(ctx)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(ok).To(BeTrue())
+					}
+
+					_, ok, err := reader.TryNext(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(ok).To(BeFalse())
+				})
+			})
+		})
+
+		Context("when using event-type prefix and pattern filters", func() {
+			BeforeEach(func() {
+				opts = append(
+					opts,
+					gospel.FilterByEventTypePrefix("event-type-2"),
+					gospel.FilterByEventTypePattern("*-3"),
+				)
+			})
+
+			It("skips over filtered facts", func() {
+				var bodies [][]byte
+
+				for len(bodies) < 2 {
+					_, err := reader.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					bodies = append(
+						bodies,
+						reader.Get().Event.Body,
+					)
+				}
+
+				Expect(bodies).To(Equal([][]byte{
+					[]byte("event-2"),
+					[]byte("event-3"),
+				}))
+			})
+		})
+
+		Context("when using an event-type prefix filter that contains SQL wildcards", func() {
+			BeforeEach(func() {
+				opts = append(
+					opts,
+					gospel.FilterByEventTypePrefix("event_type"),
+				)
+			})
+
+			It("does not treat the wildcards specially", func() {
+				_, ok, err := reader.TryNext(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(ok).To(BeFalse())
+			})
+		})
+
+		Context("when using an event-type pattern filter that matches multi-byte characters", func() {
+			BeforeEach(func() {
+				_, err := store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{EventType: "event-type-λ", Body: []byte("event-4")},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				opts = append(
+					opts,
+					gospel.FilterByEventTypePattern("event-type-??"),
+				)
+			})
+
+			It("matches a single byte for each '?'", func() {
+				_, err := reader.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(reader.Get().Addr.Offset).To(BeNumerically("==", 3))
+				Expect(reader.Get().Event.Body).To(Equal([]byte("event-4")))
+			})
+		})
+
+		Context("when excluding event types", func() {
+			BeforeEach(func() {
+				opts = append(opts, gospel.ExcludeEventTypes("event-type-2"))
+			})
+
+			It("skips over excluded facts", func() {
+				var bodies [][]byte
+
+				for len(bodies) < 2 {
+					_, err := reader.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					bodies = append(
+						bodies,
+						reader.Get().Event.Body,
+					)
+				}
+
+				Expect(bodies).To(Equal([][]byte{
+					[]byte("event-1"),
+					[]byte("event-3"),
+				}))
+			})
+
+			Context("when combined with an inclusive filter", func() {
+				BeforeEach(func() {
+					opts = append(opts, gospel.FilterByEventType(
+						"event-type-1",
+						"event-type-2",
+					))
+				})
+
+				It("skips over facts that are excluded or not included", func() {
+					_, err := reader.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(reader.Get().Event.Body).To(Equal([]byte("event-1")))
+
+					_, ok, err := reader.TryNext(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(ok).To(BeFalse())
+				})
+			})
+		})
+
+		Context("when using a content-type filter", func() {
+			BeforeEach(func() {
+				_, err := store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{EventType: "event-type-4", ContentType: "application/json", Body: []byte("event-4")},
+					gospel.Event{EventType: "event-type-5", ContentType: "text/plain", Body: []byte("event-5")},
+					gospel.Event{EventType: "event-type-6", ContentType: "application/vnd.foo.bar+json", Body: []byte("event-6")},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				opts = append(
+					opts,
+					gospel.FilterByContentType("application/json", "application/vnd.foo.*"),
+				)
+			})
+
+			It("skips over filtered facts", func() {
+				var bodies [][]byte
+
+				for len(bodies) < 2 {
+					_, err := reader.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					bodies = append(
+						bodies,
+						reader.Get().Event.Body,
+					)
+				}
+
+				Expect(bodies).To(Equal([][]byte{
+					[]byte("event-4"),
+					[]byte("event-6"),
+				}))
+
+				_, ok, err := reader.TryNext(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(ok).To(BeFalse())
+			})
+		})
+
+		Context("when using a stream filter", func() {
+			BeforeEach(func() {
+				_, err := store.AppendUnchecked(
+					ctx,
+					"other-stream",
+					gospel.Event{EventType: "event-type-x", Body: []byte("event-x")},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				_, err = store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{EventType: "event-type-4", Body: []byte("event-4")},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				opts = append(opts, gospel.FilterByStreamPattern("test-*"))
+			})
+
+			Context("when reading the ε-stream", func() {
+				BeforeEach(func() {
+					addr.Stream = ""
+				})
+
+				It("only returns facts that originated from matching streams", func() {
+					var bodies [][]byte
+
+					for len(bodies) < 4 {
+						nx, err := reader.Next(ctx)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(nx.Stream).To(Equal(""))
+						Expect(reader.Get().Addr.Stream).To(Equal(""))
+
+						bodies = append(
+							bodies,
+							reader.Get().Event.Body,
+						)
+					}
+
+					Expect(bodies).To(Equal([][]byte{
+						[]byte("event-1"),
+						[]byte("event-2"),
+						[]byte("event-3"),
+						[]byte("event-4"),
+					}))
+
+					_, ok, err := reader.TryNext(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(ok).To(BeFalse())
+				})
+			})
+
+			Context("when reading a named stream that matches the filter", func() {
+				It("returns all facts", func() {
+					for i := 0; i < 4; i++ {
+						_, err := reader.Next(ctx)
+						Expect(err).ShouldNot(HaveOccurred())
+					}
+				})
+			})
+
+			Context("when reading a named stream that does not match the filter", func() {
+				BeforeEach(func() {
+					addr.Stream = "other-stream"
+				})
+
+				It("does not return any facts", func() {
+					_, ok, err := reader.TryNext(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(ok).To(BeFalse())
+				})
+			})
+		})
+
+		Context("when reading the ε-stream", func() {
+			BeforeEach(func() {
+				addr.Stream = ""
+			})
+
+			It("returns the originating address of each fact", func() {
+				var origins []gospel.Address
+
+				for len(origins) < 5 {
+					_, err := reader.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					origins = append(origins, reader.Get().Origin)
+				}
+
+				Expect(origins).To(Equal([]gospel.Address{
+					{Stream: "", Offset: 0}, // $store.created
+					{Stream: "", Offset: 1}, // $stream.created
+					{Stream: "test-stream", Offset: 0},
+					{Stream: "test-stream", Offset: 1},
+					{Stream: "test-stream", Offset: 2},
+				}))
+			})
+		})
+
+		Context("when using a time filter", func() {
+			BeforeEach(func() {
+				// Ensure the next event is recorded at a later time than the
+				// events appended above.
+				time.Sleep(10 * time.Millisecond)
+
+				_, err := store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{EventType: "event-type-4", Body: []byte("event-4")},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				// Use the time of the new fact, as recorded by the store, so that
+				// the test is not sensitive to clock differences with the server.
+				r, err := store.Open(ctx, gospel.Address{Stream: "test-stream", Offset: 3})
+				Expect(err).ShouldNot(HaveOccurred())
+				defer r.Close()
+
+				_, err = r.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				opts = append(opts, gospel.FromTime(r.Get().Time))
+			})
+
+			It("skips over facts that occurred before the given time", func() {
+				_, err := reader.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(reader.Get().Addr.Offset).To(BeNumerically("==", 3))
+				Expect(reader.Get().Event.Body).To(Equal([]byte("event-4")))
+			})
+
+			It("skips over facts that occurred before the given time when reading in reverse", func() {
+				reader.Close()
+
+				var err error
+				reader, err = store.Open(
+					ctx,
+					gospel.Address{Stream: "test-stream", Offset: 4},
+					append(opts, gospel.Reverse())...,
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				_, err = reader.Next(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(reader.Get().Event.Body).To(Equal([]byte("event-4")))
+
+				_, err = reader.Next(ctx)
+				Expect(err).To(Equal(gospel.ErrEndOfRange))
+			})
+
+			Context("when no fact occurred at or after the given time", func() {
+				BeforeEach(func() {
+					r, err := store.Open(ctx, gospel.Address{Stream: "test-stream", Offset: 3})
+					Expect(err).ShouldNot(HaveOccurred())
+					defer r.Close()
+
+					_, err = r.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					opts = append(opts, gospel.FromTime(r.Get().Time.Add(time.Millisecond)))
+				})
+
+				It("returns facts that are appended later", func() {
+					_, ok, err := reader.TryNext(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(ok).To(BeFalse())
+
+					time.Sleep(10 * time.Millisecond)
+
+					_, err = store.AppendUnchecked(
+						ctx,
+						"test-stream",
+						gospel.Event{EventType: "event-type-5", Body: []byte("event-5")},
+					)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					_, err = reader.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(reader.Get().Addr.Offset).To(BeNumerically("==", 4))
+					Expect(reader.Get().Event.Body).To(Equal([]byte("event-5")))
+				})
+			})
+		})
+
+		Context("when reading in reverse", func() {
+			BeforeEach(func() {
+				addr.Offset = 3
+				opts = append(opts, gospel.Reverse())
+			})
+
+			Describe("Next", func() {
+				It("returns facts in reverse order", func() {
+					var bodies [][]byte
+
+					for i := 0; i < 3; i++ {
+						nx, err := reader.Next(ctx)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(nx).To(Equal(reader.Get().Addr))
+
+						bodies = append(
+							bodies,
+							reader.Get().Event.Body,
+						)
+					}
+
+					Expect(bodies).To(Equal([][]byte{
+						[]byte("event-3"),
+						[]byte("event-2"),
+						[]byte("event-1"),
+					}))
+				})
+
+				It("returns ErrEndOfRange when the beginning of the stream is reached", func() {
+					for i := 0; i < 3; i++ {
+						_, err := reader.Next(ctx)
+						Expect(err).ShouldNot(HaveOccurred())
+					}
+
+					_, err := reader.Next(ctx)
+					Expect(err).To(Equal(gospel.ErrEndOfRange))
+				})
+			})
+
+			Describe("TryNext", func() {
+				It("returns ErrEndOfRange when the beginning of the stream is reached", func() {
+					for i := 0; i < 3; i++ {
+						_, ok, err := reader.TryNext(ctx)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(ok).To(BeTrue())
+					}
+
+					_, ok, err := reader.TryNext(ctx)
+					Expect(err).To(Equal(gospel.ErrEndOfRange))
+					Expect(ok).To(BeFalse())
+				})
+			})
+
+			Context("when opened after the end of the stream", func() {
+				BeforeEach(func() {
+					addr.Offset = 100
+				})
+
+				It("begins reading at the most recent fact", func() {
+					_, err := reader.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(reader.Get().Addr.Offset).To(BeNumerically("==", 2))
+				})
+			})
+
+			Context("when opened at the beginning of the stream", func() {
+				BeforeEach(func() {
+					addr.Offset = 0
+				})
+
+				It("returns ErrEndOfRange", func() {
+					_, err := reader.Next(ctx)
+					Expect(err).To(Equal(gospel.ErrEndOfRange))
+				})
+			})
+
+			Context("when using an event-type filter", func() {
+				BeforeEach(func() {
+					opts = append(opts, gospel.FilterByEventType(
+						"event-type-1",
+						"event-type-3",
+					))
+				})
+
+				It("skips over filtered facts", func() {
+					var bodies [][]byte
+
+					for i := 0; i < 2; i++ {
+						_, err := reader.Next(ctx)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						bodies = append(
+							bodies,
+							reader.Get().Event.Body,
+						)
+					}
+
+					Expect(bodies).To(Equal([][]byte{
+						[]byte("event-3"),
+						[]byte("event-1"),
+					}))
+
+					_, err := reader.Next(ctx)
+					Expect(err).To(Equal(gospel.ErrEndOfRange))
+				})
+			})
+		})
+
+		Context("when using a stop offset", func() {
+			BeforeEach(func() {
+				opts = append(opts, gospel.StopAt(2))
+			})
+
+			It("returns ErrEndOfRange when the stop offset is reached", func() {
+				for i := 0; i < 2; i++ {
+					_, err := reader.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+				}
+
+				_, err := reader.Next(ctx)
+				Expect(err).To(Equal(gospel.ErrEndOfRange))
+
+				_, ok, err := reader.TryNext(ctx)
+				Expect(err).To(Equal(gospel.ErrEndOfRange))
+				Expect(ok).To(BeFalse())
+			})
+
+			Context("when the stop offset is after the end of the stream", func() {
+				BeforeEach(func() {
+					opts = append(opts, gospel.StopAt(5))
+				})
+
+				It("waits for facts to be appended up to the stop offset", func() {
+					for i := 0; i < 3; i++ {
+						_, err := reader.Next(ctx)
+						Expect(err).ShouldNot(HaveOccurred())
+					}
+
+					nextCtx, cancel := context.WithTimeout(ctx, 250*time.Millisecond)
+					defer cancel()
+
+					_, err := reader.Next(nextCtx)
+					Expect(err).To(Equal(context.DeadlineExceeded))
+
+					_, err = store.AppendUnchecked(
+						ctx,
+						"test-stream",
+						gospel.Event{EventType: "event-type-4", Body: []byte("event-4")},
+						gospel.Event{EventType: "event-type-5", Body: []byte("event-5")},
+						gospel.Event{EventType: "event-type-6", Body: []byte("event-6")},
+					)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					for i := 0; i < 2; i++ {
+						_, err := reader.Next(ctx)
+						Expect(err).ShouldNot(HaveOccurred())
+					}
+
+					Expect(reader.Get().Event.Body).To(Equal([]byte("event-5")))
+
+					_, err = reader.Next(ctx)
+					Expect(err).To(Equal(gospel.ErrEndOfRange))
+				})
+			})
+
+			Context("when reading in reverse", func() {
+				BeforeEach(func() {
+					addr.Offset = 3
+					opts = append(opts, gospel.Reverse(), gospel.StopAt(1))
+				})
+
+				It("returns ErrEndOfRange after the fact at the stop offset is read", func() {
+					var bodies [][]byte
+
+					for i := 0; i < 2; i++ {
+						_, err := reader.Next(ctx)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						bodies = append(
+							bodies,
+							reader.Get().Event.Body,
+						)
+					}
+
+					Expect(bodies).To(Equal([][]byte{
+						[]byte("event-3"),
+						[]byte("event-2"),
+					}))
+
+					_, err := reader.Next(ctx)
+					Expect(err).To(Equal(gospel.ErrEndOfRange))
+				})
+			})
+		})
+
+		Context("when stopping at the head of the stream", func() {
+			BeforeEach(func() {
+				opts = append(opts, gospel.StopAtHead())
+			})
+
+			It("does not read facts appended after the reader is opened", func() {
+				_, err := store.AppendUnchecked(
+					ctx,
+					"test-stream",
+					gospel.Event{EventType: "event-type-4", Body: []byte("event-4")},
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				for i := 0; i < 3; i++ {
+					_, err := reader.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+				}
+
+				_, err = reader.Next(ctx)
+				Expect(err).To(Equal(gospel.ErrEndOfRange))
+			})
+
+			Context("when using an event-type filter", func() {
+				BeforeEach(func() {
+					opts = append(opts, gospel.FilterByEventType("event-type-1"))
+				})
+
+				It("returns ErrEndOfRange when the only remaining facts are filtered", func() {
+					_, err := reader.Next(ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					_, err = reader.Next(ctx)
+					Expect(err).To(Equal(gospel.ErrEndOfRange))
+				})
+			})
+
+			Context("when the stream does not exist", func() {
+				BeforeEach(func() {
+					addr.Stream = "unused-stream"
+				})
+
+				It("returns ErrEndOfRange", func() {
+					_, err := reader.Next(ctx)
+					Expect(err).To(Equal(gospel.ErrEndOfRange))
+				})
+			})
+		})
+	})
+}
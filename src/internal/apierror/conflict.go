@@ -14,11 +14,51 @@ import (
 type ConflictError struct {
 	addr  gospel.Address
 	event gospel.Event
+	next  gospel.Address
+
+	// orig is the address at which an event with the same ID as event was
+	// previously appended, if the conflict was caused by a duplicate ID.
+	orig *gospel.Address
 }
 
 // NewConflict returns a new ConflictError, which implements gospel.ConflictError.
-func NewConflict(addr gospel.Address, ev gospel.Event) ConflictError {
-	return ConflictError{addr, ev}
+//
+// addr is the address at which ev was to be appended, and next is the actual
+// next unused offset of the stream.
+func NewConflict(addr gospel.Address, ev gospel.Event, next gospel.Address) ConflictError {
+	return ConflictError{addr, ev, next, nil}
+}
+
+// NewDuplicateConflict returns a new ConflictError, which implements
+// gospel.ConflictError, for an append to a stream that failed because ev has
+// the same ID as an event that was previously appended at orig, but the append
+// was not a repeat of the earlier append.
+//
+// next is the actual next unused offset of the stream, which is also reported
+// as the address at which ev was to be appended.
+func NewDuplicateConflict(ev gospel.Event, orig, next gospel.Address) ConflictError {
+	return ConflictError{next, ev, next, &orig}
+}
+
+// NewExpectationConflict returns a new ConflictError, which implements
+// gospel.ConflictError, for an append to stream that failed because the stream
+// did not satisfy the expectation e.
+//
+// next is the actual next unused offset of the stream.
+func NewExpectationConflict(
+	stream string,
+	e gospel.Expectation,
+	ev gospel.Event,
+	next gospel.Address,
+) ConflictError {
+	addr := gospel.Address{Stream: stream}
+
+	switch e.Mode {
+	case gospel.ExactOffset, gospel.MaxOffset:
+		addr.Offset = e.Offset
+	}
+
+	return ConflictError{addr, ev, next, nil}
 }
 
 // ConflictDetails returns the address at which the conflict occurred and the
@@ -27,10 +67,35 @@ func (e ConflictError) ConflictDetails() (gospel.Address, gospel.Event) {
 	return e.addr, e.event
 }
 
+// ConflictHead returns the next unused offset of the stream at the time of the
+// conflict, and whether or not the stream existed.
+func (e ConflictError) ConflictHead() (gospel.Address, bool) {
+	return e.next, e.next.Offset > 0
+}
+
 func (e ConflictError) Error() string {
+	if e.orig != nil {
+		return fmt.Sprintf(
+			"conflict occurred appending %s event at %s, an event with ID %q was already appended at %s",
+			e.event,
+			e.addr,
+			e.event.ID,
+			*e.orig,
+		)
+	}
+
+	if e.next.Offset == 0 {
+		return fmt.Sprintf(
+			"conflict occurred appending %s event at %s, the stream does not exist",
+			e.event,
+			e.addr,
+		)
+	}
+
 	return fmt.Sprintf(
-		"conflict occurred appending %s event at %s",
+		"conflict occurred appending %s event at %s, the next unused offset is %s",
 		e.event,
 		e.addr,
+		e.next,
 	)
 }
@@ -8,27 +8,122 @@ import (
 )
 
 var _ = Describe("ConflictError", func() {
+	addr := gospel.Address{
+		Stream: "test-stream",
+		Offset: 123,
+	}
+
+	ev := gospel.Event{
+		EventType:   "event-type",
+		ContentType: "text/plain",
+		Body:        []byte("Hello, world!"),
+	}
+
 	It("is considered a conflict by gospel.IsConflict", func() {
 		Expect(gospel.IsConflict(ConflictError{})).To(BeTrue())
 	})
 
+	Describe("ConflictDetails", func() {
+		It("returns the address and the event", func() {
+			err := NewConflict(addr, ev, gospel.Address{Stream: "test-stream", Offset: 100})
+
+			a, e := err.ConflictDetails()
+			Expect(a).To(Equal(addr))
+			Expect(e).To(Equal(ev))
+		})
+	})
+
+	Describe("ConflictHead", func() {
+		It("returns the next unused offset of the stream", func() {
+			next := gospel.Address{Stream: "test-stream", Offset: 100}
+			err := NewConflict(addr, ev, next)
+
+			nx, exists := err.ConflictHead()
+			Expect(nx).To(Equal(next))
+			Expect(exists).To(BeTrue())
+		})
+
+		It("reports that the stream does not exist if the next offset is zero", func() {
+			next := gospel.Address{Stream: "test-stream"}
+			err := NewConflict(addr, ev, next)
+
+			nx, exists := err.ConflictHead()
+			Expect(nx).To(Equal(next))
+			Expect(exists).To(BeFalse())
+		})
+	})
+
 	Describe("Error", func() {
 		It("returns a meaningful error message", func() {
-			err := NewConflict(
-				gospel.Address{
-					Stream: "test-stream",
-					Offset: 123,
-				},
-				gospel.Event{
-					EventType:   "event-type",
-					ContentType: "text/plain",
-					Body:        []byte("Hello, world!"),
-				},
-			)
+			err := NewConflict(addr, ev, gospel.Address{Stream: "test-stream", Offset: 100})
+
+			Expect(err.Error()).To(Equal(
+				"conflict occurred appending event-type! event at test-stream+123, the next unused offset is test-stream+100",
+			))
+		})
+
+		It("returns a meaningful error message when the stream does not exist", func() {
+			err := NewConflict(addr, ev, gospel.Address{Stream: "test-stream"})
 
 			Expect(err.Error()).To(Equal(
-				"conflict occurred appending event-type! event at test-stream+123",
+				"conflict occurred appending event-type! event at test-stream+123, the stream does not exist",
 			))
 		})
 	})
 })
+
+var _ = Describe("NewExpectationConflict", func() {
+	ev := gospel.Event{EventType: "event-type"}
+	next := gospel.Address{Stream: "test-stream", Offset: 5}
+
+	It("uses the expected offset as the conflicting address for exact offsets", func() {
+		err := NewExpectationConflict("test-stream", gospel.ExpectOffset(3), ev, next)
+
+		addr, _ := err.ConflictDetails()
+		Expect(addr).To(Equal(gospel.Address{Stream: "test-stream", Offset: 3}))
+	})
+
+	It("uses the maximum offset as the conflicting address for offset limits", func() {
+		err := NewExpectationConflict("test-stream", gospel.ExpectOffsetAtMost(3), ev, next)
+
+		addr, _ := err.ConflictDetails()
+		Expect(addr).To(Equal(gospel.Address{Stream: "test-stream", Offset: 3}))
+	})
+
+	It("uses the start of the stream as the conflicting address for other modes", func() {
+		err := NewExpectationConflict("test-stream", gospel.ExpectNoStream(), ev, next)
+
+		addr, _ := err.ConflictDetails()
+		Expect(addr).To(Equal(gospel.Address{Stream: "test-stream"}))
+	})
+
+	It("includes the actual next offset", func() {
+		err := NewExpectationConflict("test-stream", gospel.ExpectNoStream(), ev, next)
+
+		nx, exists := err.ConflictHead()
+		Expect(nx).To(Equal(next))
+		Expect(exists).To(BeTrue())
+	})
+})
+
+var _ = Describe("NewDuplicateConflict", func() {
+	ev := gospel.Event{EventType: "event-type", ID: "event-id"}
+	orig := gospel.Address{Stream: "other-stream", Offset: 3}
+	next := gospel.Address{Stream: "test-stream", Offset: 5}
+
+	It("uses the next unused offset as the conflicting address", func() {
+		err := NewDuplicateConflict(ev, orig, next)
+
+		addr, e := err.ConflictDetails()
+		Expect(addr).To(Equal(next))
+		Expect(e).To(Equal(ev))
+	})
+
+	It("includes the original address in the error message", func() {
+		err := NewDuplicateConflict(ev, orig, next)
+
+		Expect(err.Error()).To(Equal(
+			`conflict occurred appending event-type! event at test-stream+5, an event with ID "event-id" was already appended at other-stream+3`,
+		))
+	})
+})
@@ -0,0 +1,102 @@
+package dedupe
+
+import (
+	"fmt"
+
+	"github.com/jmalloc/gospel/src/gospel"
+)
+
+// Outcome is the result of comparing the events in an append with those that
+// have been appended previously.
+type Outcome int
+
+const (
+	// Unique indicates that none of the events have been appended previously.
+	Unique Outcome = iota
+
+	// Repeat indicates that the append is an exact repeat of an earlier
+	// append, and hence nothing should be appended.
+	Repeat
+
+	// Conflict indicates that some of the events have been appended
+	// previously, but the append is not an exact repeat of an earlier append.
+	Conflict
+)
+
+// IDs returns the non-empty IDs of the given events.
+//
+// It returns an error if any two events share the same ID. Such an append can
+// never succeed, so the error is not a conflict.
+func IDs(events []gospel.Event) ([]string, error) {
+	var ids []string
+	seen := map[string]struct{}{}
+
+	for _, ev := range events {
+		if ev.ID == "" {
+			continue
+		}
+
+		if _, ok := seen[ev.ID]; ok {
+			return nil, fmt.Errorf(
+				"can not append %s event, another event in the same append has the ID %q",
+				ev,
+				ev.ID,
+			)
+		}
+
+		seen[ev.ID] = struct{}{}
+		ids = append(ids, ev.ID)
+	}
+
+	return ids, nil
+}
+
+// Compare compares an append of events to stream with the events that have
+// been appended previously.
+//
+// prev maps the ID of each previously appended event to its address. It need
+// only contain the IDs of the given events.
+//
+// An append is a repeat if every event has an ID, and the events were
+// previously appended to the same stream, at consecutive offsets, in the same
+// order.
+//
+// If the outcome is Repeat, addr is the address of the next offset after the
+// original append. If the outcome is Conflict, addr is the address at which
+// ev was previously appended, and ev is the first event that was previously
+// appended.
+func Compare(
+	stream string,
+	events []gospel.Event,
+	prev map[string]gospel.Address,
+) (o Outcome, addr gospel.Address, ev gospel.Event) {
+	found := 0
+	repeat := true
+
+	for i, e := range events {
+		a, ok := prev[e.ID]
+		if e.ID == "" || !ok {
+			repeat = false
+			continue
+		}
+
+		if found == 0 {
+			addr, ev = a, e
+		}
+		found++
+
+		first := prev[events[0].ID]
+		if a.Stream != stream || a.Offset != first.Offset+uint64(i) {
+			repeat = false
+		}
+	}
+
+	switch {
+	case found == 0:
+		return Unique, gospel.Address{}, gospel.Event{}
+	case repeat:
+		return Repeat, prev[events[len(events)-1].ID].Next(), gospel.Event{}
+	default:
+		return Conflict, addr, ev
+	}
+}
@@ -0,0 +1,108 @@
+package dedupe_test
+
+import (
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/jmalloc/gospel/src/internal/dedupe"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IDs", func() {
+	It("returns the non-empty IDs", func() {
+		ids, err := IDs([]gospel.Event{
+			{ID: "id-1"},
+			{},
+			{ID: "id-2"},
+		})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ids).To(Equal([]string{"id-1", "id-2"}))
+	})
+
+	It("returns an error if two events have the same ID", func() {
+		_, err := IDs([]gospel.Event{
+			{EventType: "event-type", ID: "id-1"},
+			{EventType: "event-type", ID: "id-1"},
+		})
+
+		Expect(err).To(MatchError(`can not append event-type! event, another event in the same append has the ID "id-1"`))
+	})
+})
+
+var _ = Describe("Compare", func() {
+	events := []gospel.Event{
+		{EventType: "event-type-1", ID: "id-1"},
+		{EventType: "event-type-2", ID: "id-2"},
+	}
+
+	It("returns Unique if none of the events were appended previously", func() {
+		o, _, _ := Compare("test-stream", events, nil)
+		Expect(o).To(Equal(Unique))
+	})
+
+	It("returns Repeat and the original next address if the append is a repeat", func() {
+		o, addr, _ := Compare(
+			"test-stream",
+			events,
+			map[string]gospel.Address{
+				"id-1": {Stream: "test-stream", Offset: 3},
+				"id-2": {Stream: "test-stream", Offset: 4},
+			},
+		)
+
+		Expect(o).To(Equal(Repeat))
+		Expect(addr).To(Equal(gospel.Address{Stream: "test-stream", Offset: 5}))
+	})
+
+	It("returns Conflict if only some of the events were appended previously", func() {
+		o, addr, ev := Compare(
+			"test-stream",
+			events,
+			map[string]gospel.Address{
+				"id-2": {Stream: "test-stream", Offset: 4},
+			},
+		)
+
+		Expect(o).To(Equal(Conflict))
+		Expect(addr).To(Equal(gospel.Address{Stream: "test-stream", Offset: 4}))
+		Expect(ev).To(Equal(events[1]))
+	})
+
+	It("returns Conflict if the events were appended to a different stream", func() {
+		o, _, _ := Compare(
+			"test-stream",
+			events,
+			map[string]gospel.Address{
+				"id-1": {Stream: "other-stream", Offset: 3},
+				"id-2": {Stream: "other-stream", Offset: 4},
+			},
+		)
+
+		Expect(o).To(Equal(Conflict))
+	})
+
+	It("returns Conflict if the events were appended in a different order", func() {
+		o, _, _ := Compare(
+			"test-stream",
+			events,
+			map[string]gospel.Address{
+				"id-1": {Stream: "test-stream", Offset: 4},
+				"id-2": {Stream: "test-stream", Offset: 3},
+			},
+		)
+
+		Expect(o).To(Equal(Conflict))
+	})
+
+	It("returns Conflict if an event without an ID is included with previously appended events", func() {
+		o, _, _ := Compare(
+			"test-stream",
+			[]gospel.Event{events[0], {}},
+			map[string]gospel.Address{
+				"id-1": {Stream: "test-stream", Offset: 3},
+			},
+		)
+
+		Expect(o).To(Equal(Conflict))
+	})
+})
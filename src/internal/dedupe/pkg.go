@@ -0,0 +1,3 @@
+// Package dedupe contains utilities for detecting repeated appends of events
+// that have application-defined IDs.
+package dedupe
@@ -0,0 +1,57 @@
+package indexreader
+
+import (
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+)
+
+// Index is an interface to the in-memory index of an event store.
+//
+// Unless otherwise noted, the methods must only be called while the read lock
+// is held.
+type Index interface {
+	// RLock acquires the read lock. It may be called at any time.
+	RLock()
+
+	// RUnlock releases the read lock.
+	RUnlock()
+
+	// Done returns a channel that is closed when the event store is closed. It
+	// may be called at any time.
+	Done() <-chan struct{}
+
+	// Err returns a non-nil error if the event store has been closed.
+	Err() error
+
+	// NextOffset returns the next unused offset of the given stream.
+	NextOffset(stream string) uint64
+
+	// Entry returns the index entry of the fact at addr, which must refer to
+	// an existing fact.
+	Entry(addr gospel.Address) Entry
+
+	// Read returns the fact at addr, which must refer to an existing fact. The
+	// returned fact must not share any memory with the event store.
+	Read(addr gospel.Address) (gospel.Fact, error)
+
+	// Wait returns a channel that is closed when new facts are appended to the
+	// given stream. release must be called once the caller stops waiting.
+	Wait(stream string) (wait <-chan struct{}, release func())
+}
+
+// Entry contains the information about a fact that is needed to determine
+// whether it matches a reader's filter, without reading the fact itself.
+type Entry struct {
+	// Origin is the address of the fact on the named stream it was originally
+	// appended to.
+	Origin gospel.Address
+
+	// Time is the time at which the fact was appended.
+	Time time.Time
+
+	// EventType and ContentType are the event type and content type of the
+	// fact's event.
+	EventType   string
+	ContentType string
+}
@@ -0,0 +1,3 @@
+// Package indexreader contains a reader for event stores that keep an index of
+// their facts in memory, such as gospelmem and gospelfile.
+package indexreader
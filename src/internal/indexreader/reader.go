@@ -0,0 +1,321 @@
+package indexreader
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/internal/match"
+	"github.com/jmalloc/gospel/src/internal/options"
+	"github.com/jmalloc/twelf/src/twelf"
+)
+
+// Reader is an implementation of gospel.Reader that reads facts from an Index.
+//
+// Unlike readers that poll a database, a Reader is woken as soon as a fact is
+// appended to its stream.
+type Reader struct {
+	// index is the index of the event store that contains the stream.
+	index Index
+
+	// logger is the target for debug logging. Readers do not perform general
+	// activity logging.
+	logger twelf.Logger
+
+	// opts is the options specified when opening the reader.
+	opts *options.ReaderOptions
+
+	// addr is the address of the next fact to be inspected. Facts that do not
+	// match the reader's filter are skipped over as they are inspected.
+	//
+	// If the reader is reading in reverse, the next fact to be inspected is
+	// the one immediately before addr.
+	addr gospel.Address
+
+	// bounded is true if the reader ends once it reaches the offset in stop,
+	// as per the StopAt() and StopAtHead() options.
+	bounded bool
+	stop    uint64
+
+	// current is the fact returned by Get() until Next() is called again.
+	current *gospel.Fact
+
+	// done is a signaling channel that is closed when Close() is called.
+	done chan struct{}
+}
+
+// errReaderClosed is an error returned by Next() when it is called on a closed
+// reader, or when the reader is closed while a call to Next() is pending.
+var errReaderClosed = errors.New("reader is closed")
+
+// Open returns a new reader that reads facts from idx, beginning at addr.
+func Open(
+	idx Index,
+	addr gospel.Address,
+	logger twelf.Logger,
+	opts *options.ReaderOptions,
+) *Reader {
+	r := &Reader{
+		index:   idx,
+		logger:  logger,
+		opts:    opts,
+		addr:    addr,
+		bounded: opts.StopAtOffset,
+		stop:    opts.StopOffset,
+		done:    make(chan struct{}),
+	}
+
+	if opts.StopAtHead && !opts.Reverse {
+		idx.RLock()
+		head := idx.NextOffset(addr.Stream)
+		idx.RUnlock()
+
+		if !r.bounded || head < r.stop {
+			r.bounded = true
+			r.stop = head
+		}
+	}
+
+	r.logInitialization()
+
+	return r
+}
+
+// Next blocks until a fact is available for reading or ctx is canceled.
+//
+// If err is nil, the "current" fact is ready to be returned by Get().
+//
+// nx is the offset within the stream that the reader has reached. It can be
+// used to efficiently resume reading in a future call to EventStore.Open().
+//
+// Note that nx is not always the address immediately following the fact
+// returned by Get() - it may be "further ahead" in the stream, this skipping
+// over any facts that the reader is not interested in.
+func (r *Reader) Next(ctx context.Context) (nx gospel.Address, err error) {
+	for {
+		var ok bool
+		var wait <-chan struct{}
+		var release func()
+
+		nx, ok, wait, release, err = r.advance()
+		if ok || err != nil {
+			return nx, err
+		}
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-r.done:
+			err = errReaderClosed
+		case <-r.index.Done():
+			// The next call to advance() returns the index's error.
+		}
+
+		release()
+
+		if err != nil {
+			return nx, err
+		}
+	}
+}
+
+// TryNext blocks until the next fact is available for reading, the end of
+// stream is reached, or ctx is canceled.
+//
+// If ok is true, a new fact is available and is ready to be returned by
+// Get(). ok is false if the current fact is the last known fact in the
+// stream.
+//
+// nx is the offset within the stream that the reader has reached. It can be
+// used to efficiently resume reading in a future call to EventStore.Open().
+// nx is invalid if ok is false.
+func (r *Reader) TryNext(ctx context.Context) (nx gospel.Address, ok bool, err error) {
+	if err := ctx.Err(); err != nil {
+		return nx, false, err
+	}
+
+	nx, ok, _, release, err := r.advance()
+	if release != nil {
+		release()
+	}
+
+	return nx, ok, err
+}
+
+// Get returns the "current" fact.
+//
+// It panics if Next() has not been called.
+// Get() returns the same Fact until Next() is called again.
+func (r *Reader) Get() gospel.Fact {
+	if r.current == nil {
+		panic("Next() must be called before calling Get()")
+	}
+
+	return *r.current
+}
+
+// Close closes the reader.
+func (r *Reader) Close() error {
+	select {
+	case <-r.done:
+	default:
+		close(r.done)
+	}
+
+	return nil
+}
+
+// advance moves the reader to the next fact that matches the reader's filter.
+//
+// If ok is true, the fact has been made "current" and nx is the address of the
+// next fact that matches the filter, or the end of the stream, if there is no
+// such fact yet.
+//
+// If ok is false, wait is a channel that is closed when new facts are appended
+// to the stream, and release must be called once the caller stops waiting.
+func (r *Reader) advance() (nx gospel.Address, ok bool, wait <-chan struct{}, release func(), err error) {
+	select {
+	case <-r.done:
+		return nx, false, nil, nil, errReaderClosed
+	default:
+	}
+
+	r.index.RLock()
+	defer r.index.RUnlock()
+
+	if err := r.index.Err(); err != nil {
+		return nx, false, nil, nil, err
+	}
+
+	if r.opts.Reverse {
+		return r.advanceReverse()
+	}
+
+	if !r.skip() {
+		if r.bounded && r.addr.Offset >= r.stop {
+			return nx, false, nil, nil, gospel.ErrEndOfRange
+		}
+
+		// The channel must be obtained while the lock is held, otherwise an
+		// append may occur before we begin waiting.
+		wait, release = r.index.Wait(r.addr.Stream)
+		return nx, false, wait, release, nil
+	}
+
+	f, err := r.index.Read(r.addr)
+	if err != nil {
+		return nx, false, nil, nil, err
+	}
+
+	r.current = &f
+
+	r.addr = r.addr.Next()
+	r.skip()
+
+	return r.addr, true, nil, nil, nil
+}
+
+// advanceReverse moves the reader to the previous fact that matches the
+// reader's filter.
+//
+// If ok is true, the fact has been made "current" and nx is the address
+// immediately after the previous fact that matches the filter, or the
+// beginning of the stream, if there is no such fact. It returns
+// gospel.ErrEndOfRange if there are no more matching facts.
+func (r *Reader) advanceReverse() (nx gospel.Address, ok bool, wait <-chan struct{}, release func(), err error) {
+	if !r.skipReverse() {
+		return nx, false, nil, nil, gospel.ErrEndOfRange
+	}
+
+	r.addr.Offset--
+
+	f, err := r.index.Read(r.addr)
+	if err != nil {
+		return nx, false, nil, nil, err
+	}
+
+	r.current = &f
+
+	r.skipReverse()
+
+	return r.addr, true, nil, nil, nil
+}
+
+// skip advances r.addr past any facts that do not match the reader's filter.
+//
+// It returns true if r.addr refers to a matching fact, or false if the end of
+// the stream or the reader's stop offset has been reached.
+func (r *Reader) skip() bool {
+	end := r.index.NextOffset(r.addr.Stream)
+	if r.bounded && r.stop < end {
+		end = r.stop
+	}
+
+	for r.addr.Offset < end {
+		if r.match(r.index.Entry(r.addr)) {
+			return true
+		}
+
+		r.addr = r.addr.Next()
+	}
+
+	return false
+}
+
+// skipReverse moves r.addr back past any facts that do not match the reader's
+// filter.
+//
+// It returns true if the fact immediately before r.addr is a matching fact, or
+// false if the beginning of the stream or the reader's stop offset has been
+// reached.
+func (r *Reader) skipReverse() bool {
+	if head := r.index.NextOffset(r.addr.Stream); r.addr.Offset > head {
+		r.addr.Offset = head
+	}
+
+	var begin uint64
+	if r.bounded {
+		begin = r.stop
+	}
+
+	for r.addr.Offset > begin {
+		prev := gospel.Address{
+			Stream: r.addr.Stream,
+			Offset: r.addr.Offset - 1,
+		}
+
+		if r.match(r.index.Entry(prev)) {
+			return true
+		}
+
+		r.addr = prev
+	}
+
+	return false
+}
+
+// match returns true if the fact described by e matches the reader's filter.
+func (r *Reader) match(e Entry) bool {
+	if r.opts.FilterByTime && e.Time.Before(r.opts.FromTime) {
+		return false
+	}
+
+	return match.EventType(r.opts, e.EventType) &&
+		match.ContentType(r.opts, e.ContentType) &&
+		match.Stream(r.opts, e.Origin.Stream)
+}
+
+// logInitialization logs a debug message describing the reader settings.
+func (r *Reader) logInitialization() {
+	if !r.logger.IsDebug() {
+		return
+	}
+
+	r.logger.Debug(
+		"[reader %p] %s | filter: %s",
+		r,
+		r.addr,
+		match.Format(r.opts),
+	)
+}
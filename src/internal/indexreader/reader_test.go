@@ -0,0 +1,194 @@
+package indexreader_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/jmalloc/gospel/src/internal/indexreader"
+	"github.com/jmalloc/gospel/src/internal/notify"
+	"github.com/jmalloc/gospel/src/internal/options"
+	"github.com/jmalloc/twelf/src/twelf"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// index is an Index of the facts on a single stream.
+type index struct {
+	sync.RWMutex
+
+	facts []gospel.Fact
+	reads []uint64 // the offsets passed to Read()
+	hub   notify.Hub
+	done  chan struct{}
+}
+
+func (i *index) Done() <-chan struct{} {
+	return i.done
+}
+
+func (i *index) Err() error {
+	select {
+	case <-i.done:
+		return errors.New("<closed>")
+	default:
+		return nil
+	}
+}
+
+func (i *index) NextOffset(string) uint64 {
+	return uint64(len(i.facts))
+}
+
+func (i *index) Entry(addr gospel.Address) Entry {
+	f := i.facts[addr.Offset]
+
+	return Entry{
+		Origin:      f.Origin,
+		Time:        f.Time,
+		EventType:   f.Event.EventType,
+		ContentType: f.Event.ContentType,
+	}
+}
+
+func (i *index) Read(addr gospel.Address) (gospel.Fact, error) {
+	i.reads = append(i.reads, addr.Offset)
+	return i.facts[addr.Offset], nil
+}
+
+func (i *index) Wait(stream string) (<-chan struct{}, func()) {
+	return i.hub.Wait(stream)
+}
+
+func (i *index) append(eventType string) {
+	i.Lock()
+	addr := gospel.Address{Stream: "test-stream", Offset: uint64(len(i.facts))}
+	i.facts = append(i.facts, gospel.Fact{
+		Addr:   addr,
+		Origin: addr,
+		Time:   time.Now(),
+		Event:  gospel.Event{EventType: eventType},
+	})
+	i.Unlock()
+
+	i.hub.Notify("test-stream")
+}
+
+var _ = Describe("Reader", func() {
+	var (
+		ctx    context.Context
+		cancel func()
+
+		idx *index
+	)
+
+	open := func(addr gospel.Address, opts ...gospel.ReaderOption) *Reader {
+		return Open(idx, addr, twelf.SilentLogger, options.NewReaderOptions(opts))
+	}
+
+	BeforeEach(func() {
+		var fn func()
+		ctx, fn = context.WithTimeout(context.Background(), 3*time.Second)
+		cancel = fn // defeat go vet warning about unused cancel func
+
+		idx = &index{done: make(chan struct{})}
+		idx.append("event-type-1")
+		idx.append("event-type-2")
+		idx.append("event-type-1")
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	Describe("Next", func() {
+		It("only reads the facts that match the filter", func() {
+			r := open(
+				gospel.Address{Stream: "test-stream"},
+				gospel.FilterByEventType("event-type-1"),
+				gospel.StopAtHead(),
+			)
+			defer r.Close()
+
+			var offsets []uint64
+			for {
+				_, err := r.Next(ctx)
+				if err == gospel.ErrEndOfRange {
+					break
+				}
+				Expect(err).ShouldNot(HaveOccurred())
+
+				offsets = append(offsets, r.Get().Addr.Offset)
+			}
+
+			Expect(offsets).To(Equal([]uint64{0, 2}))
+			Expect(idx.reads).To(Equal([]uint64{0, 2}))
+		})
+
+		It("reads facts in reverse", func() {
+			r := open(
+				gospel.Address{Stream: "test-stream", Offset: 3},
+				gospel.Reverse(),
+			)
+			defer r.Close()
+
+			var offsets []uint64
+			for {
+				_, err := r.Next(ctx)
+				if err == gospel.ErrEndOfRange {
+					break
+				}
+				Expect(err).ShouldNot(HaveOccurred())
+
+				offsets = append(offsets, r.Get().Addr.Offset)
+			}
+
+			Expect(offsets).To(Equal([]uint64{2, 1, 0}))
+		})
+
+		It("wakes when a fact is appended", func() {
+			r := open(gospel.Address{Stream: "test-stream", Offset: 3})
+			defer r.Close()
+
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				idx.append("event-type-3")
+			}()
+
+			nx, err := r.Next(ctx)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(nx.Offset).To(BeNumerically("==", 4))
+			Expect(r.Get().Event.EventType).To(Equal("event-type-3"))
+		})
+
+		It("returns the index's error when the index is closed while waiting", func() {
+			r := open(gospel.Address{Stream: "test-stream", Offset: 3})
+			defer r.Close()
+
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				close(idx.done)
+			}()
+
+			_, err := r.Next(ctx)
+
+			Expect(err).To(MatchError("<closed>"))
+		})
+
+		It("returns an error when the reader is closed while waiting", func() {
+			r := open(gospel.Address{Stream: "test-stream", Offset: 3})
+
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				r.Close()
+			}()
+
+			_, err := r.Next(ctx)
+
+			Expect(err).To(MatchError("reader is closed"))
+		})
+	})
+})
@@ -71,16 +71,55 @@ func AppendUnchecked(
 	}
 }
 
+// AppendExpected logs new events being appended to a stream using
+// EventStore.AppendExpected().
+func AppendExpected(
+	logger twelf.Logger,
+	next gospel.Address,
+	e gospel.Expectation,
+	events []gospel.Event,
+) {
+	addr := next
+	addr.Offset -= uint64(len(events))
+
+	switch len(events) {
+	case 1:
+		logger.Log(
+			"appended %s at %s (expected %s)",
+			events[0],
+			addr,
+			e,
+		)
+	case 2:
+		logger.Log(
+			"appended %s and 1 more event at %s (expected %s)",
+			events[0],
+			addr,
+			e,
+		)
+	default:
+		logger.Log(
+			"appended %s and %d more events at %s (expected %s)",
+			events[0],
+			len(events)-1,
+			addr,
+			e,
+		)
+	}
+}
+
 // Conflict logs an append that failed due to a conflict.
 func Conflict(
 	logger twelf.Logger,
 	err gospel.ConflictError,
 ) {
 	addr, ev := err.ConflictDetails()
+	next, _ := err.ConflictHead()
 
 	logger.Log(
-		"conflict appending %s at %s",
+		"conflict appending %s at %s, next unused offset is %s",
 		ev,
 		addr,
+		next,
 	)
 }
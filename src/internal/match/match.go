@@ -0,0 +1,200 @@
+package match
+
+import (
+	"strings"
+
+	"github.com/jmalloc/gospel/src/internal/options"
+)
+
+// EventType returns true if the event type t is allowed by the event type
+// filters in o, and is not excluded.
+func EventType(o *options.ReaderOptions, t string) bool {
+	for _, x := range o.ExcludedEventTypes {
+		if t == x {
+			return false
+		}
+	}
+
+	if !o.FilterByEventType {
+		return true
+	}
+
+	return anyOf(t, o.EventTypes, o.EventTypePrefixes, o.EventTypePatterns)
+}
+
+// ContentType returns true if the content type t is allowed by the content
+// type filters in o.
+func ContentType(o *options.ReaderOptions, t string) bool {
+	if !o.FilterByContentType {
+		return true
+	}
+
+	return anyOf(t, o.ContentTypes, o.ContentTypePrefixes, nil)
+}
+
+// Stream returns true if a fact that originated from the named stream s is
+// allowed by the stream filters in o.
+//
+// s is the empty string for facts that do not originate from a named stream,
+// which are never allowed if o has a stream filter.
+func Stream(o *options.ReaderOptions, s string) bool {
+	if !o.FilterByStream {
+		return true
+	}
+
+	return s != "" && anyOf(s, o.Streams, o.StreamPrefixes, o.StreamPatterns)
+}
+
+// anyOf returns true if s is equal to one of the values in exact, begins with
+// one of the given prefixes, or matches one of the given glob patterns.
+func anyOf(s string, exact, prefixes, patterns []string) bool {
+	for _, x := range exact {
+		if s == x {
+			return true
+		}
+	}
+
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+
+	for _, p := range patterns {
+		if Glob(p, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Glob returns true if s matches the glob pattern p.
+//
+// Within p, '*' matches any sequence of bytes, including an empty sequence,
+// and '?' matches any single byte. All other bytes match themselves.
+//
+// Matching is byte-based, rather than character-based, so that it behaves
+// identically in every event store implementation, including those that store
+// names as binary strings.
+func Glob(p, s string) bool {
+	var pi, si int
+
+	// star is the index within p of the most recent '*', and mark is the index
+	// within s that it was last assumed to match up to. If a later byte fails
+	// to match, the '*' is extended by one byte and matching resumes from
+	// there.
+	star, mark := -1, 0
+
+	for si < len(s) {
+		switch {
+		case pi < len(p) && p[pi] == '*':
+			star, mark = pi, si
+			pi++
+		case pi < len(p) && (p[pi] == '?' || p[pi] == s[si]):
+			pi++
+			si++
+		case star != -1:
+			mark++
+			pi, si = star+1, mark
+		default:
+			return false
+		}
+	}
+
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+
+	return pi == len(p)
+}
+
+// Like returns an SQL LIKE pattern that is equivalent to the glob pattern p.
+//
+// The pattern uses backslash as the escape character, which is the default
+// for both MariaDB and PostgreSQL.
+func Like(p string) string {
+	var buf []rune
+
+	for _, r := range p {
+		switch r {
+		case '*':
+			buf = append(buf, '%')
+		case '?':
+			buf = append(buf, '_')
+		case '%', '_', '\\':
+			buf = append(buf, '\\', r)
+		default:
+			buf = append(buf, r)
+		}
+	}
+
+	return string(buf)
+}
+
+// LikePrefix returns an SQL LIKE pattern that matches any string that begins
+// with the given prefix.
+//
+// The pattern uses backslash as the escape character, which is the default
+// for both MariaDB and PostgreSQL.
+func LikePrefix(prefix string) string {
+	var buf []rune
+
+	for _, r := range prefix {
+		switch r {
+		case '%', '_', '\\':
+			buf = append(buf, '\\', r)
+		default:
+			buf = append(buf, r)
+		}
+	}
+
+	return string(append(buf, '%'))
+}
+
+// Format returns a human-readable description of the event type and content
+// type filters in o, for use in debug logging.
+func Format(o *options.ReaderOptions) string {
+	s := "*"
+
+	if o.FilterByEventType {
+		var types []string
+		types = append(types, o.EventTypes...)
+
+		for _, p := range o.EventTypePrefixes {
+			types = append(types, p+"*")
+		}
+
+		types = append(types, o.EventTypePatterns...)
+		s = strings.Join(types, ", ")
+	}
+
+	if len(o.ExcludedEventTypes) != 0 {
+		s += " (excluding: " + strings.Join(o.ExcludedEventTypes, ", ") + ")"
+	}
+
+	if o.FilterByContentType {
+		var types []string
+		types = append(types, o.ContentTypes...)
+
+		for _, p := range o.ContentTypePrefixes {
+			types = append(types, p+"*")
+		}
+
+		s += " (content-type: " + strings.Join(types, ", ") + ")"
+	}
+
+	if o.FilterByStream {
+		var streams []string
+		streams = append(streams, o.Streams...)
+
+		for _, p := range o.StreamPrefixes {
+			streams = append(streams, p+"*")
+		}
+
+		streams = append(streams, o.StreamPatterns...)
+		s += " (stream: " + strings.Join(streams, ", ") + ")"
+	}
+
+	return s
+}
@@ -0,0 +1,181 @@
+package match_test
+
+import (
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/jmalloc/gospel/src/internal/match"
+	"github.com/jmalloc/gospel/src/internal/options"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EventType", func() {
+	It("allows all event types if there is no filter", func() {
+		opts := options.NewReaderOptions(nil)
+
+		Expect(EventType(opts, "foo")).To(BeTrue())
+	})
+
+	DescribeTable(
+		"it matches event types against the filter",
+		func(t string, expected bool) {
+			opts := options.NewReaderOptions(
+				[]options.ReaderOption{
+					gospel.FilterByEventType("exact"),
+					gospel.FilterByEventTypePrefix("prefix."),
+					gospel.FilterByEventTypePattern("*.pattern.?"),
+				},
+			)
+
+			Expect(EventType(opts, t)).To(Equal(expected))
+		},
+		Entry("exact match", "exact", true),
+		Entry("exact mismatch", "exact.not", false),
+		Entry("prefix match", "prefix.foo", true),
+		Entry("prefix mismatch", "not.prefix.foo", false),
+		Entry("pattern match", "foo.pattern.1", true),
+		Entry("pattern mismatch", "foo.pattern.12", false),
+	)
+
+	It("does not allow excluded event types", func() {
+		opts := options.NewReaderOptions(
+			[]options.ReaderOption{
+				gospel.ExcludeEventTypes("foo"),
+			},
+		)
+
+		Expect(EventType(opts, "foo")).To(BeFalse())
+		Expect(EventType(opts, "bar")).To(BeTrue())
+	})
+
+	It("does not allow excluded event types that match the inclusive filter", func() {
+		opts := options.NewReaderOptions(
+			[]options.ReaderOption{
+				gospel.FilterByEventTypePrefix("foo."),
+				gospel.ExcludeEventTypes("foo.bar"),
+			},
+		)
+
+		Expect(EventType(opts, "foo.bar")).To(BeFalse())
+		Expect(EventType(opts, "foo.baz")).To(BeTrue())
+	})
+})
+
+var _ = Describe("ContentType", func() {
+	It("allows all content types if there is no filter", func() {
+		opts := options.NewReaderOptions(nil)
+
+		Expect(ContentType(opts, "application/json")).To(BeTrue())
+	})
+
+	DescribeTable(
+		"it matches content types against the filter",
+		func(t string, expected bool) {
+			opts := options.NewReaderOptions(
+				[]options.ReaderOption{
+					gospel.FilterByContentType(
+						"application/json",
+						"application/vnd.foo.*",
+					),
+				},
+			)
+
+			Expect(ContentType(opts, t)).To(Equal(expected))
+		},
+		Entry("exact match", "application/json", true),
+		Entry("exact mismatch", "application/json+ld", false),
+		Entry("family match", "application/vnd.foo.bar+json", true),
+		Entry("family mismatch", "application/vnd.bar.foo+json", false),
+	)
+})
+
+var _ = Describe("Stream", func() {
+	It("allows all streams if there is no filter", func() {
+		opts := options.NewReaderOptions(nil)
+
+		Expect(Stream(opts, "foo")).To(BeTrue())
+		Expect(Stream(opts, "")).To(BeTrue())
+	})
+
+	DescribeTable(
+		"it matches stream names against the filter",
+		func(s string, expected bool) {
+			opts := options.NewReaderOptions(
+				[]options.ReaderOption{
+					gospel.FilterByStream("exact"),
+					gospel.FilterByStreamPrefix("prefix-"),
+					gospel.FilterByStreamPattern("*"),
+				},
+			)
+
+			Expect(Stream(opts, s)).To(Equal(expected))
+		},
+		Entry("exact match", "exact", true),
+		Entry("prefix match", "prefix-foo", true),
+		Entry("pattern match", "foo", true),
+		Entry("no originating stream", "", false),
+	)
+})
+
+var _ = DescribeTable(
+	"Glob",
+	func(p, s string, expected bool) {
+		Expect(Glob(p, s)).To(Equal(expected))
+	},
+	Entry("empty pattern", "", "", true),
+	Entry("empty pattern, non-empty string", "", "a", false),
+	Entry("literal match", "abc", "abc", true),
+	Entry("literal mismatch", "abc", "abd", false),
+	Entry("star matches empty sequence", "a*c", "ac", true),
+	Entry("star matches sequence", "a*c", "abbbc", true),
+	Entry("star requires suffix", "a*c", "abbb", false),
+	Entry("star backtracks", "a*bc", "abcbc", true),
+	Entry("multiple stars", "*a*b*", "xxaxxbxx", true),
+	Entry("question mark matches single character", "a?c", "abc", true),
+	Entry("question mark requires a character", "a?c", "ac", false),
+	Entry("question mark matches a single byte of a multibyte character", "a?c", "aλc", false),
+	Entry("question marks match each byte of a multibyte character", "a??c", "aλc", true),
+	Entry("star matches multibyte characters", "a*c", "aλc", true),
+)
+
+var _ = DescribeTable(
+	"Like",
+	func(p, expected string) {
+		Expect(Like(p)).To(Equal(expected))
+	},
+	Entry("wildcards", "a*b?c", `a%b_c`),
+	Entry("escaped characters", `a%b_c\d`, `a\%b\_c\\d`),
+)
+
+var _ = DescribeTable(
+	"LikePrefix",
+	func(p, expected string) {
+		Expect(LikePrefix(p)).To(Equal(expected))
+	},
+	Entry("plain prefix", "foo.", `foo.%`),
+	Entry("escaped characters", `a%b_c\d*`, `a\%b\_c\\d*%`),
+)
+
+var _ = Describe("Format", func() {
+	It("returns an asterisk if there is no filter", func() {
+		opts := options.NewReaderOptions(nil)
+
+		Expect(Format(opts)).To(Equal("*"))
+	})
+
+	It("describes each filter", func() {
+		opts := options.NewReaderOptions(
+			[]options.ReaderOption{
+				gospel.FilterByEventType("exact"),
+				gospel.FilterByEventTypePrefix("prefix."),
+				gospel.FilterByEventTypePattern("*.pattern"),
+				gospel.ExcludeEventTypes("excluded"),
+				gospel.FilterByContentType("application/json", "text/*"),
+			},
+		)
+
+		Expect(Format(opts)).To(Equal(
+			"exact, prefix.*, *.pattern (excluding: excluded) (content-type: application/json, text/*)",
+		))
+	})
+})
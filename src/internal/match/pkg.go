@@ -0,0 +1,3 @@
+// Package match contains utilities for matching events against the event type
+// and content type filters specified by reader options.
+package match
@@ -0,0 +1,52 @@
+package metadata
+
+import "encoding/json"
+
+// Marshal returns the stored representation of the event metadata m.
+//
+// It returns nil if m is empty, so that events without metadata do not incur
+// any storage overhead.
+func Marshal(m map[string]string) ([]byte, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(m)
+}
+
+// Unmarshal returns the event metadata stored in data.
+//
+// It returns a nil map if data is empty.
+func Unmarshal(data []byte) (map[string]string, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var m map[string]string
+
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	if len(m) == 0 {
+		return nil, nil
+	}
+
+	return m, nil
+}
+
+// Copy returns a copy of m that does not share storage with m.
+//
+// It returns nil if m is empty.
+func Copy(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+
+	return c
+}
@@ -0,0 +1,70 @@
+package metadata_test
+
+import (
+	. "github.com/jmalloc/gospel/src/internal/metadata"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Marshal", func() {
+	It("returns nil if the metadata is nil", func() {
+		data, err := Marshal(nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(data).To(BeNil())
+	})
+
+	It("returns nil if the metadata is empty", func() {
+		data, err := Marshal(map[string]string{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(data).To(BeNil())
+	})
+
+	It("returns data that can be unmarshaled", func() {
+		m := map[string]string{
+			"correlation-id": "<correlation>",
+			"causation-id":   "<causation>",
+		}
+
+		data, err := Marshal(m)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		u, err := Unmarshal(data)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(u).To(Equal(m))
+	})
+})
+
+var _ = Describe("Unmarshal", func() {
+	It("returns nil if the data is empty", func() {
+		m, err := Unmarshal(nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(m).To(BeNil())
+	})
+
+	It("returns nil if the data contains an empty map", func() {
+		m, err := Unmarshal([]byte(`{}`))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(m).To(BeNil())
+	})
+
+	It("returns an error if the data is malformed", func() {
+		_, err := Unmarshal([]byte(`<malformed>`))
+		Expect(err).Should(HaveOccurred())
+	})
+})
+
+var _ = Describe("Copy", func() {
+	It("returns nil if the metadata is empty", func() {
+		Expect(Copy(map[string]string{})).To(BeNil())
+	})
+
+	It("returns a copy that does not share storage with the original", func() {
+		m := map[string]string{"key": "value"}
+
+		c := Copy(m)
+		Expect(c).To(Equal(m))
+
+		c["key"] = "other"
+		Expect(m["key"]).To(Equal("value"))
+	})
+})
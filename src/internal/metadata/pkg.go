@@ -0,0 +1,3 @@
+// Package metadata contains utilities for encoding event metadata for storage
+// by event store implementations.
+package metadata
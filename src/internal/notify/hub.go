@@ -0,0 +1,97 @@
+package notify
+
+import "sync"
+
+// Hub delivers wake-up notifications to goroutines that are waiting for some
+// event, typically the appending of new facts to a specific stream.
+//
+// Waiters and notifiers are matched by an arbitrary key, which must be
+// comparable.
+//
+// The zero-value is a hub with no waiters, ready to use.
+type Hub struct {
+	m       sync.Mutex
+	waiters map[interface{}]*waiter
+}
+
+// waiter is the state shared by all goroutines waiting on the same key.
+type waiter struct {
+	ch   chan struct{}
+	refs int
+}
+
+// Wait returns a channel that is closed the next time Notify() is called with
+// key k, or when NotifyAll() is called.
+//
+// Callers must obtain the channel before checking for the condition they are
+// waiting on, otherwise a notification that occurs between the check and the
+// call to Wait() may be missed.
+//
+// release must be called once the caller is no longer waiting on the channel,
+// whether or not it has been closed, so that the hub can discard keys that
+// no longer have any waiters. It is safe to call release more than once.
+func (h *Hub) Wait(k interface{}) (ch <-chan struct{}, release func()) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	w, ok := h.waiters[k]
+	if !ok {
+		if h.waiters == nil {
+			h.waiters = map[interface{}]*waiter{}
+		}
+
+		w = &waiter{ch: make(chan struct{})}
+		h.waiters[k] = w
+	}
+
+	w.refs++
+	released := false
+
+	return w.ch, func() {
+		h.m.Lock()
+		defer h.m.Unlock()
+
+		if released {
+			return
+		}
+
+		released = true
+		w.refs--
+
+		// The entry may already have been removed by a notification, in
+		// which case k may now refer to a different waiter.
+		if w.refs == 0 && h.waiters[k] == w {
+			delete(h.waiters, k)
+		}
+	}
+}
+
+// Len returns the number of keys that currently have waiters.
+func (h *Hub) Len() int {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	return len(h.waiters)
+}
+
+// Notify wakes all goroutines that are waiting on key k.
+func (h *Hub) Notify(k interface{}) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	if w, ok := h.waiters[k]; ok {
+		close(w.ch)
+		delete(h.waiters, k)
+	}
+}
+
+// NotifyAll wakes all waiting goroutines, regardless of their key.
+func (h *Hub) NotifyAll() {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	for k, w := range h.waiters {
+		close(w.ch)
+		delete(h.waiters, k)
+	}
+}
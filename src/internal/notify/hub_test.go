@@ -0,0 +1,116 @@
+package notify_test
+
+import (
+	. "github.com/jmalloc/gospel/src/internal/notify"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Hub", func() {
+	var hub *Hub
+
+	BeforeEach(func() {
+		hub = &Hub{}
+	})
+
+	// wait returns the channel returned by hub.Wait(k).
+	wait := func(k interface{}) <-chan struct{} {
+		ch, _ := hub.Wait(k)
+		return ch
+	}
+
+	Describe("Wait", func() {
+		It("returns a channel that is not closed", func() {
+			Expect(wait("foo")).NotTo(BeClosed())
+		})
+
+		It("returns the same channel to multiple waiters of the same key", func() {
+			Expect(wait("foo")).To(Equal(wait("foo")))
+		})
+
+		It("discards the key once every waiter has released it", func() {
+			_, release1 := hub.Wait("foo")
+			_, release2 := hub.Wait("foo")
+
+			release1()
+			Expect(hub.Len()).To(Equal(1))
+
+			release2()
+			Expect(hub.Len()).To(Equal(0))
+		})
+
+		It("ignores repeated calls to release", func() {
+			_, release1 := hub.Wait("foo")
+			_, release2 := hub.Wait("foo")
+
+			release1()
+			release1()
+
+			Expect(hub.Len()).To(Equal(1))
+
+			release2()
+		})
+
+		It("does not discard a new waiter when releasing after a notification", func() {
+			_, release := hub.Wait("foo")
+			hub.Notify("foo")
+
+			ch := wait("foo")
+			release()
+
+			Expect(hub.Len()).To(Equal(1))
+
+			hub.Notify("foo")
+			Expect(ch).To(BeClosed())
+		})
+	})
+
+	Describe("Notify", func() {
+		It("closes the channel for the given key", func() {
+			ch := wait("foo")
+
+			hub.Notify("foo")
+
+			Expect(ch).To(BeClosed())
+		})
+
+		It("does not close the channel for other keys", func() {
+			ch := wait("bar")
+
+			hub.Notify("foo")
+
+			Expect(ch).NotTo(BeClosed())
+		})
+
+		It("does not affect waiters that begin waiting after the notification", func() {
+			wait("foo")
+			hub.Notify("foo")
+
+			Expect(wait("foo")).NotTo(BeClosed())
+		})
+
+		It("includes type when matching key values", func() {
+			type keyType1 string
+			type keyType2 string
+
+			ch := wait(keyType1("foo"))
+
+			hub.Notify(keyType2("foo"))
+
+			Expect(ch).NotTo(BeClosed())
+		})
+	})
+
+	Describe("NotifyAll", func() {
+		It("closes the channels for all keys", func() {
+			ch1 := wait("foo")
+			ch2 := wait("bar")
+
+			hub.NotifyAll()
+
+			Expect(ch1).To(BeClosed())
+			Expect(ch2).To(BeClosed())
+			Expect(hub.Len()).To(Equal(0))
+		})
+	})
+})
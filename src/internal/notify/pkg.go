@@ -0,0 +1,3 @@
+// Package notify contains utilities for waking readers when new facts are
+// appended to a stream.
+package notify
@@ -1,11 +1,29 @@
 package options
 
+import "time"
+
 // ReaderOptions is a struct that contains the options applied by ReaderOption
 // functions.
 type ReaderOptions struct {
-	FilterByEventType bool
-	EventTypes        []string
-	extra             map[interface{}]interface{}
+	FilterByEventType   bool
+	EventTypes          []string
+	EventTypePrefixes   []string
+	EventTypePatterns   []string
+	ExcludedEventTypes  []string
+	FilterByContentType bool
+	ContentTypes        []string
+	ContentTypePrefixes []string
+	FilterByStream      bool
+	Streams             []string
+	StreamPrefixes      []string
+	StreamPatterns      []string
+	FilterByTime        bool
+	FromTime            time.Time
+	Reverse             bool
+	StopAtOffset        bool
+	StopOffset          uint64
+	StopAtHead          bool
+	extra               map[interface{}]interface{}
 }
 
 // ReaderOption is a function that applies a reader option to a ReaderOptions
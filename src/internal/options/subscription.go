@@ -0,0 +1,33 @@
+package options
+
+import "time"
+
+// SubscriptionOptions is a struct that contains the options applied by
+// SubscriptionOption functions.
+type SubscriptionOptions struct {
+	CommitEvery    int
+	CommitInterval time.Duration
+	ReaderOptions  []ReaderOption
+}
+
+// SubscriptionOption is a function that applies a subscription option to a
+// SubscriptionOptions struct.
+type SubscriptionOption func(o *SubscriptionOptions)
+
+// NewSubscriptionOptions returns a new SubscriptionOptions struct with opts
+// applied.
+//
+// If no commit policy is specified, the subscription commits after every fact.
+func NewSubscriptionOptions(opts []SubscriptionOption) *SubscriptionOptions {
+	o := &SubscriptionOptions{}
+
+	for _, fn := range opts {
+		fn(o)
+	}
+
+	if o.CommitEvery == 0 && o.CommitInterval == 0 {
+		o.CommitEvery = 1
+	}
+
+	return o
+}
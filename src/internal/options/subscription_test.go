@@ -0,0 +1,50 @@
+package options_test
+
+import (
+	"time"
+
+	. "github.com/jmalloc/gospel/src/internal/options"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewSubscriptionOptions", func() {
+	// commitEvery and commitInterval are subscription options that set the
+	// commit policy.
+	commitEvery := func(n int) SubscriptionOption {
+		return func(o *SubscriptionOptions) { o.CommitEvery = n }
+	}
+
+	commitInterval := func(d time.Duration) SubscriptionOption {
+		return func(o *SubscriptionOptions) { o.CommitInterval = d }
+	}
+
+	It("applies the provided options", func() {
+		opts := NewSubscriptionOptions(
+			[]SubscriptionOption{
+				commitEvery(10),
+				commitInterval(time.Second),
+			},
+		)
+
+		Expect(opts.CommitEvery).To(Equal(10))
+		Expect(opts.CommitInterval).To(Equal(time.Second))
+	})
+
+	It("commits after every fact if no commit policy is specified", func() {
+		opts := NewSubscriptionOptions(nil)
+
+		Expect(opts.CommitEvery).To(Equal(1))
+		Expect(opts.CommitInterval).To(BeZero())
+	})
+
+	It("does not commit after every fact if only a commit interval is specified", func() {
+		opts := NewSubscriptionOptions(
+			[]SubscriptionOption{
+				commitInterval(time.Second),
+			},
+		)
+
+		Expect(opts.CommitEvery).To(BeZero())
+	})
+})
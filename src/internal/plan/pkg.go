@@ -0,0 +1,3 @@
+// Package plan contains utilities for verifying appends against the state of
+// an event store that is held in memory, such as gospelmem and gospelfile.
+package plan
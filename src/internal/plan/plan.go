@@ -0,0 +1,154 @@
+package plan
+
+import (
+	"github.com/jmalloc/gospel/src/gospel"
+	"github.com/jmalloc/gospel/src/internal/apierror"
+	"github.com/jmalloc/gospel/src/internal/dedupe"
+)
+
+// State is an interface for querying the state of an event store.
+type State interface {
+	// NextOffset returns the next unused offset of the given stream.
+	NextOffset(stream string) uint64
+
+	// LookupID returns the address of the event with the given ID. ok is false
+	// if no such event has been appended.
+	LookupID(id string) (addr gospel.Address, ok bool)
+}
+
+// Check panics if appends is not a valid argument to AppendMulti().
+func Check(appends []gospel.StreamAppend) {
+	if len(appends) == 0 {
+		panic("no appends provided")
+	}
+
+	for _, a := range appends {
+		if a.Stream == "" {
+			panic("can not append to the ε-stream")
+		}
+
+		if len(a.Events) == 0 {
+			panic("no events provided")
+		}
+	}
+}
+
+// Append verifies that an append of events to stream is allowed by the IDs of
+// the events.
+//
+// If the append is a repeat of an earlier append, repeat is true and nx is the
+// address of the next unused offset after the original append, in which case
+// the append must not be performed. If the append is not a repeat, but
+// contains events that have already been appended, a conflict error is
+// returned. If two of the events have the same ID, a non-conflict error is
+// returned.
+func Append(
+	s State,
+	stream string,
+	events []gospel.Event,
+) (nx gospel.Address, repeat bool, err error) {
+	return checkIDs(
+		s,
+		gospel.Address{
+			Stream: stream,
+			Offset: s.NextOffset(stream),
+		},
+		events,
+		nil,
+	)
+}
+
+// Appends verifies that each of the appends is allowed, checking each against
+// the state of the store after the preceding appends.
+//
+// It returns the address of the next unused offset after each append, and
+// whether each append is a repeat of an earlier append, in which case it must
+// not be performed.
+//
+// It returns a conflict error if any append is not allowed, or a non-conflict
+// error if two events within the same append have the same ID.
+func Appends(
+	s State,
+	appends []gospel.StreamAppend,
+) ([]gospel.Address, []bool, error) {
+	addrs := make([]gospel.Address, len(appends))
+	repeats := make([]bool, len(appends))
+	pendingNext := map[string]uint64{}
+	pendingIDs := map[string]gospel.Address{}
+
+	for i, a := range appends {
+		next := gospel.Address{
+			Stream: a.Stream,
+			Offset: s.NextOffset(a.Stream) + pendingNext[a.Stream],
+		}
+
+		nx, repeat, err := checkIDs(s, next, a.Events, pendingIDs)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if repeat {
+			addrs[i] = nx
+			repeats[i] = true
+			continue
+		}
+
+		if !a.Expectation.IsSatisfiedBy(next.Offset) {
+			return nil, nil, apierror.NewExpectationConflict(
+				a.Stream,
+				a.Expectation,
+				a.Events[0],
+				next,
+			)
+		}
+
+		for _, ev := range a.Events {
+			if ev.ID != "" {
+				pendingIDs[ev.ID] = next
+			}
+
+			next.Offset++
+		}
+
+		pendingNext[a.Stream] += uint64(len(a.Events))
+		addrs[i] = next
+	}
+
+	return addrs, repeats, nil
+}
+
+// checkIDs compares an append of events at next with the events that have
+// already been appended to the store, or that are pending within the same
+// operation.
+func checkIDs(
+	s State,
+	next gospel.Address,
+	events []gospel.Event,
+	pending map[string]gospel.Address,
+) (nx gospel.Address, repeat bool, err error) {
+	ids, err := dedupe.IDs(events)
+	if err != nil {
+		return nx, false, err
+	}
+
+	prev := map[string]gospel.Address{}
+
+	for _, id := range ids {
+		if addr, ok := pending[id]; ok {
+			prev[id] = addr
+		} else if addr, ok := s.LookupID(id); ok {
+			prev[id] = addr
+		}
+	}
+
+	o, addr, ev := dedupe.Compare(next.Stream, events, prev)
+
+	switch o {
+	case dedupe.Repeat:
+		return addr, true, nil
+	case dedupe.Conflict:
+		return nx, false, apierror.NewDuplicateConflict(ev, addr, next)
+	default:
+		return nx, false, nil
+	}
+}
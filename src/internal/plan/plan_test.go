@@ -0,0 +1,154 @@
+package plan_test
+
+import (
+	"github.com/jmalloc/gospel/src/gospel"
+	. "github.com/jmalloc/gospel/src/internal/plan"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// state is a State backed by maps.
+type state struct {
+	next map[string]uint64
+	ids  map[string]gospel.Address
+}
+
+func (s state) NextOffset(stream string) uint64 {
+	return s.next[stream]
+}
+
+func (s state) LookupID(id string) (gospel.Address, bool) {
+	addr, ok := s.ids[id]
+	return addr, ok
+}
+
+var _ = Describe("Check", func() {
+	It("panics if there are no appends", func() {
+		Expect(func() {
+			Check(nil)
+		}).To(Panic())
+	})
+
+	It("panics if an append is to the ε-stream", func() {
+		Expect(func() {
+			Check([]gospel.StreamAppend{
+				{Events: []gospel.Event{{EventType: "event-type"}}},
+			})
+		}).To(Panic())
+	})
+
+	It("panics if an append has no events", func() {
+		Expect(func() {
+			Check([]gospel.StreamAppend{
+				{Stream: "test-stream"},
+			})
+		}).To(Panic())
+	})
+})
+
+var _ = Describe("Append", func() {
+	var s state
+
+	BeforeEach(func() {
+		s = state{
+			next: map[string]uint64{"test-stream": 2},
+			ids: map[string]gospel.Address{
+				"id-1": {Stream: "test-stream", Offset: 0},
+				"id-2": {Stream: "test-stream", Offset: 1},
+			},
+		}
+	})
+
+	It("allows an append of new events", func() {
+		_, repeat, err := Append(s, "test-stream", []gospel.Event{{ID: "id-3"}})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(repeat).To(BeFalse())
+	})
+
+	It("returns the original next address if the append is a repeat", func() {
+		nx, repeat, err := Append(s, "test-stream", []gospel.Event{{ID: "id-1"}, {ID: "id-2"}})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(repeat).To(BeTrue())
+		Expect(nx).To(Equal(gospel.Address{Stream: "test-stream", Offset: 2}))
+	})
+
+	It("returns a conflict error if only some of the events were appended previously", func() {
+		_, _, err := Append(s, "test-stream", []gospel.Event{{ID: "id-2"}, {ID: "id-3"}})
+
+		Expect(gospel.IsConflict(err)).To(BeTrue())
+	})
+
+	It("returns a non-conflict error if two of the events have the same ID", func() {
+		_, _, err := Append(s, "test-stream", []gospel.Event{{ID: "id-3"}, {ID: "id-3"}})
+
+		Expect(err).Should(HaveOccurred())
+		Expect(gospel.IsConflict(err)).To(BeFalse())
+	})
+})
+
+var _ = Describe("Appends", func() {
+	var s state
+
+	BeforeEach(func() {
+		s = state{
+			next: map[string]uint64{"stream-a": 1},
+			ids: map[string]gospel.Address{
+				"id-1": {Stream: "stream-a", Offset: 0},
+			},
+		}
+	})
+
+	It("returns the next address after each append, accounting for the preceding appends", func() {
+		addrs, repeats, err := Appends(s, []gospel.StreamAppend{
+			{Stream: "stream-a", Events: []gospel.Event{{}, {}}},
+			{Stream: "stream-b", Events: []gospel.Event{{}}},
+			{Stream: "stream-a", Events: []gospel.Event{{}}},
+		})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(addrs).To(Equal([]gospel.Address{
+			{Stream: "stream-a", Offset: 3},
+			{Stream: "stream-b", Offset: 1},
+			{Stream: "stream-a", Offset: 4},
+		}))
+		Expect(repeats).To(Equal([]bool{false, false, false}))
+	})
+
+	It("reports appends that are repeats of earlier appends", func() {
+		addrs, repeats, err := Appends(s, []gospel.StreamAppend{
+			{Stream: "stream-a", Events: []gospel.Event{{ID: "id-1"}}},
+			{Stream: "stream-b", Events: []gospel.Event{{}}},
+		})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(addrs).To(Equal([]gospel.Address{
+			{Stream: "stream-a", Offset: 1},
+			{Stream: "stream-b", Offset: 1},
+		}))
+		Expect(repeats).To(Equal([]bool{true, false}))
+	})
+
+	It("returns a conflict error if an event ID is used by a preceding append", func() {
+		_, _, err := Appends(s, []gospel.StreamAppend{
+			{Stream: "stream-b", Events: []gospel.Event{{ID: "id-2"}}},
+			{Stream: "stream-c", Events: []gospel.Event{{ID: "id-2"}, {ID: "id-3"}}},
+		})
+
+		Expect(gospel.IsConflict(err)).To(BeTrue())
+	})
+
+	It("returns a conflict error if an expectation is not satisfied", func() {
+		_, _, err := Appends(s, []gospel.StreamAppend{
+			{Stream: "stream-a", Events: []gospel.Event{{}}},
+			{
+				Stream:      "stream-a",
+				Expectation: gospel.ExpectOffset(1),
+				Events:      []gospel.Event{{}},
+			},
+		})
+
+		Expect(gospel.IsConflict(err)).To(BeTrue())
+	})
+})